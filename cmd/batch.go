@@ -0,0 +1,81 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+
+	log "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+// Whether the arguments name more than one book, i.e. several files, a glob or a directory
+func isBatch(args []string) bool {
+	if len(args) != 1 {
+		return true
+	}
+	if lib.IsUrl(args[0]) || args[0] == internal.StdioPath {
+		return false
+	}
+	if internal.IsGlob(args[0]) {
+		return true
+	}
+	info, err := os.Stat(args[0])
+	return err == nil && info.IsDir()
+}
+
+// Convert every book the arguments name and print a summary of how each went
+func runBatch(cmd *cobra.Command, args []string) error {
+	inputs, err := internal.ExpandInputs(args, config.GetBool("recursive"))
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("no books to convert were found in %s", strings.Join(args, ", "))
+	}
+
+	conf := audiobookArgs(cmd, "")
+	log.Infof("Converting %d books with model: %s", len(inputs), conf.Model)
+	results := internal.Batch(conf, inputs)
+
+	if err := printBatchSummary(cmd.OutOrStdout(), results); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d books failed to convert", failed, len(results))
+	}
+	return nil
+}
+
+// Print a table with the outcome of each book of a batch
+func printBatchSummary(output io.Writer, results []internal.BatchResult) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "STATUS\tINPUT\tOUTPUT\tTIME\t")
+	for _, result := range results {
+		status, detail, took := "ok", result.Output, result.Duration.Round(time.Second).String()
+		switch {
+		case result.Err != nil:
+			status, detail = "failed", strings.ReplaceAll(result.Err.Error(), "\n", " ")
+		case result.Skipped:
+			status, took = "skipped", "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t\n", status, result.Input, detail, took)
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,44 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBatch(t *testing.T) {
+	require.False(t, isBatch([]string{"testdata/titlepage_and_2_chapters.epub"}))
+	require.False(t, isBatch([]string{"-"}))
+	require.False(t, isBatch([]string{"https://example.com/book.epub"}))
+	require.True(t, isBatch([]string{"testdata"}))
+	require.True(t, isBatch([]string{"testdata/*.epub"}))
+	require.True(t, isBatch([]string{"a.txt", "b.txt"}))
+
+	book := filepath.Join(t.TempDir(), "Book [2020].epub")
+	require.NoError(t, os.WriteFile(book, []byte("book"), 0644))
+	require.False(t, isBatch([]string{book}))
+}
+
+func TestPrintBatchSummary(t *testing.T) {
+	var output bytes.Buffer
+	err := printBatchSummary(&output, []internal.BatchResult{
+		{Input: "a.txt", Output: "out/a.wav", Duration: 61 * time.Second},
+		{Input: "b.txt", Output: "out/b.wav", Skipped: true},
+		{Input: "c.pdf", Err: fmt.Errorf("ebook-convert failed\nbadly")},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "STATUS   INPUT  OUTPUT                      TIME  \n"+
+		"ok       a.txt  out/a.wav                   1m1s  \n"+
+		"skipped  b.txt  out/b.wav                   -     \n"+
+		"failed   c.pdf  ebook-convert failed badly  0s    \n", output.String())
+}
@@ -0,0 +1,30 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+}
+
+var buildCmd = &cobra.Command{
+	Use:   "build [" + internal.ProjectFileName + "]",
+	Short: "Build an audiobook from a project file",
+	Long: "Build the audiobook described by a project file (default ./" + internal.ProjectFileName + ") listing its sources, chapters, voices, speed, lexicon, formats and tags. " +
+		"The audio of chapters that did not change is reused from the last build. Options not set in the project file come from the flags and config file",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		projectPath := internal.ProjectFileName
+		if len(args) == 1 {
+			projectPath = args[0]
+		}
+		_, err := internal.Build(audiobookArgs(cmd, ""), projectPath)
+		return err
+	},
+}
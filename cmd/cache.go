@@ -0,0 +1,42 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Work with the cache of converted text and chapter audio",
+	Long: "The text ebook-convert makes from each file is cached by a hash of the file, and the audio of each chapter by a hash of its normalized text, voice and speed, " +
+		"so that rebuilding a book only converts and reads the chapters that changed. The least recently used entries are removed when it grows past --cache-size",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all cached text and chapter audio",
+	Long:  "Remove the audio and text subdirectories of the cache directory; anything else in it is left alone",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cacheLocation()
+		if err != nil {
+			return err
+		}
+		if err := internal.ClearCache(dir); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Removed the cached text and audio in %s\n", dir)
+		return nil
+	},
+}
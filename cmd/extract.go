@@ -0,0 +1,45 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	extractCmd.Flags().String("dir", "", "Directory to write the text files and manifest to (default <output>/<book name>)")
+	rootCmd.AddCommand(extractCmd)
+}
+
+var extractCmd = &cobra.Command{
+	Use:   "extract <file>",
+	Short: "Write the text of a book to a directory to edit before it is synthesized",
+	Long: "Convert a book to text with the same section selection and cleanup as a conversion and write one numbered text file per section along with a " +
+		internal.ManifestName + " of their titles, order and voice. Edit, reorder or delete the files and then build the audiobook with the synthesize command",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			return err
+		}
+		conf := audiobookArgs(cmd, args[0])
+		if dir == "" {
+			name := filepath.Base(conf.FileName)
+			dir = filepath.Join(conf.OutputDirectory, strings.TrimSuffix(name, filepath.Ext(name)))
+		}
+
+		manifest, err := internal.Extract(conf, dir)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Wrote %d sections to %s\n", len(manifest.Sections), dir)
+		return nil
+	},
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/iconv"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	lexiconTestCmd.Flags().String("book", "", "Also use the sidecar lexicon of this book")
+	lexiconCmd.AddCommand(lexiconTestCmd)
+	rootCmd.AddCommand(lexiconCmd)
+}
+
+var lexiconCmd = &cobra.Command{
+	Use:   "lexicon",
+	Short: "Work with pronunciation lexicons",
+	Long: `Pronunciation lexicons respell words that piper mispronounces before they are read.
+Entries in ~/.config/QuickPiperAudiobook/lexicon.yaml apply to every book and entries in
+<book>.lexicon.yaml next to a book apply only to that book, i.e.
+
+- word: Hermione
+  say: her my oh nee
+- regex: '\bv(\d+)\.(\d+)'
+  say: version $1 point $2`,
+}
+
+var lexiconTestCmd = &cobra.Command{
+	Use:   "test <word or phrase>",
+	Short: "Synthesize a word with the lexicon applied to hear how it is read",
+	Long:  "Apply the lexicon and text normalization to a word or phrase, print what will be read, and save it as lexicon-test.wav in the output directory",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		book, err := cmd.Flags().GetString("book")
+		if err != nil {
+			return err
+		}
+
+		iconv.SetOverrides(transliterationOverrides())
+		spoken, wav, err := internal.LexiconTest(internal.AudiobookArgs{
+			FileName:        book,
+			Model:           config.GetString("model"),
+			OutputDirectory: config.GetString("output"),
+			SpeakUTF8:       config.GetBool("speak-utf-8"),
+			DownloadSources: downloadSources(),
+			Normalize:       config.GetBool("normalize"),
+			NormalizeSkip:   config.GetStringSlice("normalize-skip"),
+			LexiconFiles:    config.GetStringSlice("lexicon"),
+		}, strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+
+		cmd.Printf("Read as: %s\nSaved to: %s\n", spoken, wav)
+		return nil
+	},
+}
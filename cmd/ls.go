@@ -4,7 +4,10 @@
 package cmd
 
 import (
-	"path/filepath"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
 
@@ -12,21 +15,61 @@ import (
 )
 
 func init() {
-	rootCmd.AddCommand(versionCmd)
+	lsCmd.Flags().Bool("json", false, "Output the list of models as JSON")
+	rootCmd.AddCommand(lsCmd)
 }
 
-var versionCmd = &cobra.Command{
+// An installed model along with whether it is the configured default
+type listedModel struct {
+	piper.ModelInfo
+	Default bool `json:"default"`
+}
+
+var lsCmd = &cobra.Command{
 	Use:     "ls",
 	Aliases: []string{"list-models"},
 	Short:   "List the models that are installed",
-	Long:    "List all the models that are installed in ~/.config/QuickPiperAudiobook",
-	Run: func(cmd *cobra.Command, args []string) {
-		models, err := piper.FindModels("~/.config/QuickPiperAudiobook")
+	Long:    "List all the models that are installed in ~/.config/QuickPiperAudiobook along with their language, quality, and size",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		paths, err := piper.FindModels("~/.config/QuickPiperAudiobook")
 		if err != nil {
-			cmd.PrintErrln(err)
+			return err
 		}
+
+		defaultModel := strings.TrimSuffix(config.GetString("model"), ".onnx") + ".onnx"
+
+		models := []listedModel{}
+		for _, path := range paths {
+			info, err := piper.ReadModelInfo(path)
+			if err != nil {
+				cmd.PrintErrln(err)
+				continue
+			}
+			models = append(models, listedModel{ModelInfo: info, Default: info.Name == defaultModel})
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(models)
+		}
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "\tNAME\tLANGUAGE\tQUALITY\tSAMPLE RATE\tSPEAKERS\tSIZE")
 		for _, model := range models {
-			cmd.Println(filepath.Base(model))
+			marker := ""
+			if model.Default {
+				marker = "*"
+			}
+			fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%d\t%d\t%.1f MB\n",
+				marker, model.Name, model.Language, model.Quality, model.SampleRate, model.NumSpeakers,
+				float64(model.SizeBytes)/(1024*1024))
 		}
+		return writer.Flush()
 	},
 }
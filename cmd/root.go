@@ -5,55 +5,147 @@ package cmd
 
 import (
 	"os"
+	"strings"
 
 	log "github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/iconv"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/filters"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/library"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/normalize"
 )
 
 var config *viper.Viper
 
+// The model used when none is given and the book's language does not pick one
+const defaultModel = "en_US-hfc_male-medium.onnx"
+
 // Root command for the CLI
 var rootCmd = &cobra.Command{
-	Use:   "QuickPiperAudiobook <file>",
+	Use:   "QuickPiperAudiobook <file>...",
 	Short: "Converts a text file into an audiobook",
-	Long:  "Convert text files from various formats into an audiobook",
-	Args:  cobra.ExactArgs(1),
+	Long:  "Convert text files from various formats into an audiobook. Use - as the file to read from standard input and --output - to write to standard output. Pass several files, globs or directories to convert them all",
+	Args:  cobra.MinimumNArgs(1),
 	RunE:  runAudiobookConversion,
 }
 
 func runAudiobookConversion(cmd *cobra.Command, args []string) error {
-	filePath := args[0]
-	model := config.GetString("model")
-	outDir := config.GetString("output")
-	speakUTF8 := config.GetBool("speak-utf-8")
-	outputMp3 := config.GetBool("mp3")
-	chapters := config.GetBool("chapters")
-	threads := config.GetInt("threads")
-	verbose := config.GetBool("verbose")
-
-	if verbose {
-		log.SetLevel(log.DebugLevel)
+	if isBatch(args) {
+		return runBatch(cmd, args)
 	}
 
-	log.Infof("Processing file: %s with model: %s", filePath, model)
-
-	conf := internal.AudiobookArgs{
-		FileName:        filePath,
-		Model:           model,
-		OutputDirectory: outDir,
-		SpeakUTF8:       speakUTF8,
-		OutputAsMp3:     outputMp3,
-		Chapters:        chapters,
-		Threads:         threads,
+	conf := audiobookArgs(cmd, args[0])
+	if conf.FileName == internal.StdioPath {
+		log.Infof("Processing %s from standard input with model: %s", conf.InputFormat, conf.Model)
+	} else {
+		log.Infof("Processing file: %s with model: %s", conf.FileName, conf.Model)
 	}
 
 	_, err := internal.QuickPiperAudiobook(conf)
 	return err
 }
 
+// Build the conversion options for a file from the flags and config file
+func audiobookArgs(cmd *cobra.Command, filePath string) internal.AudiobookArgs {
+	if config.GetBool("verbose") {
+		log.SetLevel(log.DebugLevel)
+	}
+	iconv.SetOverrides(transliterationOverrides())
+
+	return internal.AudiobookArgs{
+		FileName:        filePath,
+		Model:           config.GetString("model"),
+		OutputDirectory: config.GetString("output"),
+		SpeakUTF8:       config.GetBool("speak-utf-8"),
+		OutputAsMp3:     config.GetBool("mp3"),
+		Chapters:        config.GetBool("chapters"),
+		Threads:         config.GetInt("threads"),
+		DownloadSources: downloadSources(),
+		AutoVoice:       config.GetBool("auto-voice") && !modelIsExplicit(cmd),
+		LanguageVoices:  config.GetStringMapString("voices"),
+		Multilingual:    config.GetBool("multilingual"),
+		Normalize:       config.GetBool("normalize"),
+		NormalizeSkip:   config.GetStringSlice("normalize-skip"),
+		LexiconFiles:    config.GetStringSlice("lexicon"),
+		Sections:        selectedSections(),
+		Skip:            config.GetStringSlice("skip"),
+		PdfCleanup:      config.GetBool("pdf-cleanup"),
+		EbookConvert:    config.GetBool("ebook-convert"),
+		FilterPresets:   config.GetStringSlice("filter-presets"),
+		DropLines:       config.GetStringSlice("drop-lines"),
+		Replacements:    config.GetStringSlice("replace"),
+		CacheDir:        cacheDir(),
+		CacheSize:       int64(config.GetInt("cache-size")) << 20,
+		InputFormat:     config.GetString("input-format"),
+		Feed:            config.GetBool("feed"),
+		OPDS:            config.GetBool("opds"),
+		FeedURL:         config.GetString("feed-url"),
+	}
+}
+
+// Whether the user asked for a specific model with --model, the config file or
+// QUICKPIPERAUDIOBOOK_MODEL rather than relying on the default, in which case the
+// voice is not picked from the book's language. Asking for the default model counts too
+func modelIsExplicit(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("model") || config.InConfig("model") {
+		return true
+	}
+	_, inEnv := os.LookupEnv("QUICKPIPERAUDIOBOOK_MODEL")
+	return inEnv
+}
+
+// Read where to cache the audio of chapters and converted text; empty if caching is turned off
+func cacheDir() string {
+	if !config.GetBool("cache") {
+		return ""
+	}
+	dir, err := cacheLocation()
+	if err != nil {
+		log.Warnf("Not caching audio: %v", err)
+		return ""
+	}
+	return dir
+}
+
+// Read the cache directory from --cache-dir or use the default one
+func cacheLocation() (string, error) {
+	if dir := config.GetString("cache-dir"); dir != "" {
+		return internal.ExpandPath(dir)
+	}
+	return internal.DefaultCacheDir()
+}
+
+// Read the sections to convert from --sections and --chapters-range
+func selectedSections() []string {
+	sections := config.GetStringSlice("sections")
+	if chaptersRange := config.GetString("chapters-range"); chaptersRange != "" {
+		sections = append(sections, chaptersRange)
+	}
+	return sections
+}
+
+// Read where to download piper and its models from
+func downloadSources() piper.DownloadSources {
+	return piper.DownloadSources{
+		ModelBaseURL:    config.GetString("model-base-url"),
+		CatalogURL:      config.GetString("catalog-url"),
+		PiperReleaseURL: config.GetString("piper-release-url"),
+	}
+}
+
+// Read the per language transliteration tables from the config file
+func transliterationOverrides() map[string]map[string]string {
+	overrides := make(map[string]map[string]string)
+	for language := range config.GetStringMap("transliteration") {
+		overrides[language] = config.GetStringMapString("transliteration." + language)
+	}
+	return overrides
+}
+
 func init() {
 	// Initialize configuration instance
 	config = viper.New()
@@ -61,17 +153,47 @@ func init() {
 	// Define CLI flags
 	rootCmd.PersistentFlags().String("config", "", "Path to the config file (default ~/.config/QuickPiperAudiobook/config.yaml)")
 	rootCmd.PersistentFlags().Bool("speak-utf-8", false, "Enable UTF-8 character speech (don't strip out UTF-8 characters like Chinese or diacritics)")
-	rootCmd.PersistentFlags().String("model", "en_US-hfc_male-medium.onnx", "Speech synthesis model to use")
-	rootCmd.PersistentFlags().String("output", ".", "Output directory for the audiobook")
+	rootCmd.PersistentFlags().String("model", defaultModel, "Speech synthesis model to use")
+	rootCmd.PersistentFlags().String("output", ".", "Output directory for the audiobook, or - to write the audio to standard output")
+	rootCmd.PersistentFlags().String("input-format", internal.DefaultInputFormat, "Format of a book read from standard input with - as the file i.e. epub, md, html or pdf")
 	rootCmd.PersistentFlags().Bool("mp3", false, "Export audiobook as MP3 (requires ffmpeg)")
-	rootCmd.PersistentFlags().Bool("chapters", false, "Split audiobook into chapters (requires ffmpeg & epub input)")
-	rootCmd.PersistentFlags().Int("threads", 4, "Number of threads for chapter splitting (if applicable)")
+	rootCmd.PersistentFlags().Bool("chapters", false, "Split audiobook into chapters (requires ffmpeg & epub, txt, markdown, html or fb2 input)")
+	rootCmd.PersistentFlags().Int("threads", 4, "Number of threads for chapter splitting (if applicable), shared by all books when converting several")
+	rootCmd.PersistentFlags().Bool("recursive", false, "Also convert the books in subdirectories of the directories that are passed")
 	rootCmd.PersistentFlags().Bool("verbose", false, "Enable verbose logging for debugging")
+	rootCmd.PersistentFlags().Bool("multilingual", false, "Switch voices for paragraphs in other languages using epub lang attributes or detection for text (requires ffmpeg)")
+	rootCmd.PersistentFlags().Bool("auto-voice", true, "Pick the model and UTF-8 handling from the book's language when no model is given with --model, the config file or the environment")
+	rootCmd.PersistentFlags().Bool("normalize", true, "Spell out numbers, dates, currency and abbreviations before an English voice reads them; voices for other languages read the text as is")
+	rootCmd.PersistentFlags().StringSlice("normalize-skip", nil, "Normalization rules to turn off: "+strings.Join(normalize.Rules, ", "))
+	rootCmd.PersistentFlags().StringArray("sections", nil, "Epub sections to convert instead of the whole book, by number, file, or part of the title; see the toc command")
+	rootCmd.PersistentFlags().String("chapters-range", "", "Range of epub sections to convert i.e. 5-7; see the toc command for the numbers")
+	rootCmd.PersistentFlags().StringSlice("skip", internal.DefaultSkip, "Kinds of epub sections to leave out: cover, toc, copyright, frontmatter, bodymatter, backmatter, index, nonlinear")
+	rootCmd.PersistentFlags().Bool("ebook-convert", false, "Convert epub, txt, markdown, html and fb2 files with calibre's ebook-convert instead of the built in readers")
+	rootCmd.PersistentFlags().Bool("pdf-cleanup", true, "Remove repeating headers and footers and rejoin hyphenated words and wrapped lines in PDFs")
+	rootCmd.PersistentFlags().StringSlice("filter-presets", filters.DefaultPresets, "Built in filters to strip boilerplate with: "+strings.Join(filters.PresetNames(), ", "))
+	rootCmd.PersistentFlags().StringArray("drop-lines", nil, "Regexes for lines to remove from the text before reading")
+	rootCmd.PersistentFlags().StringArray("replace", nil, "Find and replace rules for the text written as regex=>replacement")
+	rootCmd.PersistentFlags().StringSlice("lexicon", nil, "Pronunciation lexicon files to use on top of ~/.config/QuickPiperAudiobook/lexicon.yaml and the book's <name>.lexicon.yaml")
+	rootCmd.PersistentFlags().Bool("cache", true, "Cache converted text and the audio of each chapter so chapters that didn't change are not converted or read again")
+	rootCmd.PersistentFlags().String("cache-dir", "", "Directory to cache converted text and the audio of chapters in (default the user cache directory i.e. ~/.cache/QuickPiperAudiobook)")
+	rootCmd.PersistentFlags().Int("cache-size", internal.DefaultCacheSize, "Most megabytes the cache may take up before the least recently used text and audio are removed; 0 for no limit")
+	rootCmd.PersistentFlags().Bool("feed", false, "Keep a podcast feed of the audiobooks in the output directory up to date in its "+library.FeedName)
+	rootCmd.PersistentFlags().Bool("opds", false, "Keep OPDS catalogs of the audiobooks in the output directory up to date in its "+library.CatalogName+" and "+library.CatalogJSONName)
+	rootCmd.PersistentFlags().String("feed-url", "", "Url the output directory is hosted at, which the audiobooks in the podcast feed and OPDS catalogs are linked from; required with --feed")
+	rootCmd.PersistentFlags().String("model-base-url", "", "Base url or {lang}/{voice}/{quality} url template to download models from (default Hugging Face)")
+	rootCmd.PersistentFlags().String("catalog-url", "", "Url of the voices.json model catalog (default derived from the model base url; none for a model url template)")
+	rootCmd.PersistentFlags().String("piper-release-url", "", "Url of the piper release tarball to install (default GitHub)")
 
 	if err := config.BindPFlags(rootCmd.PersistentFlags()); err != nil {
 		log.Fatalf("Error binding flags: %v", err)
 	}
 
+	// Allow any option to be set with an environment variable
+	// i.e. QUICKPIPERAUDIOBOOK_MODEL_BASE_URL for --model-base-url
+	config.SetEnvPrefix("QUICKPIPERAUDIOBOOK")
+	config.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
+	config.AutomaticEnv()
+
 	cobra.OnInitialize(initConfig)
 }
 
@@ -7,8 +7,11 @@ import (
 	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
 
@@ -67,3 +70,45 @@ func TestRootCommand(t *testing.T) {
 	// Ensure the wav file was created
 	requireExistsThenRemove(t, "titlepage_and_2_chapters.wav")
 }
+
+func TestModelIsExplicit(t *testing.T) {
+	globalConfig := config
+	t.Cleanup(func() { config = globalConfig })
+
+	newCommand := func() *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("model", defaultModel, "")
+		config = viper.New()
+		require.NoError(t, config.BindPFlags(cmd.Flags()))
+		config.SetEnvPrefix("QUICKPIPERAUDIOBOOK")
+		config.AutomaticEnv()
+		return cmd
+	}
+
+	cmd := newCommand()
+	require.False(t, modelIsExplicit(cmd))
+
+	cmd = newCommand()
+	require.NoError(t, cmd.Flags().Set("model", "pl_PL-gosia-medium.onnx"))
+	require.True(t, modelIsExplicit(cmd))
+
+	// a model in the config file
+	cmd = newCommand()
+	config.SetConfigType("yaml")
+	require.NoError(t, config.ReadConfig(strings.NewReader("model: pl_PL-gosia-medium.onnx\n")))
+	require.True(t, modelIsExplicit(cmd))
+
+	// pinning the default model in the config file keeps it from being replaced
+	cmd = newCommand()
+	config.SetConfigType("yaml")
+	require.NoError(t, config.ReadConfig(strings.NewReader("model: "+defaultModel+"\n")))
+	require.True(t, modelIsExplicit(cmd))
+
+	t.Setenv("QUICKPIPERAUDIOBOOK_MODEL", "de_DE-thorsten-medium.onnx")
+	cmd = newCommand()
+	require.True(t, modelIsExplicit(cmd))
+
+	t.Setenv("QUICKPIPERAUDIOBOOK_MODEL", defaultModel)
+	cmd = newCommand()
+	require.True(t, modelIsExplicit(cmd))
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/server"
+
+	log "github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	serveCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().String("data-dir", "", "Directory to keep jobs and audiobooks in (default ~/.config/QuickPiperAudiobook/server)")
+	serveCmd.Flags().Int("workers", 1, "Number of books to convert at the same time")
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Convert books submitted to a web page or a REST API",
+	Long: "Start a server that converts books uploaded to its web page or REST API or downloaded from a url, with the same options as a conversion. " +
+		"Jobs are kept in the data directory so that they are resumed if the server is restarted. Stop the server with Ctrl+C",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, err := cmd.Flags().GetString("addr")
+		if err != nil {
+			return err
+		}
+		dataDir, err := cmd.Flags().GetString("data-dir")
+		if err != nil {
+			return err
+		}
+		if dataDir == "" {
+			if dataDir, err = server.DefaultDataDir(); err != nil {
+				return err
+			}
+		}
+		workers, err := cmd.Flags().GetInt("workers")
+		if err != nil {
+			return err
+		}
+
+		s, err := server.New(audiobookArgs(cmd, ""), dataDir)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		var running sync.WaitGroup
+		running.Add(1)
+		go func() {
+			defer running.Done()
+			s.Run(ctx, workers)
+		}()
+
+		httpServer := &http.Server{Addr: addr, Handler: s.Handler()}
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := httpServer.Shutdown(shutdownCtx); err != nil {
+				log.Errorf("Could not shut down the server: %v", err)
+			}
+		}()
+
+		log.Infof("Serving the web page at http://%s and the API at http://%s/api/jobs", addr, addr)
+		if config.GetBool("feed") {
+			log.Infof("Serving the podcast feed at http://%s/feed.xml", addr)
+		}
+		if config.GetBool("opds") {
+			log.Infof("Serving the OPDS catalogs at http://%s/catalog.xml and http://%s/catalog.json", addr, addr)
+		}
+		err = httpServer.ListenAndServe()
+		stop()
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		log.Info("Stopping the running jobs, which are resumed when the server starts again")
+		running.Wait()
+		return err
+	},
+}
@@ -0,0 +1,26 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(synthesizeCmd)
+}
+
+var synthesizeCmd = &cobra.Command{
+	Use:   "synthesize <dir>",
+	Short: "Build an audiobook from a directory written by the extract command",
+	Long: "Read the text files in a directory written by the extract command in the order of its " + internal.ManifestName +
+		" and join them into an mp3 with one chapter per file. Voices and languages set in the manifest take priority over --model",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, err := internal.Synthesize(audiobookArgs(cmd, ""), args[0])
+		return err
+	},
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	tocCmd.Flags().Bool("json", false, "Output the list of sections as JSON")
+	rootCmd.AddCommand(tocCmd)
+}
+
+var tocCmd = &cobra.Command{
+	Use:   "toc <file.epub>",
+	Short: "List the sections of an epub to pick from with --sections",
+	Long:  "List the numbered sections of an epub with their title, kind, and an estimate of how long each takes to read. Sections marked as skipped are left out unless picked with --sections",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		asJSON, err := cmd.Flags().GetBool("json")
+		if err != nil {
+			return err
+		}
+
+		entries, err := internal.TableOfContents(args[0], config.GetStringSlice("skip"))
+		if err != nil {
+			return err
+		}
+
+		if asJSON {
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(entries)
+		}
+
+		writer := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(writer, "#\tTITLE\tKIND\tWORDS\tLENGTH\tFILE\t")
+		for _, entry := range entries {
+			kind := entry.Kind
+			if !entry.Linear {
+				kind += " (nonlinear)"
+			}
+			if entry.Skipped {
+				kind += ", skipped"
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\t%d\t%s\t%s\t\n",
+				entry.Index, entry.Title, kind, entry.Words, formatMinutes(entry.EstimatedMinutes), entry.Href)
+		}
+		return writer.Flush()
+	},
+}
+
+// Format a length in minutes like "1h05m" or "12m"
+func formatMinutes(minutes float64) string {
+	rounded := int(minutes + 0.5)
+	if rounded >= 60 {
+		return fmt.Sprintf("%dh%02dm", rounded/60, rounded%60)
+	}
+	return fmt.Sprintf("%dm", rounded)
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+
+	"github.com/spf13/cobra"
+)
+
+// The inbox that is watched if none is given
+const defaultInbox = "~/ToListen/inbox"
+
+func init() {
+	watchCmd.Flags().String("done", "", "Folder to move books to once they are converted (default done next to the inbox)")
+	watchCmd.Flags().String("failed", "", "Folder to move books to that could not be converted (default failed next to the inbox)")
+	watchCmd.Flags().Duration("debounce", internal.DefaultDebounce, "How long a new file has to stop changing before it is converted")
+	rootCmd.AddCommand(watchCmd)
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [inbox]",
+	Short: "Convert the books that are put in a folder as they arrive",
+	Long: "Watch a folder (default " + defaultInbox + ") and convert every book that is added to it with the same options as a conversion, writing the audiobooks to --output. " +
+		"Once converted each book is moved to the done or failed folder along with a log of its conversion. Stop watching with Ctrl+C",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inbox := defaultInbox
+		if len(args) > 0 {
+			inbox = args[0]
+		}
+		folders := internal.DefaultWatchFolders(inbox)
+		done, err := cmd.Flags().GetString("done")
+		if err != nil {
+			return err
+		}
+		if done != "" {
+			folders.Done = done
+		}
+		failed, err := cmd.Flags().GetString("failed")
+		if err != nil {
+			return err
+		}
+		if failed != "" {
+			folders.Failed = failed
+		}
+		debounce, err := cmd.Flags().GetDuration("debounce")
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		return internal.Watch(ctx, audiobookArgs(cmd, ""), folders, debounce)
+	},
+}
@@ -0,0 +1,223 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/formats"
+
+	log "github.com/charmbracelet/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// Extensions of the files in a directory that are converted in a batch on top of the
+// formats with a built in reader. Files named explicitly are converted whatever their extension
+var batchExtensions = []string{
+	".epub", ".pdf", ".mobi", ".azw", ".azw3", ".docx", ".odt", ".rtf", ".djvu", ".lit", ".pdb",
+}
+
+// The result of converting one book of a batch
+type BatchResult struct {
+	Input string
+	// the audiobook that was created or that was already up to date
+	Output string
+	// whether the book was skipped because its audiobook is newer than it
+	Skipped  bool
+	Err      error
+	Duration time.Duration
+}
+
+// Whether the book of a file extension is converted when it is found in a directory
+func isBatchExtension(ext string) bool {
+	ext = strings.ToLower(ext)
+	if _, ok := formats.ForExtension(ext); ok {
+		return true
+	}
+	return slices.Contains(batchExtensions, ext)
+}
+
+// Whether a path is a glob to expand. Paths that exist are taken as they are
+// so books with names like "Book [2020].epub" can still be converted
+func IsGlob(path string) bool {
+	if !strings.ContainsAny(path, "*?[") {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err != nil
+}
+
+// Expand the files, globs and directories passed on the command line into the books to
+// convert, in order and without duplicates. Only directories themselves are searched
+// unless recursive is set, and hidden files and files in formats that can't be read are left out
+func ExpandInputs(patterns []string, recursive bool) ([]string, error) {
+	var inputs []string
+	seen := make(map[string]bool)
+	add := func(input string) {
+		if !seen[input] {
+			seen[input] = true
+			inputs = append(inputs, input)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if lib.IsUrl(pattern) {
+			add(pattern)
+			continue
+		}
+
+		matches := []string{pattern}
+		if IsGlob(pattern) {
+			var err error
+			if matches, err = filepath.Glob(pattern); err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+			}
+			if !strings.HasPrefix(filepath.Base(pattern), ".") {
+				// like a shell, wildcards don't match hidden files
+				matches = slices.DeleteFunc(matches, func(match string) bool {
+					return strings.HasPrefix(filepath.Base(match), ".")
+				})
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match '%s'", pattern)
+			}
+		}
+
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				add(match)
+				continue
+			}
+
+			err = filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				hidden := path != match && strings.HasPrefix(entry.Name(), ".")
+				if entry.IsDir() {
+					if path != match && (!recursive || hidden) {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !hidden && isBatchExtension(filepath.Ext(path)) {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return inputs, nil
+}
+
+// The audiobook that converting a book with the given options creates
+func expectedOutput(config AudiobookArgs) string {
+	chapters := config.Chapters && (filepath.Ext(config.FileName) == ".epub" || hasNativeReader(config))
+	ext := ".wav"
+	if config.OutputAsMp3 || chapters {
+		ext = ".mp3"
+	}
+	return filepath.Join(config.OutputDirectory, outputBaseName(config)+ext)
+}
+
+// Whether the audiobook of a local book exists and was made after the book last changed
+func isUpToDate(input, output string) bool {
+	inputInfo, err := os.Stat(input)
+	if err != nil {
+		return false
+	}
+	outputInfo, err := os.Stat(output)
+	if err != nil {
+		return false
+	}
+	return outputInfo.ModTime().After(inputInfo.ModTime())
+}
+
+// The audio of several books written to standard output at once would be interleaved
+var errSeveralToStdout = fmt.Errorf("several books can't be written to standard output; pass a directory to --output instead")
+
+// Convert several books with the same options. The books share their piper clients and
+// config.Threads limits how many piper processes run at once across all of them.
+// Books whose audiobook is newer than they are are skipped. A book that fails doesn't
+// stop the others; the results are returned in the order of the inputs
+func Batch(config AudiobookArgs, inputs []string) []BatchResult {
+	config, err := expandHomeDir(config)
+	if err == nil && config.OutputDirectory == StdioPath {
+		err = errSeveralToStdout
+	}
+	results := make([]BatchResult, len(inputs))
+	if err != nil {
+		for i, input := range inputs {
+			results[i] = BatchResult{Input: input, Err: err}
+		}
+		return results
+	}
+	config.pool = newPiperPool(config.Threads)
+	// the books are converted at the same time and report when they are all done
+	config.Quiet = true
+
+	// two books with the same name would overwrite each other's audiobook
+	outputs := make(map[string]string)
+
+	errorGroup := errgroup.Group{}
+	if config.Threads > 0 {
+		errorGroup.SetLimit(config.Threads)
+	}
+	for i, input := range inputs {
+		i, input := i, input
+		bookConfig := config
+		bookConfig.FileName = input
+		result := BatchResult{Input: input}
+
+		if input == StdioPath {
+			result.Err = fmt.Errorf("standard input can only be converted on its own")
+			results[i] = result
+			continue
+		}
+
+		expected := expectedOutput(bookConfig)
+		if other, ok := outputs[expected]; ok {
+			result.Err = fmt.Errorf("its audiobook %s would overwrite the one of %s", expected, other)
+			results[i] = result
+			continue
+		}
+		outputs[expected] = input
+
+		if !lib.IsUrl(input) && isUpToDate(input, expected) {
+			log.Infof("Skipping %s since %s is up to date", input, expected)
+			result.Output = expected
+			result.Skipped = true
+			results[i] = result
+			continue
+		}
+
+		errorGroup.Go(func() error {
+			start := time.Now()
+			result.Output, result.Err = QuickPiperAudiobook(bookConfig)
+			result.Duration = time.Since(start)
+			if result.Err != nil {
+				log.Errorf("Failed to convert %s: %v", input, result.Err)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	_ = errorGroup.Wait()
+
+	return results
+}
@@ -0,0 +1,122 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("text"), 0644))
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.txt", "b.epub", "cover.jpg", ".hidden.txt", "sub/c.md", ".git/d.txt", "other.html")
+
+	inputs, err := ExpandInputs([]string{dir}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		filepath.Join(dir, "a.txt"),
+		filepath.Join(dir, "b.epub"),
+		filepath.Join(dir, "other.html"),
+	}, inputs)
+
+	inputs, err = ExpandInputs([]string{dir}, true)
+	require.NoError(t, err)
+	require.Contains(t, inputs, filepath.Join(dir, "sub", "c.md"))
+	require.NotContains(t, inputs, filepath.Join(dir, ".git", "d.txt"))
+
+	// explicit files are converted whatever their extension and are only listed once
+	inputs, err = ExpandInputs([]string{filepath.Join(dir, "*.txt"), filepath.Join(dir, "cover.jpg"), filepath.Join(dir, "a.txt")}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "cover.jpg")}, inputs)
+
+	_, err = ExpandInputs([]string{filepath.Join(dir, "*.pdf")}, false)
+	require.ErrorContains(t, err, "no files match")
+
+	_, err = ExpandInputs([]string{filepath.Join(dir, "missing.txt")}, false)
+	require.Error(t, err)
+
+	// files whose names look like globs are taken as they are
+	writeFiles(t, dir, "Book [2020].epub")
+	inputs, err = ExpandInputs([]string{filepath.Join(dir, "Book [2020].epub")}, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "Book [2020].epub")}, inputs)
+}
+
+func TestExpectedOutput(t *testing.T) {
+	config := AudiobookArgs{FileName: "/books/story.md", OutputDirectory: "/out"}
+	require.Equal(t, "/out/story.wav", expectedOutput(config))
+
+	config.Chapters = true
+	require.Equal(t, "/out/story.mp3", expectedOutput(config))
+
+	// pdfs can't be split into chapters so they stay wav without --mp3
+	config.FileName = "/books/paper.pdf"
+	require.Equal(t, "/out/paper.wav", expectedOutput(config))
+
+	config.FileName = "/books/novel.epub"
+	config.Sections = []string{"5-7"}
+	require.Equal(t, "/out/novel (sections 5-7).mp3", expectedOutput(config))
+}
+
+func TestBatchSkipsUpToDateBooks(t *testing.T) {
+	books := t.TempDir()
+	output := t.TempDir()
+	writeFiles(t, books, "a.txt", "b.txt")
+	writeFiles(t, output, "a.wav")
+	// the audiobook of a is newer than it and the one of b doesn't exist
+	past := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(books, "a.txt"), past, past))
+
+	config := AudiobookArgs{OutputDirectory: output, Threads: 2}
+	results := Batch(config, []string{filepath.Join(books, "a.txt"), "-"})
+	require.Len(t, results, 2)
+
+	require.True(t, results[0].Skipped)
+	require.NoError(t, results[0].Err)
+	require.Equal(t, filepath.Join(output, "a.wav"), results[0].Output)
+
+	require.False(t, results[1].Skipped)
+	require.ErrorContains(t, results[1].Err, "standard input")
+}
+
+func TestBatchRejectsStdout(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "one.txt", "two.txt")
+	results := Batch(AudiobookArgs{OutputDirectory: StdioPath}, []string{filepath.Join(dir, "one.txt"), filepath.Join(dir, "two.txt")})
+	for _, result := range results {
+		require.ErrorIs(t, result.Err, errSeveralToStdout)
+	}
+
+	err := Watch(context.Background(), AudiobookArgs{OutputDirectory: StdioPath}, DefaultWatchFolders(dir), time.Second)
+	require.ErrorIs(t, err, errSeveralToStdout)
+	require.NoDirExists(t, StdioPath)
+}
+
+func TestBatchRejectsBooksWithTheSameOutput(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	output := t.TempDir()
+	writeFiles(t, first, "book.txt")
+	writeFiles(t, second, "book.txt")
+	writeFiles(t, output, "book.wav")
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(output, "book.wav"), future, future))
+
+	results := Batch(AudiobookArgs{OutputDirectory: output}, []string{filepath.Join(first, "book.txt"), filepath.Join(second, "book.txt")})
+	require.True(t, results[0].Skipped)
+	require.ErrorContains(t, results[1].Err, "would overwrite")
+}
@@ -4,6 +4,7 @@
 package binarymanagers
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os/exec"
@@ -19,11 +20,16 @@ type PipedOutput struct {
 }
 
 func RunPiped(cmdName string, args []string, pipedInput io.Reader) (PipedOutput, error) {
+	return RunPipedContext(context.Background(), cmdName, args, pipedInput)
+}
+
+// Like RunPiped, but the command is killed when ctx is done
+func RunPipedContext(ctx context.Context, cmdName string, args []string, pipedInput io.Reader) (PipedOutput, error) {
 	if pipedInput == nil {
 		return PipedOutput{}, fmt.Errorf("piped input was nil")
 	}
 
-	fullCmd := exec.Command(cmdName, args...)
+	fullCmd := exec.CommandContext(ctx, cmdName, args...)
 
 	stdout, err := fullCmd.StdoutPipe()
 	if err != nil {
@@ -5,8 +5,11 @@ package binarymanagers
 
 import (
 	"bytes"
+	"context"
 	"io"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -17,6 +20,18 @@ func TestRunBinary(t *testing.T) {
 	require.Equal(t, "hello\n", output)
 }
 
+// Make sure a command is killed when its context is done
+func TestRunPipedContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	output, err := RunPipedContext(ctx, "sleep", []string{"10"}, strings.NewReader(""))
+	require.NoError(t, err)
+
+	start := time.Now()
+	cancel()
+	require.Error(t, output.Handle.Wait())
+	require.Less(t, time.Since(start), 5*time.Second)
+}
+
 func FuzzRunPipedBinary(f *testing.F) {
 	// Test echo to cat
 	f.Fuzz(func(t *testing.T, message string) {
@@ -4,10 +4,17 @@
 package ebookconvert
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
 )
 
 type EmptyConversionResultError struct {
@@ -18,10 +25,31 @@ func (e *EmptyConversionResultError) Error() string {
 	return fmt.Sprintf("ebook-convert output is empty: %s", e.Filename)
 }
 
+// The text from ebook-convert, which removes its temporary file when closed
+type convertedText struct {
+	*os.File
+	// whether the file is temporary and should be removed when closed
+	temporary bool
+}
+
+func (c convertedText) Close() error {
+	err := c.File.Close()
+	if c.temporary {
+		if removeErr := os.Remove(c.Name()); removeErr != nil && err == nil {
+			err = removeErr
+		}
+	}
+	return err
+}
+
 // Convert input data to text using the ebook-convert command
 // Assumings that the input data is in the format of the file extension provided
-// Will output .txt file since piper doesn't support reading other formats
-func ConvertToText(input io.Reader, fileExt string) (io.Reader, error) {
+// Will output .txt file since piper doesn't support reading other formats.
+//
+// If cacheDir is not empty, the text is kept there keyed by a hash of the input
+// so converting the same input again doesn't run ebook-convert.
+// The caller must close the returned reader to remove its temporary files
+func ConvertToText(input io.Reader, fileExt string, cacheDir string) (io.ReadCloser, error) {
 
 	if _, err := exec.LookPath("ebook-convert"); err != nil {
 		return nil, fmt.Errorf("the ebook-convert command was not found in your PATH. Please install it with your package manager")
@@ -32,31 +60,73 @@ func ConvertToText(input io.Reader, fileExt string) (io.Reader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporary file: %v", err)
 	}
+	defer os.Remove(tmpInputFile.Name())
 	defer tmpInputFile.Close()
-	tmpOutputFile, err := os.CreateTemp("", "ebook-convert-tmp-output-*.txt")
-	if err != nil {
-		return nil, fmt.Errorf("failed to create temporary file: %v", err)
-	}
 
-	// Write the input data to the temporary file
-	_, err = io.Copy(tmpInputFile, input)
+	// Write the input data to the temporary file, hashing it on the way for the cache
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s:", strings.ToLower(fileExt))
+	_, err = io.Copy(io.MultiWriter(tmpInputFile, hash), input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to write to temporary file: %v", err)
 	}
+	if err := tmpInputFile.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write to temporary file: %v", err)
+	}
 
-	cmd := exec.Command("ebook-convert", tmpInputFile.Name(), tmpOutputFile.Name())
+	outputDir := os.TempDir()
+	cachedPath := ""
+	if cacheDir != "" {
+		key := hex.EncodeToString(hash.Sum(nil))
+		// keys are spread over subdirectories so no directory gets too big
+		cachedPath = filepath.Join(cacheDir, key[:2], key+".txt")
+		if cached, err := openCached(cachedPath); err == nil {
+			log.Debugf("Using cached ebook-convert output %s", cachedPath)
+			// mark it as used so it is among the last to be pruned from the cache
+			now := time.Now()
+			if err := os.Chtimes(cachedPath, now, now); err != nil {
+				log.Debugf("Failed to mark %s as used: %v", cachedPath, err)
+			}
+			return cached, nil
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
 
-	// make sure that tmpInputFile contains some data and is not an empty file
-	if _, err := tmpInputFile.Stat(); err != nil {
-		return nil, fmt.Errorf("failed to stat temporary input file: %v", err)
+		// write the output next to where it is cached so it can be moved into place
+		outputDir = filepath.Dir(cachedPath)
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create the ebook-convert cache: %v", err)
+		}
 	}
 
+	tmpOutputFile, err := os.CreateTemp(outputDir, "ebook-convert-tmp-output-*.txt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmpOutputFile.Close()
+	keepOutput := false
+	defer func() {
+		if !keepOutput {
+			os.Remove(tmpOutputFile.Name())
+		}
+	}()
+
+	cmd := exec.Command("ebook-convert", tmpInputFile.Name(), tmpOutputFile.Name())
+
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert ebook: %s\nOutput: %s", err, string(output))
 	}
 
-	if fileInfo, err := tmpOutputFile.Stat(); err != nil {
+	if cachedPath != "" {
+		// empty results are cached too so they are skipped without running ebook-convert again
+		if err := os.Rename(tmpOutputFile.Name(), cachedPath); err != nil {
+			return nil, fmt.Errorf("failed to cache ebook-convert output: %v", err)
+		}
+		return openCached(cachedPath)
+	}
+
+	if fileInfo, err := os.Stat(tmpOutputFile.Name()); err != nil {
 		return nil, fmt.Errorf("failed to stat temporary output file: %v", err)
 	} else if fileInfo.Size() == 0 {
 		return nil, &EmptyConversionResultError{Filename: tmpOutputFile.Name()}
@@ -67,6 +137,22 @@ func ConvertToText(input io.Reader, fileExt string) (io.Reader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open output file: %v", err)
 	}
+	keepOutput = true
+	return convertedText{File: outputFile, temporary: true}, nil
+}
 
-	return outputFile, nil
+// Open text in the cache, which is left in place when it is closed
+func openCached(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if fileInfo, err := file.Stat(); err != nil {
+		file.Close()
+		return nil, err
+	} else if fileInfo.Size() == 0 {
+		file.Close()
+		return nil, &EmptyConversionResultError{Filename: path}
+	}
+	return convertedText{File: file}, nil
 }
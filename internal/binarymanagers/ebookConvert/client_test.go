@@ -20,8 +20,9 @@ func TestConvertToText(t *testing.T) {
 	defer inputFile.Close()
 
 	// Call the ConvertToText function
-	outputReader, err := ConvertToText(inputFile, ".epub")
+	outputReader, err := ConvertToText(inputFile, ".epub", "")
 	require.NoError(t, err, "ConvertToText returned an error")
+	defer outputReader.Close()
 
 	// Read the output to verify its content
 	outputBytes, err := io.ReadAll(outputReader)
@@ -44,6 +45,58 @@ func TestConvertEmpty(t *testing.T) {
 	require.NoError(t, err, "failed to open test EPUB file")
 	defer inputFile.Close()
 
-	_, err = ConvertToText(inputFile, ".epub")
+	_, err = ConvertToText(inputFile, ".epub", "")
 	require.Error(t, err, "ConvertToText should return an error when input is nil")
 }
+
+// Make sure converting the same input again uses the cache
+func TestConvertToTextCached(t *testing.T) {
+	cacheDir := t.TempDir()
+	convert := func() string {
+		inputFile, err := os.Open(filepath.Join("testdata", "test.epub"))
+		require.NoError(t, err)
+		defer inputFile.Close()
+
+		outputReader, err := ConvertToText(inputFile, ".epub", cacheDir)
+		require.NoError(t, err)
+		defer outputReader.Close()
+		output, err := io.ReadAll(outputReader)
+		require.NoError(t, err)
+		return string(output)
+	}
+
+	first := convert()
+	cached, err := filepath.Glob(filepath.Join(cacheDir, "*", "*.txt"))
+	require.NoError(t, err)
+	require.Len(t, cached, 1)
+	require.Equal(t, first, convert())
+}
+
+func TestOpenCached(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := openCached(filepath.Join(dir, "missing.txt"))
+	require.True(t, os.IsNotExist(err))
+
+	empty := filepath.Join(dir, "empty.txt")
+	require.NoError(t, os.WriteFile(empty, nil, 0644))
+	_, err = openCached(empty)
+	var emptyErr *EmptyConversionResultError
+	require.ErrorAs(t, err, &emptyErr)
+
+	text := filepath.Join(dir, "text.txt")
+	require.NoError(t, os.WriteFile(text, []byte("text"), 0644))
+	reader, err := openCached(text)
+	require.NoError(t, err)
+	require.NoError(t, reader.Close())
+	// cached text is kept when it is closed
+	require.FileExists(t, text)
+}
+
+func TestClosingRemovesTemporaryText(t *testing.T) {
+	file, err := os.CreateTemp(t.TempDir(), "ebook-convert-tmp-output-*.txt")
+	require.NoError(t, err)
+
+	require.NoError(t, convertedText{File: file, temporary: true}.Close())
+	require.NoFileExists(t, file.Name())
+}
@@ -8,6 +8,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type Mp3Section struct {
@@ -22,6 +24,13 @@ type Mp3Section struct {
 // Concatenates MP3 files and saves the output as an MP3 file
 // with proper chapter metadata markers
 func ConcatMp3s(sectionsInOrder []Mp3Section, outputName string) error {
+	return ConcatWithChapters(sectionsInOrder, nil, outputName)
+}
+
+// Concatenates MP3 files into a single file with chapter markers and the given
+// tags, i.e. {"artist": "James Joyce"}. The output is an m4b audiobook if
+// outputName ends in .m4b or .m4a and an MP3 otherwise
+func ConcatWithChapters(sectionsInOrder []Mp3Section, tags map[string]string, outputName string) error {
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
 		return fmt.Errorf("ffmpeg not found in PATH: %v", err)
 	}
@@ -64,16 +73,19 @@ func ConcatMp3s(sectionsInOrder []Mp3Section, outputName string) error {
 	}
 
 	// Generate metadata file with chapter markers
-	if err := generateMetadataFile(metadataFile, sectionsInOrder); err != nil {
+	if err := generateMetadataFile(metadataFile, sectionsInOrder, tags); err != nil {
 		return fmt.Errorf("failed to create metadata file: %v", err)
 	}
 
 	// Run ffmpeg to concatenate and embed metadata
-	cmd := exec.Command(
-		"ffmpeg", "-f", "concat", "-safe", "0", "-i", concatFile.Name(),
-		"-i", metadataFile.Name(), "-map_metadata", "1", "-id3v2_version", "3", "-acodec", "libmp3lame",
-		"-b:a", "192k", "-y", outputName,
-	)
+	args := []string{"-f", "concat", "-safe", "0", "-i", concatFile.Name(), "-i", metadataFile.Name(), "-map_metadata", "1"}
+	switch strings.ToLower(filepath.Ext(outputName)) {
+	case ".m4b", ".m4a":
+		args = append(args, "-acodec", "aac", "-b:a", "96k", "-f", "ipod")
+	default:
+		args = append(args, "-id3v2_version", "3", "-acodec", "libmp3lame", "-b:a", "192k")
+	}
+	cmd := exec.Command("ffmpeg", append(args, "-y", outputName)...)
 
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -83,13 +95,24 @@ func ConcatMp3s(sectionsInOrder []Mp3Section, outputName string) error {
 	return nil
 }
 
-// Write an ffmetadata file with chapters based on MP3 durations.
-func generateMetadataFile(metadataFile *os.File, sectionsInOrder []Mp3Section) error {
+// Write an ffmetadata file with the tags and chapters based on MP3 durations.
+func generateMetadataFile(metadataFile *os.File, sectionsInOrder []Mp3Section, tags map[string]string) error {
 	_, err := metadataFile.WriteString(";FFMETADATA1\n")
 	if err != nil {
 		return err
 	}
 
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(metadataFile, "%s=%s\n", escapeMetadata(key), escapeMetadata(tags[key])); err != nil {
+			return err
+		}
+	}
+
 	startTime := int64(0)
 	for i, section := range sectionsInOrder {
 		endTime := startTime + section.Duration
@@ -109,7 +132,7 @@ func generateMetadataFile(metadataFile *os.File, sectionsInOrder []Mp3Section) e
 		}
 
 		chapter := fmt.Sprintf("\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
-			startTimeOffset, endTime, section.Title)
+			startTimeOffset, endTime, escapeMetadata(section.Title))
 
 		_, err := metadataFile.WriteString(chapter)
 		if err != nil {
@@ -121,3 +144,47 @@ func generateMetadataFile(metadataFile *os.File, sectionsInOrder []Mp3Section) e
 	metadataFile.Close()
 	return nil
 }
+
+// Escape the characters that have a special meaning in ffmetadata files
+func escapeMetadata(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", `\`+"\n").Replace(value)
+}
+
+// Join audio files end to end into a single file without any chapter markers.
+// The format of the output is chosen from the extension of outputName
+func JoinAudio(filesInOrder []string, outputName string) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH: %v", err)
+	}
+
+	concatFile, err := os.CreateTemp("", "join-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create temp concat file: %v", err)
+	}
+	defer os.Remove(concatFile.Name())
+
+	for _, file := range filesInOrder {
+		absPath, err := filepath.Abs(file)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path of %s: %v", file, err)
+		}
+		if _, err := concatFile.WriteString(fmt.Sprintf("file '%s'\n", absPath)); err != nil {
+			return fmt.Errorf("failed to write to concat file: %v", err)
+		}
+	}
+	if err := concatFile.Close(); err != nil {
+		return fmt.Errorf("failed to close concat file: %v", err)
+	}
+
+	args := []string{"-f", "concat", "-safe", "0", "-i", concatFile.Name()}
+	if filepath.Ext(outputName) == ".mp3" {
+		args = append(args, "-acodec", "libmp3lame", "-b:a", "128k")
+	}
+	args = append(args, "-y", outputName)
+
+	output, err := exec.Command("ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %v\n%s", err, output)
+	}
+	return nil
+}
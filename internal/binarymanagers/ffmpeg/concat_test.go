@@ -88,3 +88,32 @@ func TestConcatNonExistFileFails(t *testing.T) {
 	defer os.Remove(outputFile)
 	require.Error(t, err)
 }
+
+func TestJoinAudio(t *testing.T) {
+	files := []string{"testdata/cow-bell.mp3", "testdata/rooster.mp3"}
+
+	const outputFile = "test_ffmpeg_join.wav"
+	err := JoinAudio(files, outputFile)
+	defer os.Remove(outputFile)
+	require.NoError(t, err)
+	require.FileExists(t, outputFile)
+
+	validate := exec.Command("ffmpeg", "-v", "error", "-i", outputFile, "-f", "null", "-")
+	require.NoError(t, validate.Run())
+}
+
+func TestGenerateMetadataFile(t *testing.T) {
+	metadataFile, err := os.CreateTemp(t.TempDir(), "metadata-*.txt")
+	require.NoError(t, err)
+
+	sections := []Mp3Section{{Title: "One; Two", Duration: 1000}, {Duration: 2000}}
+	tags := map[string]string{"title": "A=B", "artist": "Joyce"}
+	require.NoError(t, generateMetadataFile(metadataFile, sections, tags))
+
+	data, err := os.ReadFile(metadataFile.Name())
+	require.NoError(t, err)
+	metadata := string(data)
+	require.True(t, strings.HasPrefix(metadata, ";FFMETADATA1\nartist=Joyce\ntitle=A\\=B\n"))
+	require.Contains(t, metadata, "START=0\nEND=1000\ntitle=One\\; Two\n")
+	require.Contains(t, metadata, "START=500\nEND=3000\ntitle=Chapter 2\n")
+}
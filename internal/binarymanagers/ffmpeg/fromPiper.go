@@ -4,18 +4,26 @@
 package ffmpeg
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"strconv"
 
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers"
 
 	"github.com/charmbracelet/log"
 )
 
-// Convert raw PCM audio from piper to MP3 using ffmpeg
-func OutputToMp3(piperRawAudio io.Reader, outputName string) error {
+// The sample rate of all mp3s that are created. Models output audio at different
+// rates so everything is resampled to the same rate so it can be concatenated
+const outputSampleRate = "22050"
+
+// Convert raw PCM audio from piper at the given sample rate to MP3 using ffmpeg.
+// ffmpeg is killed when ctx is done
+func OutputToMp3(ctx context.Context, piperRawAudio io.Reader, sampleRate int, outputName string) error {
 	if _, err := exec.LookPath("ffmpeg"); err != nil {
 		return fmt.Errorf("ffmpeg not found in PATH: %v", err)
 	}
@@ -24,10 +32,10 @@ func OutputToMp3(piperRawAudio io.Reader, outputName string) error {
 		return fmt.Errorf("nil was passed to ffmpeg mp3 generation")
 	}
 
-	args := []string{"-f", "s16le", "-ar", "22050", "-ac", "1", "-i", "pipe:0",
-		"-acodec", "libmp3lame", "-b:a", "128k", "-y", outputName}
+	args := []string{"-f", "s16le", "-ar", strconv.Itoa(sampleRate), "-ac", "1", "-i", "pipe:0",
+		"-acodec", "libmp3lame", "-b:a", "128k", "-ar", outputSampleRate, "-y", outputName}
 
-	output, err := binarymanagers.RunPiped("ffmpeg", args, piperRawAudio)
+	output, err := binarymanagers.RunPipedContext(ctx, "ffmpeg", args, piperRawAudio)
 	if err != nil {
 		return err
 	}
@@ -51,7 +59,7 @@ func OutputToMp3(piperRawAudio io.Reader, outputName string) error {
 	}
 
 	// Verify output
-	verifyCmd := exec.Command("ffmpeg", "-v", "error", "-i", outputName, "-f", "null", "-")
+	verifyCmd := exec.CommandContext(ctx, "ffmpeg", "-v", "error", "-i", outputName, "-f", "null", "-")
 	verifyOutput, err := verifyCmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("ffmpeg failed to validate audio output. This may be a sign of corrupted data; try setting a lower --thread value. Got error: %v\nstderr: %s", err, string(verifyOutput))
@@ -59,3 +67,30 @@ func OutputToMp3(piperRawAudio io.Reader, outputName string) error {
 
 	return nil
 }
+
+// Convert raw PCM audio from piper at the given sample rate to MP3 using ffmpeg,
+// writing the MP3 to output as it is encoded instead of to a file
+func StreamMp3(piperRawAudio io.Reader, sampleRate int, output io.Writer) error {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return fmt.Errorf("ffmpeg not found in PATH: %v", err)
+	}
+
+	if piperRawAudio == nil {
+		return fmt.Errorf("nil was passed to ffmpeg mp3 generation")
+	}
+
+	args := []string{"-f", "s16le", "-ar", strconv.Itoa(sampleRate), "-ac", "1", "-i", "pipe:0",
+		"-acodec", "libmp3lame", "-b:a", "128k", "-ar", outputSampleRate, "-f", "mp3", "pipe:1"}
+
+	log.Debugf("Running ffmpeg to stream an mp3")
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("ffmpeg", args...)
+	cmd.Stdin = piperRawAudio
+	cmd.Stdout = output
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %v\nstderr: %s", err, stderr.String())
+	}
+	return nil
+}
@@ -4,6 +4,7 @@
 package ffmpeg
 
 import (
+	"context"
 	"os"
 	"strings"
 	"testing"
@@ -15,7 +16,7 @@ import (
 
 func TestPiperToMp3(t *testing.T) {
 
-	piperClient, err := piper.NewPiperClient("en_US-lessac-medium.onnx")
+	piperClient, err := piper.NewPiperClient("en_US-lessac-medium.onnx", piper.DownloadSources{})
 	require.NoError(t, err)
 
 	const testData = "This is some test data for ffmpeg integration tests."
@@ -27,7 +28,7 @@ func TestPiperToMp3(t *testing.T) {
 	require.NoError(t, err)
 	defer os.Remove(file.Name())
 
-	err = OutputToMp3(streamData.Stdout, file.Name())
+	err = OutputToMp3(context.Background(), streamData.Stdout, piperClient.SampleRate(), file.Name())
 	require.NoError(t, err)
 	require.FileExists(t, file.Name())
 
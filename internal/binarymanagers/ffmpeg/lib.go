@@ -4,9 +4,11 @@
 package ffmpeg
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 )
 
 // Retrieves the duration of an MP3 file in milliseconds using ffprobe.
@@ -30,3 +32,62 @@ func getMp3Duration(mp3File string) (int64, error) {
 	}
 	return int64(durationSec * 1000), nil // Convert to milliseconds
 }
+
+// A chapter marker read from an audio file
+type Chapter struct {
+	Title string
+	// Start of the chapter in milliseconds
+	Start int64
+}
+
+// The length and chapter markers of an audio file
+type AudioInfo struct {
+	// Duration in milliseconds
+	Duration int64
+	Chapters []Chapter
+}
+
+// The subset of ffprobe's JSON output that Probe reads
+type probeOutput struct {
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+	Chapters []struct {
+		StartTime string `json:"start_time"`
+		Tags      struct {
+			Title string `json:"title"`
+		} `json:"tags"`
+	} `json:"chapters"`
+}
+
+// Read the duration and chapter markers of an audio file using ffprobe
+func Probe(audioFile string) (AudioInfo, error) {
+	if _, err := os.Stat(audioFile); err != nil {
+		return AudioInfo{}, err
+	}
+
+	cmd := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-show_chapters",
+		"-of", "json", audioFile)
+	output, err := cmd.Output()
+	if err != nil {
+		return AudioInfo{}, fmt.Errorf("ffprobe error: %v %s", err, output)
+	}
+
+	var probed probeOutput
+	if err := json.Unmarshal(output, &probed); err != nil {
+		return AudioInfo{}, fmt.Errorf("failed to parse ffprobe output: %v", err)
+	}
+
+	var info AudioInfo
+	if durationSec, err := strconv.ParseFloat(probed.Format.Duration, 64); err == nil {
+		info.Duration = int64(durationSec * 1000)
+	}
+	for _, chapter := range probed.Chapters {
+		startSec, err := strconv.ParseFloat(chapter.StartTime, 64)
+		if err != nil {
+			return AudioInfo{}, fmt.Errorf("failed to parse the start of chapter '%s': %v", chapter.Tags.Title, err)
+		}
+		info.Chapters = append(info.Chapters, Chapter{Title: chapter.Tags.Title, Start: int64(startSec * 1000)})
+	}
+	return info, nil
+}
@@ -14,3 +14,10 @@ func TestMp3Duration(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, duration, int64(2115))
 }
+
+func TestProbe(t *testing.T) {
+	info, err := Probe("testdata/cow-bell.mp3")
+	require.NoError(t, err)
+	require.Equal(t, int64(2115), info.Duration)
+	require.Empty(t, info.Chapters)
+}
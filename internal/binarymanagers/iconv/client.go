@@ -4,25 +4,141 @@
 package iconv
 
 import (
-	"fmt"
+	"bufio"
 	"io"
-	"os/exec"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// the number of bytes at the start of a text used to detect which language's rules apply
+const languageSampleSize = 4096
+
+// Rules added to or replacing the built in ones, keyed by language code
+// and then by lowercase character; set once from the config with SetOverrides
+var (
+	overridesMu sync.RWMutex
+	overrides   map[string]map[string]string
 )
 
+// Add or change the transliteration rules of each language, i.e. {"pl": {"ł": "w"}}.
+// Each lowercase character maps to its replacement and the uppercase version of the
+// character is replaced with a capitalized replacement
+func SetOverrides(rules map[string]map[string]string) {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides = rules
+}
+
+// Build the table of characters to replace in text in a language, i.e. "de"
+// reads "ä" as "ae" instead of "a"; the overrides take priority over the built in rules
+func tableFor(language string) map[rune]string {
+	table := make(map[rune]string)
+	for r, replacement := range genericTable {
+		table[r] = replacement
+	}
+	for r, replacement := range languageTables[lang.Base(language)] {
+		table[r] = replacement
+	}
+
+	overridesMu.RLock()
+	defer overridesMu.RUnlock()
+	for configured, rules := range overrides {
+		if lang.Base(configured) != lang.Base(language) {
+			continue
+		}
+		for character, replacement := range rules {
+			r, size := utf8.DecodeRuneInString(character)
+			if size == 0 || size != len(character) {
+				log.Warnf("Ignoring transliteration for '%s' since only single characters can be replaced", character)
+				continue
+			}
+			table[r] = replacement
+			if upper := unicode.ToUpper(r); upper != r {
+				table[upper] = capitalize(replacement)
+			}
+		}
+	}
+	return table
+}
+
 // Remove diacritics from text so that an english voice can read it
 // without explicitly speaking the diacritics and messing with speech
 // i.e. "café" -> "cafe" and "résumé" -> "resume"
+// The rules of the language the text is written in are used, i.e. German "ä" is read as "ae".
+// Characters that can't be transliterated, like Chinese, are left out.
+// This used to shell out to iconv but is now done in Go since the output of
+// iconv differs between libc implementations
 func RemoveDiacritics(input io.Reader) (io.Reader, error) {
-	if _, err := exec.LookPath("iconv"); err != nil {
-		return nil, fmt.Errorf("iconv not found in PATH: %v", err)
+	// detect the language from the start of the text without consuming it
+	buffered := bufio.NewReaderSize(input, languageSampleSize)
+	sample, err := buffered.Peek(languageSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
 	}
 
-	command := []string{"-f", "UTF-8", "-t", "ASCII//TRANSLIT//IGNORE"}
-	output, err := binarymanagers.RunPiped("iconv", command, input)
-	if err != nil {
-		return nil, err
+	chain := transform.Chain(
+		&asciiMapper{table: tableFor(lang.Detect(string(sample)))},
+		norm.NFD,
+		runes.Remove(runes.In(unicode.Mn)),
+		&asciiMapper{fallback: true},
+	)
+	return transform.NewReader(buffered, chain), nil
+}
+
+// A transformer that replaces characters using a table. If fallback is set,
+// every remaining non ASCII character is left out
+type asciiMapper struct {
+	table    map[rune]string
+	fallback bool
+	warned   bool
+}
+
+func (m *asciiMapper) Reset() {}
+
+func (m *asciiMapper) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if src[nSrc] < utf8.RuneSelf {
+			if nDst >= len(dst) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			dst[nDst] = src[nSrc]
+			nDst++
+			nSrc++
+			continue
+		}
+
+		if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+		r, size := utf8.DecodeRune(src[nSrc:])
+
+		replacement, ok := m.table[r]
+		switch {
+		case ok:
+		case m.fallback:
+			if !m.warned {
+				log.Warnf("Some characters like '%c' could not be transliterated and will be left out. Use --speak-utf-8 with a model for the language to read them", r)
+				m.warned = true
+			}
+			replacement = ""
+		default:
+			// leave it for the next stage of the chain
+			replacement = string(src[nSrc : nSrc+size])
+		}
+
+		if nDst+len(replacement) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += copy(dst[nDst:], replacement)
+		nSrc += size
 	}
-	return output.Stdout, nil
+	return nDst, nSrc, nil
 }
@@ -7,6 +7,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -20,8 +21,8 @@ func TestRemoveDiacritics(t *testing.T) {
 		{"dhyāna jhāna Bön Shān dōng sheng Qí shān", "dhyana jhana Bon Shan dong sheng Qi shan"},
 		{"test without diacritics", "test without diacritics"},
 		{"1234567890", "1234567890"},
-		// if you try to remove diacritics on 汉字 it will be replaced by ??
-		{"你好", "??"},
+		// 汉字 can't be transliterated so it is left out instead of being read as question marks
+		{"你好", ""},
 	} {
 		t.Run(test.input, func(t *testing.T) {
 			piperInput := strings.NewReader(test.input)
@@ -34,3 +35,36 @@ func TestRemoveDiacritics(t *testing.T) {
 	}
 
 }
+
+func TestLanguageRules(t *testing.T) {
+
+	read := func(t *testing.T, input io.Reader) string {
+		result, err := RemoveDiacritics(input)
+		require.NoError(t, err)
+		resultBytes, err := io.ReadAll(result)
+		require.NoError(t, err)
+		return string(resultBytes)
+	}
+
+	t.Run("characters without a decomposition and punctuation", func(t *testing.T) {
+		output := read(t, strings.NewReader("Łódź, Æsir straße — “quoted” …"))
+		require.Equal(t, "Lodz, AEsir strasse - \"quoted\" ...", output)
+	})
+
+	t.Run("the rules of the language of the text", func(t *testing.T) {
+		require.Equal(t, "Der Koenig und die Koenigin sind in Muenchen", read(t, strings.NewReader("Der König und die Königin sind in München")))
+		require.Equal(t, "The king of Munchen and the queen", read(t, strings.NewReader("The king of München and the queen")))
+		require.Equal(t, "Han og hun er i Koebenhavn og det var Aarhus", read(t, strings.NewReader("Han og hun er i København og det var Århus")))
+	})
+
+	t.Run("overrides apply to both cases", func(t *testing.T) {
+		SetOverrides(map[string]map[string]string{"pl": {"ł": "w", "ch": "h"}})
+		t.Cleanup(func() { SetOverrides(nil) })
+		require.Equal(t, "Wodz i wodka nie jest w domu", read(t, strings.NewReader("Łodz i łodka nie jest w domu")))
+	})
+
+	t.Run("multibyte characters split across reads", func(t *testing.T) {
+		const input = "Der Bön und die Tür 你好 ü"
+		require.Equal(t, "Der Boen und die Tuer  ue", read(t, iotest.OneByteReader(strings.NewReader(input))))
+	})
+}
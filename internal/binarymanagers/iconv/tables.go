@@ -0,0 +1,74 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package iconv
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Characters that don't decompose into a letter and a diacritic
+// along with punctuation that has a common ASCII equivalent
+var genericTable = map[rune]string{
+	'ß': "ss", 'ẞ': "SS",
+	'æ': "ae", 'Æ': "AE",
+	'œ': "oe", 'Œ': "OE",
+	'ø': "o", 'Ø': "O",
+	'đ': "d", 'Đ': "D",
+	'ð': "d", 'Ð': "D",
+	'þ': "th", 'Þ': "Th",
+	'ł': "l", 'Ł': "L",
+	'ħ': "h", 'Ħ': "H",
+	'ı': "i",
+	'ŋ': "ng", 'Ŋ': "NG",
+	'ſ': "s",
+	'ĳ': "ij", 'Ĳ': "IJ",
+	'ﬁ': "fi", 'ﬂ': "fl", 'ﬀ': "ff",
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'",
+	'“': "\"", '”': "\"", '„': "\"", '‟': "\"", '″': "\"",
+	'«': "\"", '»': "\"", '‹': "'", '›': "'",
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...",
+	'•': "", '·': "",
+	'\u00a0': " ", '\u2007': " ", '\u2009': " ", '\u202f': " ",
+	'\u200b': "", '\u00ad': "", '\ufeff': "",
+	'×': "x", '÷': "/",
+	'©': "(c)", '®': "(R)", '™': "TM",
+	'€': "EUR", '£': "GBP", '¥': "JPY",
+	'°': " degrees ",
+	'½': "1/2", '¼': "1/4", '¾': "3/4",
+}
+
+// Rules for languages that transliterate some letters differently
+// than just removing their diacritics
+var languageTables = map[string]map[rune]string{
+	"de": {
+		'ä': "ae", 'Ä': "Ae",
+		'ö': "oe", 'Ö': "Oe",
+		'ü': "ue", 'Ü': "Ue",
+	},
+	"da": {
+		'æ': "ae", 'Æ': "Ae",
+		'ø': "oe", 'Ø': "Oe",
+		'å': "aa", 'Å': "Aa",
+	},
+	"no": {
+		'æ': "ae", 'Æ': "Ae",
+		'ø': "oe", 'Ø': "Oe",
+		'å': "aa", 'Å': "Aa",
+	},
+	"is": {
+		'æ': "ae", 'Æ': "Ae",
+		'ö': "o", 'Ö': "O",
+	},
+}
+
+// Uppercase the first character of a replacement
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
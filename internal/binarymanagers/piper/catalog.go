@@ -0,0 +1,92 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package piper
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A single voice in the voices.json catalog of the piper-voices repository
+// Only the fields needed for resolving a voice are parsed
+type catalogEntry struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Quality  string `json:"quality"`
+	Language struct {
+		Code   string `json:"code"`
+		Family string `json:"family"`
+	} `json:"language"`
+	Aliases []string `json:"aliases"`
+}
+
+// The voices.json catalog keyed by the voice name without the .onnx suffix
+type voiceCatalog map[string]catalogEntry
+
+// Download and parse the voice catalog
+func fetchCatalog(catalogURL string) (voiceCatalog, error) {
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(catalogURL)
+	if err != nil {
+		return nil, fmt.Errorf("error making GET request to %s: %v", catalogURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error: status code %d from %s", resp.StatusCode, catalogURL)
+	}
+
+	var catalog voiceCatalog
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		return nil, fmt.Errorf("error parsing voice catalog from %s: %v", catalogURL, err)
+	}
+	return catalog, nil
+}
+
+// Find a voice in the catalog by its name or one of its aliases
+func (c voiceCatalog) lookup(modelName string) (voiceSpec, error) {
+	name := strings.TrimSuffix(modelName, ".onnx")
+
+	entry, ok := c[name]
+	if !ok {
+		for _, candidate := range c {
+			if slices.Contains(candidate.Aliases, name) {
+				entry, ok = candidate, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return voiceSpec{}, fmt.Errorf("model '%s' was not found in the voice catalog%s", modelName, c.suggestionsFor(name))
+	}
+
+	return voiceSpec{
+		Family:   entry.Language.Family,
+		Language: entry.Language.Code,
+		Voice:    entry.Name,
+		Quality:  entry.Quality,
+	}, nil
+}
+
+// List the voices that share a language with the requested name so a
+// typo in the voice or quality is easy to fix
+func (c voiceCatalog) suggestionsFor(name string) string {
+	language, _, _ := strings.Cut(name, "-")
+
+	var similar []string
+	for key, entry := range c {
+		if entry.Language.Code == language {
+			similar = append(similar, key)
+		}
+	}
+	if len(similar) == 0 {
+		return ""
+	}
+	slices.Sort(similar)
+	return fmt.Sprintf("; voices available for %s are: %s", language, strings.Join(similar, ", "))
+}
@@ -0,0 +1,45 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package piper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testCatalog() voiceCatalog {
+	thorsten := catalogEntry{Key: "de_DE-thorsten-high", Name: "thorsten", Quality: "high"}
+	thorsten.Language.Code = "de_DE"
+	thorsten.Language.Family = "de"
+
+	kerstin := catalogEntry{Key: "de_DE-kerstin-low", Name: "kerstin", Quality: "low", Aliases: []string{"de-kerstin-low"}}
+	kerstin.Language.Code = "de_DE"
+	kerstin.Language.Family = "de"
+
+	return voiceCatalog{thorsten.Key: thorsten, kerstin.Key: kerstin}
+}
+
+func TestCatalogLookup(t *testing.T) {
+	catalog := testCatalog()
+
+	t.Run("by name with or without onnx suffix", func(t *testing.T) {
+		for _, name := range []string{"de_DE-thorsten-high", "de_DE-thorsten-high.onnx"} {
+			voice, err := catalog.lookup(name)
+			require.NoError(t, err)
+			require.Equal(t, voiceSpec{Family: "de", Language: "de_DE", Voice: "thorsten", Quality: "high"}, voice)
+		}
+	})
+
+	t.Run("by alias", func(t *testing.T) {
+		voice, err := catalog.lookup("de-kerstin-low")
+		require.NoError(t, err)
+		require.Equal(t, "de_DE-kerstin-low.onnx", voice.filename())
+	})
+
+	t.Run("unknown voice suggests others in the same language", func(t *testing.T) {
+		_, err := catalog.lookup("de_DE-thorsten-ultra")
+		require.ErrorContains(t, err, "de_DE-kerstin-low, de_DE-thorsten-high")
+	})
+}
@@ -5,11 +5,13 @@ package piper
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/log"
@@ -21,14 +23,23 @@ import (
 type PiperClient struct {
 	binary string
 	model  string
+	// the sample rate of the raw audio the model outputs
+	sampleRate int
+	// how long each phoneme is spoken for relative to the model's default; 0 for the default
+	lengthScale float64
+	// piper is killed when it is done; nil to never kill it
+	ctx context.Context
 }
 
-// Install the piper binary to the specified path
-func installBinary(installationPath string) error {
+// The sample rate most piper models output audio at
+const defaultSampleRate = 22050
+
+// Install the piper binary from the release url to the specified path
+func installBinary(releaseURL, installationPath string) error {
 
 	log.Info("Installing piper...")
 
-	resp, err := http.Get("https://github.com/rhasspy/piper/releases/download/v1.2.0/piper_amd64.tar.gz")
+	resp, err := http.Get(releaseURL)
 	if err != nil {
 		return fmt.Errorf("failed to download piper: %v", err)
 	}
@@ -54,7 +65,11 @@ func installBinary(installationPath string) error {
 	return nil
 }
 
-func NewPiperClient(model string) (*PiperClient, error) {
+// Create a piper client for the given model, downloading piper and the model
+// from the given sources if they are not already installed
+func NewPiperClient(model string, sources DownloadSources) (*PiperClient, error) {
+
+	sources = sources.withDefaults()
 
 	homedir, homedirErr := os.UserHomeDir()
 	if homedirErr != nil {
@@ -78,17 +93,50 @@ func NewPiperClient(model string) (*PiperClient, error) {
 	// Check if piper is already installed
 	if _, err := os.Stat(piperExecutable); err != nil {
 		// Not found, install
-		if installErr := installBinary(QuickPiperAudiobookDir); installErr != nil {
+		if installErr := installBinary(sources.PiperReleaseURL, QuickPiperAudiobookDir); installErr != nil {
 			return nil, fmt.Errorf("failed to install piper: %v", installErr)
 		}
 	}
 
-	fullModelPath, err := findOrDownloadModel(model, QuickPiperAudiobookDir)
+	fullModelPath, err := findOrDownloadModel(model, QuickPiperAudiobookDir, sources)
 	if err != nil {
 		return nil, fmt.Errorf("failed to expand model path: %v", err)
 	}
 
-	return &PiperClient{model: fullModelPath, binary: piperExecutable}, nil
+	sampleRate := defaultSampleRate
+	if info, err := ReadModelInfo(fullModelPath); err == nil && info.SampleRate > 0 {
+		sampleRate = info.SampleRate
+	} else {
+		log.Debugf("Could not read the sample rate of %s; assuming %d", fullModelPath, defaultSampleRate)
+	}
+
+	return &PiperClient{model: fullModelPath, binary: piperExecutable, sampleRate: sampleRate}, nil
+}
+
+// The sample rate of the raw audio that Run outputs when streaming
+func (p PiperClient) SampleRate() int {
+	return p.sampleRate
+}
+
+// The path of the model file the client reads with
+func (p PiperClient) Model() string {
+	return p.model
+}
+
+// Return a copy of the client that speaks at the given speed relative to the
+// model's default, i.e. 1.25 for a quarter faster. 0 or 1 use the default speed
+func (p PiperClient) WithSpeed(speed float64) PiperClient {
+	p.lengthScale = 0
+	if speed > 0 && speed != 1 {
+		p.lengthScale = 1 / speed
+	}
+	return p
+}
+
+// Return a copy of the client whose piper processes are killed when ctx is done
+func (p PiperClient) WithContext(ctx context.Context) PiperClient {
+	p.ctx = ctx
+	return p
 }
 
 // Run calls piper with the given model, using inputData as the text to be spoken.
@@ -118,6 +166,9 @@ func (p PiperClient) Run(filename string, inputData io.Reader, outdir string, st
 
 	var outFilePath string
 	piperArgs := []string{"-m", modelAbs}
+	if p.lengthScale > 0 {
+		piperArgs = append(piperArgs, "--length_scale", strconv.FormatFloat(p.lengthScale, 'f', 3, 64))
+	}
 
 	if streamOutput {
 		piperArgs = append(piperArgs, "--output_raw")
@@ -134,7 +185,11 @@ func (p PiperClient) Run(filename string, inputData io.Reader, outdir string, st
 
 	log.Debugf("Running %s with args %v", p.binary, piperArgs)
 
-	output, err := bin.RunPiped(p.binary, piperArgs, inputData)
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	output, err := bin.RunPipedContext(ctx, p.binary, piperArgs, inputData)
 	if err != nil {
 		return bin.PipedOutput{}, "", fmt.Errorf("failed to run piper: %v", err)
 	}
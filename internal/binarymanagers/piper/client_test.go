@@ -26,7 +26,7 @@ func TestPiperClient(t *testing.T) {
 
 	t.Run("installs binaries", func(t *testing.T) {
 		dir := cleanupConfigDir(t)
-		client, err := NewPiperClient("en_US-lessac-medium.onnx")
+		client, err := NewPiperClient("en_US-lessac-medium.onnx", DownloadSources{})
 		require.NoError(t, err)
 		require.Equal(t, filepath.Join(dir, "piper", "piper"), client.binary)
 		_, err = exec.LookPath(client.binary)
@@ -34,7 +34,7 @@ func TestPiperClient(t *testing.T) {
 	})
 
 	t.Run("converts data", func(t *testing.T) {
-		client, err := NewPiperClient("en_US-lessac-medium.onnx")
+		client, err := NewPiperClient("en_US-lessac-medium.onnx", DownloadSources{})
 		require.NoError(t, err)
 		_, outputFilename, err := client.Run("test_file_name.txt", strings.NewReader("This is some test data for piper integration tests."), ".", false)
 		require.NoError(t, err)
@@ -43,3 +43,12 @@ func TestPiperClient(t *testing.T) {
 	})
 
 }
+
+func TestWithSpeed(t *testing.T) {
+	client := PiperClient{model: "model.onnx"}
+	require.InDelta(t, 0.8, client.WithSpeed(1.25).lengthScale, 0.0001)
+	require.Zero(t, client.WithSpeed(1).lengthScale)
+	require.Zero(t, client.WithSpeed(0).lengthScale)
+	// the original client keeps its speed
+	require.Zero(t, client.lengthScale)
+}
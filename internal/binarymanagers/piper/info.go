@@ -0,0 +1,96 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package piper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Metadata about an installed model, read from its .onnx.json file
+type ModelInfo struct {
+	// The filename of the model, i.e. en_US-lessac-medium.onnx
+	Name string `json:"name"`
+	// The absolute path to the .onnx file
+	Path string `json:"path"`
+	// The language and region code, i.e. en_US
+	Language string `json:"language"`
+	// The english name of the language, i.e. English
+	LanguageName string `json:"language_name,omitempty"`
+	// The quality level of the model, i.e. medium
+	Quality string `json:"quality"`
+	// The sample rate of the raw audio the model outputs
+	SampleRate int `json:"sample_rate"`
+	// The number of speakers the model can speak with
+	NumSpeakers int `json:"num_speakers"`
+	// The size of the .onnx file in bytes
+	SizeBytes int64 `json:"size_bytes"`
+}
+
+// The subset of the .onnx.json config that piper models are shipped with
+type modelConfig struct {
+	Audio struct {
+		SampleRate int    `json:"sample_rate"`
+		Quality    string `json:"quality"`
+	} `json:"audio"`
+	Espeak struct {
+		Voice string `json:"voice"`
+	} `json:"espeak"`
+	Language struct {
+		Code        string `json:"code"`
+		NameEnglish string `json:"name_english"`
+	} `json:"language"`
+	NumSpeakers int `json:"num_speakers"`
+}
+
+// Read the metadata for the model at the given .onnx path
+func ReadModelInfo(onnxPath string) (ModelInfo, error) {
+	stat, err := os.Stat(onnxPath)
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("error reading model %s: %v", onnxPath, err)
+	}
+
+	data, err := os.ReadFile(onnxPath + ".json")
+	if err != nil {
+		return ModelInfo{}, fmt.Errorf("error reading model config for %s: %v", onnxPath, err)
+	}
+
+	var conf modelConfig
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return ModelInfo{}, fmt.Errorf("error parsing model config for %s: %v", onnxPath, err)
+	}
+
+	info := ModelInfo{
+		Name:         filepath.Base(onnxPath),
+		Path:         onnxPath,
+		Language:     conf.Language.Code,
+		LanguageName: conf.Language.NameEnglish,
+		Quality:      conf.Audio.Quality,
+		SampleRate:   conf.Audio.SampleRate,
+		NumSpeakers:  conf.NumSpeakers,
+		SizeBytes:    stat.Size(),
+	}
+
+	// older models don't include all the metadata in their config
+	// so fall back to what we can tell from the espeak voice and the name
+	if voice, err := parseModelName(info.Name); err == nil {
+		if info.Language == "" {
+			info.Language = voice.Language
+		}
+		if info.Quality == "" {
+			info.Quality = voice.Quality
+		}
+	}
+	if info.Language == "" {
+		info.Language = strings.ReplaceAll(conf.Espeak.Voice, "-", "_")
+	}
+	if info.NumSpeakers == 0 {
+		info.NumSpeakers = 1
+	}
+
+	return info, nil
+}
@@ -0,0 +1,56 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package piper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadModelInfo(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("reads metadata from the onnx.json", func(t *testing.T) {
+		onnx := filepath.Join(dir, "pl_PL-gosia-medium.onnx")
+		require.NoError(t, os.WriteFile(onnx, make([]byte, 2048), 0644))
+		const config = `{"audio": {"sample_rate": 22050, "quality": "medium"}, "language": {"code": "pl_PL", "name_english": "Polish"}, "num_speakers": 1}`
+		require.NoError(t, os.WriteFile(onnx+".json", []byte(config), 0644))
+
+		info, err := ReadModelInfo(onnx)
+		require.NoError(t, err)
+		require.Equal(t, ModelInfo{
+			Name:         "pl_PL-gosia-medium.onnx",
+			Path:         onnx,
+			Language:     "pl_PL",
+			LanguageName: "Polish",
+			Quality:      "medium",
+			SampleRate:   22050,
+			NumSpeakers:  1,
+			SizeBytes:    2048,
+		}, info)
+	})
+
+	t.Run("falls back to the name for older configs", func(t *testing.T) {
+		onnx := filepath.Join(dir, "de_DE-thorsten-low.onnx")
+		require.NoError(t, os.WriteFile(onnx, []byte("onnx"), 0644))
+		require.NoError(t, os.WriteFile(onnx+".json", []byte(`{"audio": {"sample_rate": 16000}, "espeak": {"voice": "de"}}`), 0644))
+
+		info, err := ReadModelInfo(onnx)
+		require.NoError(t, err)
+		require.Equal(t, "de_DE", info.Language)
+		require.Equal(t, "low", info.Quality)
+		require.Equal(t, 16000, info.SampleRate)
+		require.Equal(t, 1, info.NumSpeakers)
+	})
+
+	t.Run("missing config", func(t *testing.T) {
+		onnx := filepath.Join(dir, "custom.onnx")
+		require.NoError(t, os.WriteFile(onnx, []byte("onnx"), 0644))
+		_, err := ReadModelInfo(onnx)
+		require.Error(t, err)
+	})
+}
@@ -9,51 +9,73 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/charmbracelet/log"
+
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
 )
 
-// Piper has hundreds of pretrained models on the sample Website
-// These are some of the best ones for English. However, as long
-// as you have both the .onnx and .onnx.json files locally, you
-// can use any model you want or even train your own.
-var ModelToURL = map[string]string{
-	"en_US-hfc_male-medium.onnx":              "https://huggingface.co/rhasspy/piper-voices/resolve/main/en/en_US/hfc_male/medium/en_US-hfc_male-medium.onnx",
-	"en_US-hfc_female-medium.onnx":            "https://huggingface.co/rhasspy/piper-voices/resolve/main/en/en_US/hfc_female/medium/en_US-hfc_female-medium.onnx",
-	"en_US-lessac-medium.onnx":                "https://huggingface.co/rhasspy/piper-voices/resolve/main/en/en_US/lessac/medium/en_US-lessac-medium.onnx",
-	"en_GB-northern_english_male-medium.onnx": "https://huggingface.co/rhasspy/piper-voices/resolve/main/en/en_GB/northern_english_male/medium/en_GB-northern_english_male-medium.onnx",
-	// Below is an example of a non-English model
-	// I happily accept PRs for others here. It is just a bit tedious to enumerate them all
-	// since some do not follow the same pattern.
-	"zh_CN-huayan-medium.onnx": "https://huggingface.co/rhasspy/piper-voices/resolve/main/zh/zh_CN/huayan/medium/zh_CN-huayan-medium.onnx",
-}
-
 // Try to find the model if it exists and otherwise try to download it
-// Return the full path to the model
-func findOrDownloadModel(modelName, defaultModelDir string) (string, error) {
+// from the configured sources. Return the full path to the model
+//
+// Piper has hundreds of pretrained models on the sample Website.
+// Any of them can be used by name, i.e. "de_DE-thorsten-high", and will
+// be downloaded automatically. As long as you have both the .onnx and
+// .onnx.json files locally, you can also use any other model or even train your own.
+func findOrDownloadModel(modelName, defaultModelDir string, sources DownloadSources) (string, error) {
 
 	fullModelPath, err := expandModelPath(modelName, defaultModelDir)
 	if err == nil {
 		return fullModelPath, nil
 	}
 
-	modelURL, ok := ModelToURL[modelName]
-	if !ok {
-		return "", fmt.Errorf("model '%s' not found", modelName)
+	// allow the .onnx suffix to be left off of the model name
+	if !strings.HasSuffix(modelName, ".onnx") {
+		if fullModelPath, onnxErr := expandModelPath(modelName+".onnx", defaultModelDir); onnxErr == nil {
+			return fullModelPath, nil
+		}
 	}
 
-	file, err := lib.DownloadFile(modelURL, modelName, defaultModelDir)
+	voice, resolveErr := resolveVoice(modelName, sources)
+	if resolveErr != nil {
+		return "", fmt.Errorf("%v and it could not be downloaded: %v", err, resolveErr)
+	}
+	modelURL := sources.modelURL(voice)
+	onnxName := voice.filename()
+
+	file, err := lib.DownloadFile(modelURL, onnxName, defaultModelDir)
 	if err != nil {
+		os.Remove(filepath.Join(defaultModelDir, onnxName))
 		return "", fmt.Errorf("error downloading model '%s': %v", modelName, err)
 	}
+	defer file.Close()
+
 	jsonURL := modelURL + ".json"
-	_, err = lib.DownloadFile(jsonURL, modelName+".json", defaultModelDir)
+	_, err = lib.DownloadFile(jsonURL, onnxName+".json", defaultModelDir)
 	if err != nil {
+		// remove both files so that a partial download is not mistaken for an installed model
+		os.Remove(file.Name())
+		os.Remove(filepath.Join(defaultModelDir, onnxName+".json"))
 		return "", fmt.Errorf("error downloading model '%s': %v", modelName, err)
 	}
-	defer file.Close()
 	return file.Name(), nil
 }
 
+// Work out where to download a voice from. The catalog is preferred
+// since it knows about aliases and irregular names, but if there is none or it can't be
+// reached we fall back to the standard lang_REGION-voice-quality naming
+func resolveVoice(modelName string, sources DownloadSources) (voiceSpec, error) {
+	catalogURL := sources.withDefaults().CatalogURL
+	if catalogURL == "" {
+		return parseModelName(modelName)
+	}
+	catalog, err := fetchCatalog(catalogURL)
+	if err != nil {
+		log.Debugf("Could not use the voice catalog, falling back to the model name: %v", err)
+		return parseModelName(modelName)
+	}
+	return catalog.lookup(modelName)
+}
+
 func expandModelPath(modelName string, defaultModelDir string) (string, error) {
 	// when given a modelName check if it is present relatively or in the modelDir
 	// a path should only be valid if both the onnx and onnx.json file is present
@@ -75,6 +97,8 @@ func expandModelPath(modelName string, defaultModelDir string) (string, error) {
 	return "", fmt.Errorf("model '%s' was not found in the current directory or the default model directory: '%s'", modelName, defaultModelDir)
 }
 
+// Return the absolute paths of all the models in a directory that
+// have both an .onnx and a corresponding .onnx.json file
 func FindModels(dir string) ([]string, error) {
 
 	if strings.HasPrefix(dir, "~/") {
@@ -109,7 +133,7 @@ func FindModels(dir string) ([]string, error) {
 			// Check if the .json file exists
 			if _, err := os.Stat(jsonFilePath); err == nil {
 				// If the .json file exists, add the .onnx file path to the result
-				abs, err := filepath.Abs(name)
+				abs, err := filepath.Abs(filepath.Join(dir, name))
 				if err != nil {
 					return nil, fmt.Errorf("error getting absolute path: %v", err)
 				}
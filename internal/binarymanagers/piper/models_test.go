@@ -4,6 +4,9 @@
 package piper
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -62,3 +65,66 @@ func TestExpandModelPath(t *testing.T) {
 		}
 	})
 }
+
+func TestFindOrDownloadModel(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		switch r.URL.Path {
+		case "/voices.json":
+			require.NoError(t, json.NewEncoder(w).Encode(testCatalog()))
+		case "/de/de_DE/thorsten/high/de_DE-thorsten-high.onnx", "/de/de_DE/thorsten/high/de_DE-thorsten-high.onnx.json":
+			_, _ = w.Write([]byte("dummy"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	sources := DownloadSources{ModelBaseURL: server.URL}
+
+	t.Run("downloads a voice by name without the onnx suffix", func(t *testing.T) {
+		dir := t.TempDir()
+		path, err := findOrDownloadModel("de_DE-thorsten-high", dir, sources)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "de_DE-thorsten-high.onnx"), path)
+		require.FileExists(t, path+".json")
+
+		// once downloaded the model is found locally
+		requested = nil
+		path, err = findOrDownloadModel("de_DE-thorsten-high", dir, sources)
+		require.NoError(t, err)
+		require.Equal(t, filepath.Join(dir, "de_DE-thorsten-high.onnx"), path)
+		require.Empty(t, requested)
+	})
+
+	t.Run("falls back to the naming convention without a catalog", func(t *testing.T) {
+		dir := t.TempDir()
+		noCatalog := DownloadSources{ModelBaseURL: server.URL, CatalogURL: server.URL + "/missing.json"}
+		path, err := findOrDownloadModel("de_DE-thorsten-high.onnx", dir, noCatalog)
+		require.NoError(t, err)
+		require.FileExists(t, path)
+	})
+
+	t.Run("failed downloads leave no partial files behind", func(t *testing.T) {
+		dir := t.TempDir()
+		noCatalog := DownloadSources{ModelBaseURL: server.URL, CatalogURL: server.URL + "/missing.json"}
+		_, err := findOrDownloadModel("de_DE-nobody-high", dir, noCatalog)
+		require.Error(t, err)
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+}
+
+func TestFindModels(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"en_US-lessac-medium.onnx", "en_US-lessac-medium.onnx.json", "missing_json.onnx"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644))
+	}
+
+	models, err := FindModels(dir)
+	require.NoError(t, err)
+	// paths are relative to the model directory and not the current directory
+	require.Equal(t, []string{filepath.Join(dir, "en_US-lessac-medium.onnx")}, models)
+}
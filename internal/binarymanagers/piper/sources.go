@@ -0,0 +1,113 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package piper
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// The Hugging Face repository that hosts all the official piper voices
+	DefaultModelBaseURL = "https://huggingface.co/rhasspy/piper-voices/resolve/main"
+	// The catalog that lists every voice in the piper-voices repository
+	DefaultCatalogURL = DefaultModelBaseURL + "/voices.json"
+	// The piper release that is installed if piper is not present
+	DefaultPiperReleaseURL = "https://github.com/rhasspy/piper/releases/download/v1.2.0/piper_amd64.tar.gz"
+
+	// The layout of the piper-voices repository; appended to a model base url
+	// that does not contain any placeholders of its own
+	defaultModelPathTemplate = "{family}/{lang}/{voice}/{quality}/{file}"
+)
+
+// Where piper and its voices are downloaded from. Each value can be overridden
+// so that downloads go through a mirror or an internal artifact server.
+// Empty values fall back to the official upstream locations.
+type DownloadSources struct {
+	// Either a base url that mirrors the layout of the piper-voices repository
+	// or a full url template using the placeholders {family}, {lang}, {voice}, {quality} and {file}
+	// i.e. https://mirror.example.com/piper/{lang}/{voice}-{quality}/{file}
+	ModelBaseURL string
+	// The url of the voices.json catalog. If unset and ModelBaseURL is a plain
+	// base url, the catalog is assumed to be at the root of that base url.
+	// If unset and ModelBaseURL is a template no catalog is used
+	CatalogURL string
+	// The url of the piper release tarball to install
+	PiperReleaseURL string
+}
+
+// Fill in any unset values with the upstream defaults. A template model url
+// leaves the catalog unset so that voices are never looked up upstream
+func (s DownloadSources) withDefaults() DownloadSources {
+	if s.CatalogURL == "" {
+		switch {
+		case s.ModelBaseURL == "":
+			s.CatalogURL = DefaultCatalogURL
+		case !strings.Contains(s.ModelBaseURL, "{"):
+			s.CatalogURL = strings.TrimSuffix(s.ModelBaseURL, "/") + "/voices.json"
+		}
+	}
+	if s.ModelBaseURL == "" {
+		s.ModelBaseURL = DefaultModelBaseURL
+	}
+	if s.PiperReleaseURL == "" {
+		s.PiperReleaseURL = DefaultPiperReleaseURL
+	}
+	return s
+}
+
+// The components of a voice name that are used to build its download url
+type voiceSpec struct {
+	// The language family, i.e. "en"
+	Family string
+	// The language and region code, i.e. "en_US"
+	Language string
+	// The name of the voice, i.e. "lessac"
+	Voice string
+	// The quality level, i.e. "medium"
+	Quality string
+}
+
+// The filename of the onnx model for the voice
+func (v voiceSpec) filename() string {
+	return fmt.Sprintf("%s-%s-%s.onnx", v.Language, v.Voice, v.Quality)
+}
+
+// Parse a model name following the standard piper `lang_REGION-voice-quality.onnx` naming
+func parseModelName(modelName string) (voiceSpec, error) {
+	name := strings.TrimSuffix(modelName, ".onnx")
+
+	parts := strings.Split(name, "-")
+	if len(parts) < 3 {
+		return voiceSpec{}, fmt.Errorf("model name '%s' does not follow the lang_REGION-voice-quality naming convention", modelName)
+	}
+
+	language := parts[0]
+	quality := parts[len(parts)-1]
+	voice := strings.Join(parts[1:len(parts)-1], "-")
+
+	family, _, found := strings.Cut(language, "_")
+	if !found || family == "" || voice == "" || quality == "" {
+		return voiceSpec{}, fmt.Errorf("model name '%s' does not follow the lang_REGION-voice-quality naming convention", modelName)
+	}
+
+	return voiceSpec{Family: family, Language: language, Voice: voice, Quality: quality}, nil
+}
+
+// Build the url of the onnx file for a voice. The onnx.json url is the same with .json appended
+func (s DownloadSources) modelURL(voice voiceSpec) string {
+	template := s.withDefaults().ModelBaseURL
+	if !strings.Contains(template, "{") {
+		template = strings.TrimSuffix(template, "/") + "/" + defaultModelPathTemplate
+	}
+
+	replacer := strings.NewReplacer(
+		"{family}", voice.Family,
+		"{lang}", voice.Language,
+		"{voice}", voice.Voice,
+		"{quality}", voice.Quality,
+		"{file}", voice.filename(),
+	)
+	return replacer.Replace(template)
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package piper
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModelName(t *testing.T) {
+	voice, err := parseModelName("en_GB-northern_english_male-medium.onnx")
+	require.NoError(t, err)
+	require.Equal(t, voiceSpec{Family: "en", Language: "en_GB", Voice: "northern_english_male", Quality: "medium"}, voice)
+
+	voice, err = parseModelName("de_DE-thorsten-high")
+	require.NoError(t, err)
+	require.Equal(t, "de_DE-thorsten-high.onnx", voice.filename())
+
+	_, err = parseModelName("my_custom_model.onnx")
+	require.Error(t, err)
+}
+
+func TestModelURL(t *testing.T) {
+	voice, err := parseModelName("en_US-lessac-medium.onnx")
+	require.NoError(t, err)
+
+	t.Run("defaults to hugging face", func(t *testing.T) {
+		require.Equal(t,
+			"https://huggingface.co/rhasspy/piper-voices/resolve/main/en/en_US/lessac/medium/en_US-lessac-medium.onnx",
+			DownloadSources{}.modelURL(voice),
+		)
+	})
+
+	t.Run("base url mirrors the upstream layout", func(t *testing.T) {
+		sources := DownloadSources{ModelBaseURL: "https://artifacts.example.com/hf/piper-voices/"}
+		require.Equal(t,
+			"https://artifacts.example.com/hf/piper-voices/en/en_US/lessac/medium/en_US-lessac-medium.onnx",
+			sources.modelURL(voice),
+		)
+		require.Equal(t, "https://artifacts.example.com/hf/piper-voices/voices.json", sources.withDefaults().CatalogURL)
+	})
+
+	t.Run("template with placeholders", func(t *testing.T) {
+		sources := DownloadSources{ModelBaseURL: "https://artifacts.example.com/voices/{lang}/{voice}-{quality}/{file}"}
+		require.Equal(t,
+			"https://artifacts.example.com/voices/en_US/lessac-medium/en_US-lessac-medium.onnx",
+			sources.modelURL(voice),
+		)
+		// the catalog is not looked up upstream for a mirror that only serves the models
+		require.Empty(t, sources.withDefaults().CatalogURL)
+		resolved, err := resolveVoice("en_US-lessac-medium", sources)
+		require.NoError(t, err)
+		require.Equal(t, voice, resolved)
+
+		sources.CatalogURL = "https://artifacts.example.com/voices.json"
+		require.Equal(t, sources.CatalogURL, sources.withDefaults().CatalogURL)
+	})
+}
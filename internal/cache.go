@@ -0,0 +1,257 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+
+	log "github.com/charmbracelet/log"
+)
+
+// Bump when the audio written for the same text changes, i.e. a new bitrate,
+// so that audio made the old way is not reused
+const audioCacheVersion = 1
+
+// The subdirectories of a cache directory that hold the audio of chapters and the converted text.
+// Only these are ever removed so pointing the cache at a directory with other files in it is safe
+const (
+	audioCacheDir = "audio"
+	textCacheDir  = "text"
+)
+
+// The default for the most megabytes the cache may take up before the least recently used entries are removed
+const DefaultCacheSize = 2048
+
+// Return the directory that the audio of chapters and converted text are cached in by default
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cache directory: %v", err)
+	}
+	return filepath.Join(dir, "QuickPiperAudiobook"), nil
+}
+
+// Remove the cached audio and text in a cache directory, leaving anything else in it alone
+func ClearCache(dir string) error {
+	for _, part := range []string{audioCacheDir, textCacheDir} {
+		if err := os.RemoveAll(filepath.Join(dir, part)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the least recently used audio and text in a cache directory until it takes up
+// at most maxBytes. Entries are marked as used when they are read from the cache
+func PruneCache(dir string, maxBytes int64) error {
+	type cachedFile struct {
+		path string
+		info fs.FileInfo
+	}
+	var files []cachedFile
+	var total int64
+	for _, part := range []string{audioCacheDir, textCacheDir} {
+		err := filepath.WalkDir(filepath.Join(dir, part), func(path string, entry fs.DirEntry, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			files = append(files, cachedFile{path: path, info: info})
+			total += info.Size()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	slices.SortFunc(files, func(a, b cachedFile) int {
+		return a.info.ModTime().Compare(b.info.ModTime())
+	})
+	for _, file := range files {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(file.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		total -= file.info.Size()
+		log.Debugf("Removed %s from the cache", file.path)
+	}
+	return nil
+}
+
+// Keep the cache under its size limit after a conversion. Failing to prune is only logged
+// since the audiobook was already made
+func (config AudiobookArgs) pruneCache() {
+	if config.CacheDir == "" || config.CacheSize <= 0 {
+		return
+	}
+	if err := PruneCache(config.CacheDir, config.CacheSize); err != nil {
+		log.Warnf("Failed to prune the cache in %s: %v", config.CacheDir, err)
+	}
+}
+
+// Mark a cached file as used so it is among the last to be pruned
+func touch(path string) {
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		log.Debugf("Failed to mark %s as used: %v", path, err)
+	}
+}
+
+// A content addressed store of the audio of chapters that were already read,
+// keyed by a hash of their normalized text and how they were read, so that
+// chapters are not synthesized again when nothing about them changed. The
+// zero value is a cache that is turned off
+type audioCache struct {
+	dir string
+}
+
+// Return the key of the audio for text read with a model at a speed.
+// The text should be normalized so that changes that don't affect how it
+// is read, like a fixed filter, still use the cached audio
+func chapterKey(text, model string, speed float64) string {
+	hash := sha256.New()
+	for _, part := range []string{strconv.Itoa(audioCacheVersion), text, modelIdentity(model), strconv.FormatFloat(speed, 'f', -1, 64)} {
+		// the length prefix keeps parts from running into each other
+		fmt.Fprintf(hash, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Identify the model file that audio was read with by its path, size and modification time
+// so that different models with the same file name, or a model that was replaced, don't share audio
+func modelIdentity(model string) string {
+	if abs, err := filepath.Abs(model); err == nil {
+		model = abs
+	}
+	info, err := os.Stat(model)
+	if err != nil {
+		return model
+	}
+	return fmt.Sprintf("%s:%d:%d", model, info.Size(), info.ModTime().UnixNano())
+}
+
+func (c audioCache) enabled() bool {
+	return c.dir != ""
+}
+
+// Keys are spread over subdirectories by their first two characters so no directory gets too big
+func (c audioCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".mp3")
+}
+
+// Return the cached audio for a key if there is any
+func (c audioCache) get(key string) (string, bool) {
+	if !c.enabled() {
+		return "", false
+	}
+	path := c.path(key)
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		return "", false
+	}
+	touch(path)
+	return path, true
+}
+
+// Copy audio into the cache and return its path in the cache
+func (c audioCache) put(key, file string) (string, error) {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	source, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+
+	// write to a temporary file first so an interrupted copy is never used
+	temp, err := os.CreateTemp(filepath.Dir(path), key+"-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := io.Copy(temp, source); err != nil {
+		temp.Close()
+		return "", err
+	}
+	if err := temp.Close(); err != nil {
+		return "", err
+	}
+	return path, os.Rename(temp.Name(), path)
+}
+
+// Link a file to dest, or copy it if it can't be linked i.e. because it is on another filesystem
+func linkOrCopy(source, dest string) error {
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Link(source, dest); err == nil {
+		return nil
+	}
+	input, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer input.Close()
+	output, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(output, input); err != nil {
+		output.Close()
+		return err
+	}
+	return output.Close()
+}
+
+// Read prepared text into an mp3 at outputName, or reuse the audio of the same text
+// from the cache. The audio is always at outputName so that pruning the cache while
+// other books are converted can't remove it before it is joined. Returns outputName
+func synthesizeCached(ctx context.Context, cache audioCache, client *piper.PiperClient, speed float64, text []byte, outputName string) (string, error) {
+	key := chapterKey(string(text), client.Model(), speed)
+	if cached, ok := cache.get(key); ok {
+		log.Debugf("Reusing cached audio %s for %s", cached, outputName)
+		return outputName, linkOrCopy(cached, outputName)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(outputName), filepath.Ext(outputName))
+	streamOutput, _, err := client.WithSpeed(speed).Run(name, bytes.NewReader(text), filepath.Dir(outputName), true)
+	if err != nil {
+		return "", err
+	}
+	if err := ffmpeg.OutputToMp3(ctx, streamOutput.Stdout, client.SampleRate(), outputName); err != nil {
+		return "", err
+	}
+
+	if cache.enabled() {
+		if _, err := cache.put(key, outputName); err != nil {
+			return "", err
+		}
+	}
+	return outputName, nil
+}
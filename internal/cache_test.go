@@ -0,0 +1,110 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChapterKey(t *testing.T) {
+	model := filepath.Join(t.TempDir(), "en_US-hfc_male-medium.onnx")
+	require.NoError(t, os.WriteFile(model, []byte("model"), 0644))
+	key := chapterKey("Hello there.", model, 1)
+	require.Equal(t, key, chapterKey("Hello there.", model, 1))
+	require.NotEqual(t, key, chapterKey("Hello there!", model, 1))
+	require.NotEqual(t, key, chapterKey("Hello there.", model, 1.2))
+
+	// a model with the same name from somewhere else reads differently
+	mirrored := filepath.Join(t.TempDir(), "en_US-hfc_male-medium.onnx")
+	require.NoError(t, os.WriteFile(mirrored, []byte("other model"), 0644))
+	require.NotEqual(t, key, chapterKey("Hello there.", mirrored, 1))
+
+	// so does a model that was replaced
+	replaced := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(model, replaced, replaced))
+	require.NotEqual(t, key, chapterKey("Hello there.", model, 1))
+}
+
+func TestAudioCache(t *testing.T) {
+	var disabled audioCache
+	_, ok := disabled.get("key")
+	require.False(t, ok)
+
+	root := t.TempDir()
+	cache := audioCache{dir: filepath.Join(root, audioCacheDir)}
+	_, ok = cache.get("key")
+	require.False(t, ok)
+
+	audio := filepath.Join(t.TempDir(), "chapter.mp3")
+	require.NoError(t, os.WriteFile(audio, []byte("audio"), 0644))
+	cached, err := cache.put("key", audio)
+	require.NoError(t, err)
+
+	path, ok := cache.get("key")
+	require.True(t, ok)
+	require.Equal(t, cached, path)
+
+	require.Equal(t, filepath.Join(cache.dir, "ke", "key.mp3"), path)
+
+	// the audio handed out survives the cache being pruned before it is used
+	chapter := filepath.Join(t.TempDir(), "0001.mp3")
+	require.NoError(t, linkOrCopy(path, chapter))
+	require.NoError(t, PruneCache(root, 0))
+	require.NoFileExists(t, path)
+	data, err := os.ReadFile(chapter)
+	require.NoError(t, err)
+	require.Equal(t, "audio", string(data))
+
+	// clearing the cache leaves files that aren't part of it alone
+	unrelated := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(unrelated, []byte("notes"), 0644))
+	require.NoError(t, ClearCache(root))
+	_, ok = cache.get("key")
+	require.False(t, ok)
+	require.FileExists(t, unrelated)
+}
+
+func TestPruneCache(t *testing.T) {
+	root := t.TempDir()
+	unrelated := filepath.Join(root, "notes.txt")
+	require.NoError(t, os.WriteFile(unrelated, make([]byte, 100), 0644))
+
+	// each file is used an hour after the one before it
+	start := time.Now().Add(-24 * time.Hour)
+	var files []string
+	for i, part := range []string{audioCacheDir, textCacheDir, audioCacheDir} {
+		file := filepath.Join(root, part, "ab", fmt.Sprintf("%d", i))
+		require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+		require.NoError(t, os.WriteFile(file, make([]byte, 100), 0644))
+		used := start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(file, used, used))
+		files = append(files, file)
+	}
+
+	require.NoError(t, PruneCache(root, 300))
+	for _, file := range files {
+		require.FileExists(t, file)
+	}
+
+	require.NoError(t, PruneCache(root, 200))
+	require.NoFileExists(t, files[0])
+	require.FileExists(t, files[1])
+	require.FileExists(t, files[2])
+
+	// reading an entry keeps it over entries that were used more recently
+	cache := audioCache{dir: filepath.Join(root, audioCacheDir)}
+	require.NoError(t, os.Rename(files[1], cache.path("abcd")))
+	_, ok := cache.get("abcd")
+	require.True(t, ok)
+	require.NoError(t, PruneCache(root, 100))
+	require.NoFileExists(t, files[2])
+	require.FileExists(t, cache.path("abcd"))
+	require.FileExists(t, unrelated)
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ebookconvert "github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ebookConvert"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/formats"
+
+	log "github.com/charmbracelet/log"
+)
+
+// Convert a section of an epub to plain text. The built in extractor is used unless
+// ebook-convert was asked for, with ebook-convert as a fallback for sections it
+// can't parse. Returns an empty string for sections without any text
+func sectionText(section epub.SectionData, config AudiobookArgs) (string, error) {
+	xhtml, err := io.ReadAll(section.Text)
+	if err != nil {
+		return "", err
+	}
+
+	if !config.EbookConvert {
+		text, err := epub.ExtractText(bytes.NewReader(xhtml))
+		if err == nil {
+			return text, nil
+		}
+		log.Warnf("Could not read %s with the built in extractor, using ebook-convert instead: %v", section.Filename, err)
+	}
+
+	converted, err := ebookconvert.ConvertToText(bytes.NewReader(xhtml), filepath.Ext(section.Filename), config.textCacheDir())
+	if err != nil {
+		var emptyErr *ebookconvert.EmptyConversionResultError
+		if errors.As(err, &emptyErr) {
+			return "", nil
+		}
+		return "", err
+	}
+	defer converted.Close()
+
+	text, err := io.ReadAll(converted)
+	return string(text), err
+}
+
+// Convert an epub to plain text, reading the sections picked by --sections and --skip in reading order
+func epubText(config AudiobookArgs) (string, error) {
+	splitter, err := epub.NewEpubSplitter(config.FileName)
+	if err != nil {
+		return "", err
+	}
+	defer splitter.Close()
+
+	sections, err := splitter.SplitBySection()
+	if err != nil {
+		return "", err
+	}
+	if sections, err = pickSections(sections, config); err != nil {
+		return "", err
+	}
+
+	var texts []string
+	for _, section := range sections {
+		text, err := sectionText(section, config)
+		if err != nil {
+			return "", err
+		}
+		if text = strings.TrimSpace(text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// Whether a book is read with one of the built in readers in the formats package
+// instead of ebook-convert. Epubs have their own built in extractor
+func hasNativeReader(config AudiobookArgs) bool {
+	_, ok := formats.ForExtension(filepath.Ext(config.FileName))
+	return ok && !config.EbookConvert
+}
+
+// Read a book with the built in reader for its format
+func readDocument(config AudiobookArgs) (formats.Document, error) {
+	reader, ok := formats.ForExtension(filepath.Ext(config.FileName))
+	if !ok {
+		return formats.Document{}, fmt.Errorf("there is no built in reader for %s files", filepath.Ext(config.FileName))
+	}
+
+	file, err := os.Open(config.FileName)
+	if err != nil {
+		return formats.Document{}, err
+	}
+	defer file.Close()
+
+	document, err := reader(file)
+	if err != nil {
+		return formats.Document{}, fmt.Errorf("could not read %s: %w", config.FileName, err)
+	}
+	return document, nil
+}
+
+// Read the chapters of a book that has a built in reader and return them along with
+// the book's language; the one it declares or else the one detected from its text
+func documentChapters(config AudiobookArgs) ([]chapterText, string, error) {
+	document, err := readDocument(config)
+	if err != nil {
+		return nil, "", err
+	}
+
+	language := document.Language
+	if language == "" {
+		text := document.Text()
+		language = lang.Detect(text[:min(len(text), languageSampleSize)])
+	}
+
+	var chapters []chapterText
+	for _, chapter := range document.Chapters {
+		text := strings.TrimSpace(chapter.Text)
+		if text == "" {
+			continue
+		}
+		title := chapter.Title
+		if title == "" {
+			title = sectionTitle(text)
+		}
+		chapters = append(chapters, chapterText{title: title, text: text, language: language})
+	}
+	if len(chapters) == 0 {
+		return nil, "", fmt.Errorf("no text was found in %s", config.FileName)
+	}
+	return chapters, language, nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Make sure epubs are read without ebook-convert by default
+func TestEpubText(t *testing.T) {
+	text, err := epubText(AudiobookArgs{FileName: "testdata/titlepage_and_2_chapters.epub"})
+	require.NoError(t, err)
+	require.Equal(t, "Chapter 1.\n\ntest\n\nChapter 2.\n\ntest2", text)
+}
+
+// Make sure only the picked sections are read when the book is not split into chapters
+func TestEpubTextPicksSections(t *testing.T) {
+	text, err := epubText(AudiobookArgs{FileName: "testdata/titlepage_and_2_chapters.epub", Skip: DefaultSkip})
+	require.NoError(t, err)
+	require.Equal(t, "Chapter 1.\n\ntest\n\nChapter 2.\n\ntest2", text)
+
+	text, err = epubText(AudiobookArgs{FileName: "testdata/titlepage_and_2_chapters.epub", Sections: []string{"Chapter 2"}})
+	require.NoError(t, err)
+	require.Equal(t, "Chapter 2.\n\ntest2", text)
+
+	text, err = epubText(AudiobookArgs{FileName: "testdata/titlepage_and_2_chapters.epub", Skip: []string{"bodymatter"}})
+	require.NoError(t, err)
+	require.Empty(t, text)
+}
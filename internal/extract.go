@@ -0,0 +1,319 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/filters"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+
+	log "github.com/charmbracelet/log"
+	"golang.org/x/sync/errgroup"
+	"gopkg.in/yaml.v3"
+)
+
+// The name of the manifest written by Extract and read by Synthesize
+const ManifestName = "manifest.yaml"
+
+// Describes a directory of extracted text that can be edited before it is synthesized
+type Manifest struct {
+	// The title of the book, used to name the audiobook
+	Title string `yaml:"title"`
+	// The book the text was extracted from
+	Source string `yaml:"source"`
+	// The language of the book, used to pick voices when voices are picked automatically
+	Language string `yaml:"language,omitempty"`
+	// The model that reads the book; empty to pick it from the language
+	Voice string `yaml:"voice,omitempty"`
+	// The sections of the book in the order they are read
+	Sections []ManifestSection `yaml:"sections"`
+}
+
+// A text file in an extracted directory that becomes one chapter
+type ManifestSection struct {
+	// The text file relative to the manifest
+	File string `yaml:"file"`
+	// The title of the chapter
+	Title string `yaml:"title"`
+	// The language of the section if it is different from the book
+	Language string `yaml:"language,omitempty"`
+	// The model that reads this section instead of the book's voice
+	Voice string `yaml:"voice,omitempty"`
+}
+
+// A piece of text that becomes one chapter of an audiobook
+type chapterText struct {
+	title    string
+	text     string
+	language string
+	// the model to read the chapter with; empty to pick it from the language
+	model string
+	// how fast to read the chapter relative to the model's default; 0 for the default
+	speed float64
+}
+
+// Convert a book to plain text and write one numbered text file per section
+// into dir along with a manifest, so the text can be edited before it is read.
+// Sections are picked and cleaned up the same way as when converting directly
+func Extract(config AudiobookArgs, dir string) (Manifest, error) {
+	config, err := expandHomeDir(config)
+	if err != nil {
+		return Manifest{}, err
+	}
+	config.OutputDirectory = dir
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Manifest{}, err
+	}
+	if err := sanityCheckConfig(&config); err != nil {
+		return Manifest{}, err
+	}
+
+	if err := downloadBook(&config); err != nil {
+		return Manifest{}, err
+	}
+
+	chapters, language, err := extractChapters(config)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	manifest := Manifest{
+		Title:    strings.TrimSuffix(filepath.Base(config.FileName), filepath.Ext(config.FileName)),
+		Source:   config.FileName,
+		Language: language,
+	}
+	if !config.AutoVoice {
+		manifest.Voice = config.Model
+	}
+
+	for i, chapter := range chapters {
+		file := fmt.Sprintf("%04d-%s.txt", i+1, slug(chapter.title))
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(chapter.text), 0644); err != nil {
+			return Manifest{}, err
+		}
+		manifest.Sections = append(manifest.Sections, ManifestSection{File: file, Title: chapter.title})
+	}
+
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return Manifest{}, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestName), data, 0644); err != nil {
+		return Manifest{}, err
+	}
+	log.Infof("Extracted %d sections to %s", len(manifest.Sections), dir)
+	return manifest, nil
+}
+
+// Convert a book to cleaned up plain text chapters and return them along with the book's language
+func extractChapters(config AudiobookArgs) ([]chapterText, string, error) {
+	textFilters, err := filters.New(config.FilterPresets, config.DropLines, config.Replacements)
+	if err != nil {
+		return nil, "", err
+	}
+
+	chapters, language, err := bookChapters(config)
+	if err != nil {
+		return nil, "", err
+	}
+	var kept []chapterText
+	for _, chapter := range chapters {
+		if chapter.text = strings.TrimSpace(textFilters.Apply(chapter.text)); chapter.text != "" {
+			kept = append(kept, chapter)
+		}
+	}
+	return kept, language, nil
+}
+
+// Read the manifest of a directory written by Extract
+func ReadManifest(dir string) (Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("could not read the manifest in %s; create it with the extract command: %v", dir, err)
+	}
+	var manifest Manifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, fmt.Errorf("failed to parse %s: %v", ManifestName, err)
+	}
+	if len(manifest.Sections) == 0 {
+		return Manifest{}, fmt.Errorf("the manifest in %s has no sections", dir)
+	}
+	if manifest.Title != "" {
+		if err := checkTitle(manifest.Title); err != nil {
+			return Manifest{}, fmt.Errorf("the manifest in %s: %v", dir, err)
+		}
+	}
+	return manifest, nil
+}
+
+// Build an audiobook from a directory written by Extract, reading the sections
+// in the order of its manifest. Returns the path of the audiobook
+func Synthesize(config AudiobookArgs, dir string) (string, error) {
+	config, err := expandHomeDir(config)
+	if err != nil {
+		return "", err
+	}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if manifest.Voice != "" {
+		config.Model = manifest.Voice
+		config.AutoVoice = false
+	}
+	// the text was already cleaned up when it was extracted so it is only
+	// prepared for reading, using the lexicon of the book it came from
+	config.lexicon, err = LoadLexicon(manifest.Source, config.LexiconFiles)
+	if err != nil {
+		return "", err
+	}
+
+	var chapters []chapterText
+	for _, section := range manifest.Sections {
+		text, err := os.ReadFile(filepath.Join(dir, section.File))
+		if err != nil {
+			return "", err
+		}
+		language := section.Language
+		if language == "" {
+			language = manifest.Language
+		}
+		chapters = append(chapters, chapterText{title: section.Title, text: string(text), language: language, model: section.Voice})
+	}
+
+	title := manifest.Title
+	if title == "" {
+		title = filepath.Base(dir)
+	}
+	outputName := filepath.Join(config.OutputDirectory, title+".mp3")
+
+	tempDir, err := os.MkdirTemp("", "piper-chapters-dir-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tempDir)
+
+	voices := newVoiceSelector(config)
+	sections, err := synthesizeChapters(voices, config, chapters, manifest.Language, config.audioCache(), tempDir)
+	if err != nil {
+		return "", err
+	}
+	if err := ffmpeg.ConcatMp3s(sections, outputName); err != nil {
+		return "", err
+	}
+	config.pruneCache()
+	log.Infof("Audiobook created at: %s", outputName)
+	return outputName, nil
+}
+
+// Read each chapter with its voice into an mp3 in tempDir, or take it from
+// the cache if it was read before, and return the mp3s in order.
+// Chapters without any text are left out
+func synthesizeChapters(voices *voiceSelector, config AudiobookArgs, chapters []chapterText, bookLanguage string, cache audioCache, tempDir string) ([]ffmpeg.Mp3Section, error) {
+	errorGroup := errgroup.Group{}
+	if config.Threads > 0 {
+		errorGroup.SetLimit(config.Threads)
+	}
+	mp3InOrder := make([]ffmpeg.Mp3Section, len(chapters))
+	var finished atomic.Int32
+
+	for i, chapter := range chapters {
+		i, chapter := i, chapter
+		errorGroup.Go(func() error {
+			model, speakUTF8 := voices.voiceFor(chapter.language, bookLanguage)
+			if chapter.model != "" {
+				// a voice for the chapter's language can speak its characters
+				model = chapter.model
+				speakUTF8 = config.SpeakUTF8 || modelLanguage(model) == lang.Base(chapter.language)
+			}
+
+			prepared, err := prepareText(strings.NewReader(chapter.text), config, chapter.language, model, speakUTF8)
+			if err != nil {
+				return err
+			}
+			defer prepared.Close()
+			text, err := io.ReadAll(prepared)
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(string(text)) == "" {
+				log.Warnf("Chapter %d '%s' has no text and will be skipped", i+1, chapter.title)
+				return nil
+			}
+
+			client, err := voices.clientForModel(model)
+			if err != nil {
+				return err
+			}
+			release := voices.acquire()
+			mp3, err := synthesizeCached(config.ctx(), cache, client, chapter.speed, text, filepath.Join(tempDir, fmt.Sprintf("%04d.mp3", i+1)))
+			release()
+			if err != nil {
+				return err
+			}
+			log.Debugf("Read chapter %d '%s' with %s", i+1, chapter.title, model)
+			mp3InOrder[i] = ffmpeg.Mp3Section{Mp3File: mp3, Title: chapter.title}
+			config.reportProgress(int(finished.Add(1)), len(chapters))
+			return nil
+		})
+	}
+	if err := errorGroup.Wait(); err != nil {
+		return nil, err
+	}
+
+	var sections []ffmpeg.Mp3Section
+	for _, section := range mp3InOrder {
+		if section.Mp3File != "" {
+			sections = append(sections, section)
+		}
+	}
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("none of the chapters have any text to read")
+	}
+	return sections, nil
+}
+
+// Check that a title can name an output file without leaving the output directory
+func checkTitle(title string) error {
+	if title == "." || title == ".." || strings.ContainsAny(title, `/\`) {
+		return fmt.Errorf("the title '%s' can't be used as a file name; it must not contain / or \\", title)
+	}
+	return nil
+}
+
+// Make a short file name friendly version of a title i.e. "Chapter 1: The Sea" -> "chapter-1-the-sea"
+func slug(title string) string {
+	const maxSlugRunes = 40
+	var builder strings.Builder
+	dash := false
+	runes := 0
+	for _, r := range strings.ToLower(title) {
+		if runes >= maxSlugRunes {
+			break
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+			dash = false
+		} else if !dash && builder.Len() > 0 {
+			builder.WriteRune('-')
+			dash = true
+		}
+		runes++
+	}
+	result := strings.Trim(builder.String(), "-")
+	if result == "" {
+		return "section"
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSlug(t *testing.T) {
+	require.Equal(t, "chapter-1-the-sea", slug("Chapter 1: The Sea"))
+	require.Equal(t, "łódź-nocą", slug("  Łódź nocą!"))
+	require.Equal(t, "section", slug("***"))
+	require.LessOrEqual(t, len([]rune(slug("a very long title that goes on and on and on and on and on"))), 40)
+}
+
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := ReadManifest(dir)
+	require.Error(t, err)
+
+	manifest := Manifest{
+		Title:    "Book",
+		Source:   "book.epub",
+		Language: "en",
+		Sections: []ManifestSection{
+			{File: "0001-one.txt", Title: "One"},
+			{File: "0002-deux.txt", Title: "Deux", Language: "fr", Voice: "fr_FR-siwis-medium.onnx"},
+		},
+	}
+	data, err := yaml.Marshal(manifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestName), data, 0644))
+
+	read, err := ReadManifest(dir)
+	require.NoError(t, err)
+	require.Equal(t, manifest, read)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestName), []byte("title: Empty\nsections: []\n"), 0644))
+	_, err = ReadManifest(dir)
+	require.ErrorContains(t, err, "no sections")
+
+	manifest.Title = "../../Book"
+	data, err = yaml.Marshal(manifest)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestName), data, 0644))
+	_, err = ReadManifest(dir)
+	require.ErrorContains(t, err, "can't be used as a file name")
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package filters removes boilerplate like page numbers, licence blocks
+// and illustration markers from converted text before it is read
+package filters
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// A find and replace rule
+type replacement struct {
+	find *regexp.Regexp
+	with string
+}
+
+// A run of lines to drop, from the line matching start to the line matching end.
+// A nil start drops from the beginning of the text and a nil end drops to the end.
+// Nothing is dropped unless both ends of the block are found within maxBlockLines
+type block struct {
+	start, end *regexp.Regexp
+}
+
+// A set of rules that remove or rewrite parts of the text
+type Filters struct {
+	blocks       []block
+	drops        []*regexp.Regexp
+	replacements []replacement
+}
+
+// The separator between the pattern and the replacement in a replace rule
+const replaceSeparator = "=>"
+
+// Create filters from the names of built in presets, lines to drop and replacements.
+// Lines matching any of the drop regexes are removed. Replacements are written
+// as "regex=>replacement" and can refer to groups in the regex with $1
+func New(presets, drop, replace []string) (Filters, error) {
+	var filters Filters
+
+	for _, name := range presets {
+		preset, ok := Presets[name]
+		if !ok {
+			return Filters{}, fmt.Errorf("unknown filter preset '%s'; expected one of %s", name, strings.Join(PresetNames(), ", "))
+		}
+		filters.blocks = append(filters.blocks, preset.blocks...)
+		filters.drops = append(filters.drops, preset.drops...)
+		filters.replacements = append(filters.replacements, preset.replacements...)
+	}
+
+	for _, expression := range drop {
+		pattern, err := regexp.Compile(expression)
+		if err != nil {
+			return Filters{}, fmt.Errorf("invalid regex for dropping lines '%s': %v", expression, err)
+		}
+		filters.drops = append(filters.drops, pattern)
+	}
+
+	for _, rule := range replace {
+		find, with, ok := strings.Cut(rule, replaceSeparator)
+		if !ok {
+			return Filters{}, fmt.Errorf("replacement '%s' should be written as regex%sreplacement", rule, replaceSeparator)
+		}
+		pattern, err := regexp.Compile(find)
+		if err != nil {
+			return Filters{}, fmt.Errorf("invalid regex for replacement '%s': %v", find, err)
+		}
+		filters.replacements = append(filters.replacements, replacement{find: pattern, with: with})
+	}
+
+	return filters, nil
+}
+
+// Whether there are any rules to apply
+func (f Filters) Enabled() bool {
+	return len(f.blocks)+len(f.drops)+len(f.replacements) > 0
+}
+
+// The most lines a block can span. A marker without its other marker within this many
+// lines, like a mention of the Project Gutenberg licence in the middle of a book, is not a
+// block, and the lines held back while looking for the other marker are kept
+const maxBlockLines = 1000
+
+// Apply the rules to text. Blocks are dropped first, then single lines, then replacements
+func (f Filters) Apply(text string) string {
+	if !f.Enabled() {
+		return text
+	}
+
+	var result strings.Builder
+	s := f.newStream(func(line string) {
+		result.WriteString(line)
+	})
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if line != "" {
+			s.push(line)
+		}
+	}
+	s.finish()
+	return result.String()
+}
+
+// Apply the rules to text as it is read. Only the lines that may be part of a block
+// are held back until it is known whether the block is dropped.
+// The result must be closed if it isn't read to the end
+func (f Filters) Reader(input io.Reader) (io.ReadCloser, error) {
+	if !f.Enabled() {
+		return io.NopCloser(input), nil
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		var writeErr error
+		s := f.newStream(func(line string) {
+			if writeErr == nil {
+				_, writeErr = io.WriteString(writer, line)
+			}
+		})
+		buffered := bufio.NewReader(input)
+		for {
+			line, readErr := buffered.ReadString('\n')
+			if line != "" {
+				s.push(line)
+			}
+			if writeErr != nil {
+				// the reader was closed so there is nobody left to write to
+				return
+			}
+			if readErr == io.EOF {
+				s.finish()
+				writer.CloseWithError(writeErr)
+				return
+			}
+			if readErr != nil {
+				writer.CloseWithError(readErr)
+				return
+			}
+		}
+	}()
+	return reader, nil
+}
+
+// Applies the rules to lines one at a time, holding back the lines of a block
+// until its closing marker is found
+type stream struct {
+	filters Filters
+	output  func(line string)
+	// the block whose lines are being held back and the lines, starting with its opening marker
+	open    *block
+	pending []string
+	// the blocks that start at the beginning of the text are only looked for there
+	started bool
+}
+
+func (f Filters) newStream(output func(line string)) *stream {
+	return &stream{filters: f, output: output}
+}
+
+// Add the next line of the text, including its line ending
+func (s *stream) push(line string) {
+	if !s.started {
+		s.started = true
+		for i := range s.filters.blocks {
+			if s.filters.blocks[i].start == nil {
+				s.open = &s.filters.blocks[i]
+				break
+			}
+		}
+	}
+
+	if s.open != nil {
+		s.pending = append(s.pending, line)
+		if s.open.end != nil && matches(s.open.end)(line) {
+			// the whole block was found so it is dropped
+			s.open, s.pending = nil, nil
+		} else if len(s.pending) > maxBlockLines {
+			s.abandon()
+		}
+		return
+	}
+
+	for i := range s.filters.blocks {
+		if b := &s.filters.blocks[i]; b.start != nil && matches(b.start)(line) {
+			s.open, s.pending = b, []string{line}
+			return
+		}
+	}
+	s.emit(line)
+}
+
+// Flush the lines that are held back at the end of the text
+func (s *stream) finish() {
+	for s.open != nil {
+		if s.open.end == nil {
+			// a block that runs to the end of the text is closed by it
+			s.open, s.pending = nil, nil
+			return
+		}
+		s.abandon()
+	}
+}
+
+// Keep the lines of a block whose closing marker was not found. Its opening marker is
+// kept as it is and the lines after it are looked at again since they may start other blocks
+func (s *stream) abandon() {
+	pending := s.pending
+	opener := s.open
+	s.open, s.pending = nil, nil
+	if opener.start != nil {
+		s.emit(pending[0])
+		pending = pending[1:]
+	}
+	for _, line := range pending {
+		s.push(line)
+	}
+}
+
+// Write a line that is not part of a block after dropping and rewriting it
+func (s *stream) emit(line string) {
+	content := strings.TrimRight(line, "\r\n")
+	ending := line[len(content):]
+	for _, drop := range s.filters.drops {
+		if drop.MatchString(content) {
+			return
+		}
+	}
+	for _, r := range s.filters.replacements {
+		content = r.find.ReplaceAllString(content, r.with)
+	}
+	s.output(content + ending)
+}
+
+func matches(pattern *regexp.Regexp) func(line string) bool {
+	return func(line string) bool {
+		return pattern.MatchString(strings.TrimRight(line, "\r\n"))
+	}
+}
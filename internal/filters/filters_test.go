@@ -0,0 +1,97 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package filters
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const gutenbergBook = `The Project Gutenberg eBook of Frankenstein
+This eBook is for the use of anyone anywhere.
+*** START OF THE PROJECT GUTENBERG EBOOK FRANKENSTEIN ***
+Letter 1
+[Illustration: A ship in the ice]
+You will rejoice to hear
+*** END OF THE PROJECT GUTENBERG EBOOK FRANKENSTEIN ***
+Updated editions will replace the previous one.
+`
+
+func TestGutenbergPreset(t *testing.T) {
+	filters, err := New(DefaultPresets, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "Letter 1\n\nYou will rejoice to hear\n", filters.Apply(gutenbergBook))
+
+	// text without the markers is left alone
+	require.Equal(t, "Letter 1\nYou will rejoice\n", filters.Apply("Letter 1\nYou will rejoice\n"))
+}
+
+func TestBlocksNeedBothMarkers(t *testing.T) {
+	filters, err := New(DefaultPresets, nil, nil)
+	require.NoError(t, err)
+
+	// a marker in the middle of a book does not drop the rest of it
+	book := "Chapter 1\nEnd of Project Gutenberg's Frankenstein, he wrote\n" + strings.Repeat("and the story went on\n", maxBlockLines+1)
+	require.Equal(t, book, filters.Apply(book))
+
+	// the licence at the end of the book is dropped
+	require.Equal(t, "The end\n", filters.Apply("The end\nEnd of the Project Gutenberg EBook of Frankenstein\nThe licence\n"))
+
+	// a start marker far into the book is not the end of a header
+	book = strings.Repeat("a line\n", maxBlockLines+1) + "*** START OF THE PROJECT GUTENBERG EBOOK FRANKENSTEIN ***\nLetter 1\n"
+	require.Equal(t, book, filters.Apply(book))
+}
+
+func TestReaderStreams(t *testing.T) {
+	filters, err := New(DefaultPresets, nil, nil)
+	require.NoError(t, err)
+
+	input, writer := io.Pipe()
+	reader, err := filters.Reader(input)
+	require.NoError(t, err)
+	go func() {
+		_, _ = io.WriteString(writer, "*** START OF THE PROJECT GUTENBERG EBOOK FRANKENSTEIN ***\nLetter 1 [Illustration]\n")
+	}()
+
+	// the first line is read before the rest of the text is written
+	line := make([]byte, len("Letter 1 \n"))
+	_, err = io.ReadFull(reader, line)
+	require.NoError(t, err)
+	require.Equal(t, "Letter 1 \n", string(line))
+	writer.Close()
+}
+
+func TestPdfPreset(t *testing.T) {
+	filters, err := New([]string{PdfFurniture}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, "It was a dark\nand stormy night\nChapter IV\n",
+		filters.Apply("It was a dark\n12\n\fPage 3 of 40\nand stormy night\n- 13 -\nxiv\nChapter IV\n"))
+}
+
+func TestCustomRules(t *testing.T) {
+	filters, err := New(nil, []string{`^CHAPTER HEADER$`}, []string{`(\d+)°=>$1 degrees`, `\s*\(sic\)=>`})
+	require.NoError(t, err)
+	require.Equal(t, "It was 30 degrees\r\nthe wether was fine\r\n",
+		filters.Apply("CHAPTER HEADER\r\nIt was 30°\r\nthe wether (sic) was fine\r\n"))
+
+	reader, err := filters.Reader(strings.NewReader("CHAPTER HEADER\nkept"))
+	require.NoError(t, err)
+	text, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "kept", string(text))
+}
+
+func TestInvalidRules(t *testing.T) {
+	_, err := New([]string{"nope"}, nil, nil)
+	require.ErrorContains(t, err, "gutenberg")
+	_, err = New(nil, []string{"("}, nil)
+	require.Error(t, err)
+	_, err = New(nil, nil, []string{"no separator"})
+	require.Error(t, err)
+	_, err = New(nil, nil, []string{"(=>x"})
+	require.Error(t, err)
+}
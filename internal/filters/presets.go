@@ -0,0 +1,58 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package filters
+
+import (
+	"regexp"
+	"slices"
+)
+
+// The names of the built in presets
+const (
+	Gutenberg     = "gutenberg"
+	PdfFurniture  = "pdf"
+	Illustrations = "illustrations"
+)
+
+// The presets that are used when none are configured
+var DefaultPresets = []string{Gutenberg, Illustrations}
+
+// Built in sets of rules for common boilerplate
+var Presets = map[string]Filters{
+	// the licence header and footer around every Project Gutenberg book
+	Gutenberg: {
+		blocks: []block{
+			{end: regexp.MustCompile(`(?i)^\s*\*\*\*\s*START OF (THE|THIS) PROJECT GUTENBERG E-?BOOK`)},
+			{start: regexp.MustCompile(`(?i)^\s*\*\*\*\s*END OF (THE|THIS) PROJECT GUTENBERG E-?BOOK`)},
+			// older books only have a plain line before the licence
+			{start: regexp.MustCompile(`(?i)^\s*End of (the )?Project Gutenberg('s)? E-?Book`)},
+		},
+	},
+	// page numbers and page breaks left in text converted from a PDF
+	PdfFurniture: {
+		drops: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)^\s*(page\s+)?\d+(\s+of\s+\d+)?\s*$`),
+			regexp.MustCompile(`(?i)^\s*-\s*\d+\s*-\s*$`),
+			// front matter is numbered with lowercase roman numerals
+			regexp.MustCompile(`^\s*[ivxlc]+\s*$`),
+		},
+		replacements: []replacement{{find: regexp.MustCompile(`\f`), with: "\n"}},
+	},
+	// markers for images that were in the book, i.e. "[Illustration: A map]"
+	Illustrations: {
+		replacements: []replacement{
+			{find: regexp.MustCompile(`(?i)\[(illustration|image|figure|picture)[^\]]*\]`), with: ""},
+		},
+	},
+}
+
+// The names of all the presets in alphabetical order
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
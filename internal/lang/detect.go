@@ -0,0 +1,165 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lang
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Very common words for languages written in the Latin alphabet. These are
+// enough to tell apart languages in a paragraph of text without needing a
+// statistical model
+var stopwords = map[string][]string{
+	"ca": {"el", "la", "els", "les", "de", "que", "i", "amb", "per", "una", "és", "al", "del", "als", "això", "però"},
+	"cs": {"a", "je", "se", "na", "to", "že", "ve", "jsem", "jako", "ale", "by", "tak", "jsou", "byl", "není", "který"},
+	"da": {"og", "at", "det", "er", "en", "til", "på", "med", "jeg", "ikke", "af", "for", "den", "har", "som", "var"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "ich", "zu", "den", "mit", "sich", "des", "auf", "ein", "eine", "auch"},
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "was", "for", "with", "he", "she", "you", "this", "are"},
+	"es": {"el", "la", "de", "que", "y", "los", "las", "en", "por", "con", "una", "es", "para", "del", "se", "pero"},
+	"fi": {"ja", "on", "ei", "että", "se", "hän", "oli", "mutta", "kun", "niin", "kuin", "ole", "myös", "tämä", "joka", "mitä"},
+	"fr": {"le", "la", "les", "et", "est", "des", "que", "une", "du", "dans", "pas", "pour", "qui", "sur", "avec", "il", "je", "ne", "au", "mais", "ce", "elle", "nous", "vous"},
+	"hu": {"a", "az", "és", "hogy", "nem", "is", "egy", "van", "meg", "már", "csak", "de", "ez", "volt", "mint", "még"},
+	"it": {"il", "di", "che", "e", "la", "per", "non", "un", "una", "sono", "con", "del", "della", "gli", "le", "anche"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "ik", "te", "zijn", "op", "met", "voor", "maar", "ook"},
+	"no": {"og", "det", "er", "at", "ikke", "en", "til", "på", "jeg", "med", "som", "har", "av", "for", "var", "hun"},
+	"pl": {"i", "w", "nie", "się", "na", "to", "że", "z", "jest", "do", "jak", "ale", "co", "tak", "od", "jego"},
+	"pt": {"o", "a", "de", "que", "e", "do", "da", "em", "um", "não", "uma", "os", "para", "com", "se", "mais"},
+	"ro": {"și", "în", "de", "la", "nu", "să", "cu", "o", "un", "este", "că", "pe", "din", "mai", "sunt", "care"},
+	"sv": {"och", "att", "det", "är", "som", "en", "på", "jag", "med", "inte", "för", "har", "av", "till", "den", "var"},
+	"tr": {"ve", "bir", "bu", "da", "de", "için", "ne", "çok", "ile", "gibi", "daha", "ama", "değil", "olan", "kadar", "var"},
+	"vi": {"và", "của", "là", "không", "có", "những", "một", "được", "người", "trong", "cho", "này", "với", "các", "đã", "anh"},
+}
+
+var stopwordSets = func() map[string]map[string]bool {
+	sets := make(map[string]map[string]bool, len(stopwords))
+	for language, words := range stopwords {
+		sets[language] = make(map[string]bool, len(words))
+		for _, word := range words {
+			sets[language][word] = true
+		}
+	}
+	return sets
+}()
+
+// Detect the language of a piece of text and return its ISO 639-1 code
+// i.e. "en" or "zh". Returns an empty string if the language could not be determined.
+// The detection is a simple heuristic based on the script the text is written in
+// and, for the Latin alphabet, the frequency of very common words
+func Detect(text string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			counts["kana"]++
+		case unicode.Is(unicode.Han, r):
+			counts["han"]++
+		case unicode.Is(unicode.Hangul, r):
+			counts["ko"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			counts["cyrillic"]++
+		case unicode.Is(unicode.Greek, r):
+			counts["el"]++
+		case unicode.Is(unicode.Arabic, r):
+			counts["arabic"]++
+		case unicode.Is(unicode.Hebrew, r):
+			counts["he"]++
+		case unicode.Is(unicode.Thai, r):
+			counts["th"]++
+		case unicode.Is(unicode.Devanagari, r):
+			counts["hi"]++
+		case unicode.Is(unicode.Georgian, r):
+			counts["ka"]++
+		case unicode.Is(unicode.Latin, r):
+			counts["latin"]++
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+
+	script, best := "", 0
+	for name, count := range counts {
+		if count > best {
+			script, best = name, count
+		}
+	}
+	// ignore stray characters in an otherwise unrecognized script
+	if best*2 < letters {
+		return ""
+	}
+
+	switch script {
+	case "han", "kana":
+		// Japanese mixes kanji with kana while Chinese never uses kana
+		if counts["kana"]*10 > counts["han"] {
+			return "ja"
+		}
+		return "zh"
+	case "cyrillic":
+		return detectCyrillic(text)
+	case "arabic":
+		// letters used in Persian but not Arabic
+		if strings.ContainsAny(text, "پچژگ") {
+			return "fa"
+		}
+		return "ar"
+	case "latin":
+		return detectLatin(text)
+	default:
+		return script
+	}
+}
+
+// Tell apart languages written in Cyrillic by their distinctive letters
+func detectCyrillic(text string) string {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.ContainsAny(lower, "їєґі"):
+		return "uk"
+	case strings.ContainsAny(lower, "ћђџ"):
+		return "sr"
+	case strings.ContainsAny(lower, "әғқңөұүһі"):
+		return "kk"
+	default:
+		return "ru"
+	}
+}
+
+// Tell apart languages written in the Latin alphabet by counting common words
+func detectLatin(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	scores := map[string]int{}
+	for _, word := range words {
+		for language, set := range stopwordSets {
+			if set[word] {
+				scores[language]++
+			}
+		}
+	}
+
+	language, best, tied := "", 0, false
+	for candidate, score := range scores {
+		if score > best {
+			language, best, tied = candidate, score, false
+		} else if score == best {
+			tied = true
+		}
+	}
+
+	// require a clear winner so that a few shared words like "a" or "de"
+	// in a short piece of text don't produce a confident wrong answer
+	if best < 2 || tied {
+		return ""
+	}
+	return language
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	for _, test := range []struct {
+		expected string
+		text     string
+	}{
+		{"en", "It was the best of times, it was the worst of times, it was the age of wisdom."},
+		{"de", "Als Gregor Samsa eines Morgens aus unruhigen Träumen erwachte, fand er sich in seinem Bett zu einem ungeheueren Ungeziefer verwandelt. Er lag auf seinem panzerartig harten Rücken und sah, wenn er den Kopf ein wenig hob, seinen gewölbten Bauch."},
+		{"fr", "Longtemps, je me suis couché de bonne heure. Parfois, à peine ma bougie éteinte, mes yeux se fermaient si vite que je n'avais pas le temps de me dire: je m'endors."},
+		{"es", "En un lugar de la Mancha, de cuyo nombre no quiero acordarme, no ha mucho tiempo que vivía un hidalgo de los de lanza en astillero."},
+		{"pl", "Maszyna Turinga to abstrakcyjny model komputera, który jest używany w teorii obliczeń. Nie jest to urządzenie fizyczne, ale to model, na którym się opiera informatyka."},
+		{"zh", "标题 1 这是第一章的内容。我们在这里测试中文的语言检测。"},
+		{"ja", "吾輩は猫である。名前はまだ無い。どこで生れたかとんと見当がつかぬ。"},
+		{"ru", "Все счастливые семьи похожи друг на друга, каждая несчастливая семья несчастлива по-своему."},
+		{"uk", "Усі щасливі родини схожі між собою, кожна нещаслива родина нещаслива по-своєму. Її історія."},
+		{"el", "Άνδρα μοι έννεπε, Μούσα, πολύτροπον"},
+		{"", "1234567890"},
+		{"", "OK"},
+	} {
+		t.Run(test.expected+" "+test.text, func(t *testing.T) {
+			require.Equal(t, test.expected, Detect(test.text))
+		})
+	}
+}
+
+func TestBase(t *testing.T) {
+	require.Equal(t, "en", Base("en-US"))
+	require.Equal(t, "en", Base("en_GB"))
+	require.Equal(t, "pl", Base(" PL "))
+	require.Equal(t, "no", Base("nb-NO"))
+	require.Equal(t, "", Base(""))
+}
+
+func TestCode(t *testing.T) {
+	require.Equal(t, "fr", Code("French"))
+	require.Equal(t, "en", Code("en-US"))
+	require.Equal(t, "", Code(""))
+}
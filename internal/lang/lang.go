@@ -0,0 +1,47 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lang
+
+import (
+	"strings"
+)
+
+// Languages whose tags are written differently in books than in piper voice names
+var aliases = map[string]string{
+	// piper uses "no" for Norwegian while books tend to use Bokmål or Nynorsk
+	"nb": "no",
+	"nn": "no",
+	// older ISO 639 codes that still show up in book metadata
+	"iw": "he",
+	"in": "id",
+}
+
+// Reduce a language tag like "en-US", "en_GB" or "PL" to its lowercase
+// primary language subtag i.e. "en" or "pl" so it can be compared
+func Base(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	if alias, ok := aliases[base]; ok {
+		return alias
+	}
+	return base
+}
+
+// The English names of languages as they are written in book metadata, i.e. "Language: French"
+var languageNames = map[string]string{
+	"catalan": "ca", "chinese": "zh", "czech": "cs", "danish": "da", "dutch": "nl",
+	"english": "en", "finnish": "fi", "french": "fr", "german": "de", "greek": "el",
+	"hungarian": "hu", "italian": "it", "norwegian": "no", "polish": "pl", "portuguese": "pt",
+	"romanian": "ro", "russian": "ru", "spanish": "es", "swedish": "sv", "turkish": "tr",
+	"ukrainian": "uk", "vietnamese": "vi",
+}
+
+// Return the primary language subtag for either a language tag like "en-GB"
+// or the English name of a language like "English"
+func Code(value string) string {
+	if code, ok := languageNames[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return code
+	}
+	return Base(value)
+}
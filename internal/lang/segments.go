@@ -0,0 +1,54 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lang
+
+import (
+	"regexp"
+	"strings"
+)
+
+// A run of consecutive paragraphs that are all in the same language
+type Segment struct {
+	// The language of the text, i.e. "fr"; empty if unknown
+	Language string
+	// The paragraphs of the segment separated by blank lines
+	Text string
+}
+
+var paragraphBreak = regexp.MustCompile(`\n[ \t]*\n`)
+
+// Split plain text into runs of paragraphs that are in the same language.
+// Paragraphs are separated by blank lines. Paragraphs too short to detect
+// keep the language of the paragraph before them, starting with defaultLanguage
+func SplitByLanguage(text, defaultLanguage string) []Segment {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var segments []Segment
+	current := Base(defaultLanguage)
+	for _, paragraph := range paragraphBreak.Split(text, -1) {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if detected := Detect(paragraph); detected != "" {
+			current = detected
+		}
+		segments = append(segments, Segment{Language: current, Text: paragraph})
+	}
+	return MergeSegments(segments)
+}
+
+// Merge consecutive segments that are in the same language so
+// that each voice reads as much text as possible at once
+func MergeSegments(segments []Segment) []Segment {
+	var merged []Segment
+	for _, segment := range segments {
+		if len(merged) > 0 && Base(merged[len(merged)-1].Language) == Base(segment.Language) {
+			merged[len(merged)-1].Text += "\n\n" + segment.Text
+			continue
+		}
+		merged = append(merged, segment)
+	}
+	return merged
+}
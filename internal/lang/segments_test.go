@@ -0,0 +1,36 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lang
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitByLanguage(t *testing.T) {
+	const text = `Lesson one. In this lesson you will learn how to introduce yourself and ask how someone is doing.
+
+Bonjour, je m'appelle Marie. Je suis très contente de vous rencontrer, et vous ?
+
+Ça va ?
+
+Now it is your turn. Say that you are happy to meet them and ask how they are.
+
+Bye.`
+
+	require.Equal(t, []Segment{
+		{Language: "en", Text: "Lesson one. In this lesson you will learn how to introduce yourself and ask how someone is doing."},
+		{Language: "fr", Text: "Bonjour, je m'appelle Marie. Je suis très contente de vous rencontrer, et vous ?\n\nÇa va ?"},
+		{Language: "en", Text: "Now it is your turn. Say that you are happy to meet them and ask how they are.\n\nBye."},
+	}, SplitByLanguage(text, "en"))
+}
+
+func TestMergeSegments(t *testing.T) {
+	require.Equal(t,
+		[]Segment{{Language: "en-GB", Text: "a\n\nb"}, {Language: "fr", Text: "c"}},
+		MergeSegments([]Segment{{Language: "en-GB", Text: "a"}, {Language: "en", Text: "b"}, {Language: "fr", Text: "c"}}),
+	)
+	require.Empty(t, MergeSegments(nil))
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package lexicon respells words that piper mispronounces, such as character
+// names or jargon, using pronunciation dictionaries written by the user
+package lexicon
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A single entry in a lexicon file. Exactly one of Word or Regex must be set
+type Entry struct {
+	// A word or phrase that is matched as a whole word, i.e. "Hermione"
+	Word string `yaml:"word"`
+	// A regular expression to match instead of a word; Say can refer to its groups with $1
+	Regex string `yaml:"regex"`
+	// What piper should read instead, i.e. "her my oh nee"
+	Say string `yaml:"say"`
+	// Only match words with the same case. Words are matched regardless of case by default
+	CaseSensitive bool `yaml:"case-sensitive"`
+}
+
+type compiledEntry struct {
+	pattern *regexp.Regexp
+	say     string
+	// words need their boundaries checked
+	isWord bool
+	// whether to carry the case of the matched word over to the respelling
+	matchCase bool
+}
+
+// A set of respellings, with earlier entries taking priority over later ones
+type Lexicon struct {
+	entries []compiledEntry
+}
+
+// Create a lexicon from entries. Earlier entries take priority over later ones
+func New(entries []Entry) (Lexicon, error) {
+	var lexicon Lexicon
+	for i, entry := range entries {
+		switch {
+		case entry.Word != "" && entry.Regex != "":
+			return Lexicon{}, fmt.Errorf("lexicon entry %d has both a word and a regex", i+1)
+		case entry.Word != "":
+			expression := regexp.QuoteMeta(entry.Word)
+			if !entry.CaseSensitive {
+				expression = "(?i)" + expression
+			}
+			lexicon.entries = append(lexicon.entries, compiledEntry{
+				pattern: regexp.MustCompile(expression), say: entry.Say, isWord: true, matchCase: !entry.CaseSensitive,
+			})
+		case entry.Regex != "":
+			pattern, err := regexp.Compile(entry.Regex)
+			if err != nil {
+				return Lexicon{}, fmt.Errorf("lexicon entry %d has an invalid regex: %v", i+1, err)
+			}
+			lexicon.entries = append(lexicon.entries, compiledEntry{pattern: pattern, say: entry.Say})
+		default:
+			return Lexicon{}, fmt.Errorf("lexicon entry %d needs a word or a regex", i+1)
+		}
+	}
+	return lexicon, nil
+}
+
+// Load and combine lexicon files. Files that do not exist are skipped so optional
+// files like a book's sidecar can be passed unconditionally.
+// Entries in earlier files take priority over entries in later ones
+func Load(paths ...string) (Lexicon, error) {
+	var entries []Entry
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return Lexicon{}, err
+		}
+
+		var fileEntries []Entry
+		if err := yaml.Unmarshal(data, &fileEntries); err != nil {
+			return Lexicon{}, fmt.Errorf("failed to parse lexicon %s: %v", path, err)
+		}
+		if _, err := New(fileEntries); err != nil {
+			return Lexicon{}, fmt.Errorf("invalid lexicon %s: %v", path, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+	return New(entries)
+}
+
+// The lexicon that sits next to a book, i.e. "dune.lexicon.yaml" for "dune.epub"
+func SidecarPath(book string) string {
+	return strings.TrimSuffix(book, filepath.Ext(book)) + ".lexicon.yaml"
+}
+
+// The number of entries in the lexicon
+func (l Lexicon) Len() int {
+	return len(l.entries)
+}
+
+// Respell every match in a line of text in a single pass, so a respelling is never
+// respelled again by another entry. Where matches overlap the one that starts first wins,
+// and of matches that start at the same place the entry with priority wins
+func (l Lexicon) Apply(line string) string {
+	matches := make([][][]int, len(l.entries))
+	for i, entry := range l.entries {
+		matches[i] = entry.matches(line)
+	}
+
+	var result strings.Builder
+	position := 0
+	for {
+		best, bestMatch := -1, []int(nil)
+		for i := range matches {
+			// drop the matches that overlap text that was already respelled
+			for len(matches[i]) > 0 && matches[i][0][0] < position {
+				matches[i] = matches[i][1:]
+			}
+			if len(matches[i]) > 0 && (bestMatch == nil || matches[i][0][0] < bestMatch[0]) {
+				best, bestMatch = i, matches[i][0]
+			}
+		}
+		if bestMatch == nil {
+			break
+		}
+
+		entry := l.entries[best]
+		start, end := bestMatch[0], bestMatch[1]
+		result.WriteString(line[position:start])
+		switch {
+		case !entry.isWord:
+			result.Write(entry.pattern.ExpandString(nil, entry.say, line, bestMatch))
+		case entry.matchCase:
+			result.WriteString(matchCase(line[start:end], entry.say))
+		default:
+			result.WriteString(entry.say)
+		}
+		position = end
+	}
+	result.WriteString(line[position:])
+	return result.String()
+}
+
+// The non empty matches of an entry in a line; words that are part of a longer word are left out
+func (entry compiledEntry) matches(line string) [][]int {
+	var matches [][]int
+	for _, match := range entry.pattern.FindAllStringSubmatchIndex(line, -1) {
+		start, end := match[0], match[1]
+		if start == end {
+			continue
+		}
+		if entry.isWord {
+			before, _ := utf8.DecodeLastRuneInString(line[:start])
+			after, _ := utf8.DecodeRuneInString(line[end:])
+			if isWordRune(before) || isWordRune(after) {
+				continue
+			}
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// Respell text as it is read. The result must be closed if it isn't read to the end
+func (l Lexicon) Reader(input io.Reader) io.ReadCloser {
+	if l.Len() == 0 {
+		return io.NopCloser(input)
+	}
+	return lib.TransformLines(input, func(line string) (string, bool) {
+		return l.Apply(line), true
+	})
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+// Capitalize the respelling if the original was capitalized so that i.e.
+// a word at the start of a sentence still reads like the start of a sentence
+func matchCase(original, respelling string) string {
+	first, _ := utf8.DecodeRuneInString(original)
+	if respelling == "" || !unicode.IsUpper(first) {
+		return respelling
+	}
+	r, size := utf8.DecodeRuneInString(respelling)
+	return string(unicode.ToUpper(r)) + respelling[size:]
+}
@@ -0,0 +1,86 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lexicon
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyWords(t *testing.T) {
+	lexicon, err := New([]Entry{
+		{Word: "Hermione", Say: "her my oh nee"},
+		{Word: "Ōtani", Say: "oh tah nee"},
+		{Word: "US", Say: "you ess", CaseSensitive: true},
+		{Word: "New York", Say: "noo york"},
+	})
+	require.NoError(t, err)
+
+	for input, expected := range map[string]string{
+		"Hermione smiled.":             "Her my oh nee smiled.",
+		"said hermione":                "said her my oh nee",
+		"Hermiones":                    "Hermiones",
+		"Ōtani hit it, Ōtani ran":      "Oh tah nee hit it, Oh tah nee ran",
+		"the US and us":                "the you ess and us",
+		"In New York":                  "In Noo york",
+		"She (Hermione) left":          "She (Her my oh nee) left",
+		"Not part of Hermione_Granger": "Not part of Hermione_Granger",
+	} {
+		require.Equal(t, expected, lexicon.Apply(input), input)
+	}
+}
+
+func TestApplyRegex(t *testing.T) {
+	lexicon, err := New([]Entry{{Regex: `\bv(\d+)\.(\d+)\b`, Say: "version $1 point $2"}})
+	require.NoError(t, err)
+	require.Equal(t, "Upgrade to version 2 point 1 today", lexicon.Apply("Upgrade to v2.1 today"))
+}
+
+func TestRespellingsAreNotRespelled(t *testing.T) {
+	lexicon, err := New([]Entry{
+		{Word: "Siobhan", Say: "shiv awn"},
+		{Word: "awn", Say: "on"},
+		{Regex: `\bSt\b`, Say: "Saint"},
+		{Word: "Saint", Say: "saynt"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Shiv awn and the on, Saint Kevin and Saynt", lexicon.Apply("Siobhan and the awn, St Kevin and Saint"))
+}
+
+func TestInvalidEntries(t *testing.T) {
+	_, err := New([]Entry{{Say: "nothing"}})
+	require.Error(t, err)
+	_, err = New([]Entry{{Word: "a", Regex: "b", Say: "c"}})
+	require.Error(t, err)
+	_, err = New([]Entry{{Regex: "(", Say: "c"}})
+	require.Error(t, err)
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	book := filepath.Join(dir, "dune.epub")
+	global := filepath.Join(dir, "lexicon.yaml")
+
+	require.Equal(t, filepath.Join(dir, "dune.lexicon.yaml"), SidecarPath(book))
+	require.NoError(t, os.WriteFile(SidecarPath(book), []byte("- word: Atreides\n  say: uh tray deez\n"), 0644))
+	require.NoError(t, os.WriteFile(global, []byte("- word: Atreides\n  say: at ree deez\n- word: Harkonnen\n  say: har conn en\n"), 0644))
+
+	// the book's lexicon takes priority over the global one
+	lexicon, err := Load(SidecarPath(book), global, filepath.Join(dir, "missing.yaml"))
+	require.NoError(t, err)
+	require.Equal(t, 3, lexicon.Len())
+
+	output, err := io.ReadAll(lexicon.Reader(strings.NewReader("Atreides\nHarkonnen\n")))
+	require.NoError(t, err)
+	require.Equal(t, "Uh tray deez\nHar conn en\n", string(output))
+
+	require.NoError(t, os.WriteFile(global, []byte("- regex: '('\n  say: x\n"), 0644))
+	_, err = Load(global)
+	require.ErrorContains(t, err, global)
+}
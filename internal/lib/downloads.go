@@ -4,6 +4,7 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -22,6 +23,11 @@ func IsUrl(str string) bool {
 
 // Download a file from a URL and put it in the specified directory with the specified name
 func DownloadFile(url, outputName, outputDir string) (*os.File, error) {
+	return DownloadFileContext(context.Background(), url, outputName, outputDir)
+}
+
+// Download a file like DownloadFile, giving up when ctx is done
+func DownloadFileContext(ctx context.Context, url, outputName, outputDir string) (*os.File, error) {
 
 	if !strings.HasSuffix(outputDir, "/") {
 		outputDir += "/"
@@ -38,7 +44,11 @@ func DownloadFile(url, outputName, outputDir string) (*os.File, error) {
 	defer file.Close()
 
 	// Make a GET request to the URL
-	resp, err := http.Get(url)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error making GET request to %s: %v", url, err)
+	}
+	resp, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("error making GET request to %s: %v", url, err)
 	}
@@ -0,0 +1,45 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lib
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Apply a function to each line of text as it is read. The function gets the
+// line without its line ending and the line ending is added back afterwards.
+// Returning ok == false drops the line entirely.
+// The result must be closed if it isn't read to the end so the lines stop being read
+func TransformLines(input io.Reader, transform func(line string) (result string, ok bool)) io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	go func() {
+		buffered := bufio.NewReader(input)
+		for {
+			line, readErr := buffered.ReadString('\n')
+			if line != "" {
+				content := strings.TrimRight(line, "\r\n")
+				ending := line[len(content):]
+				if result, ok := transform(content); ok {
+					if _, err := io.WriteString(writer, result+ending); err != nil {
+						// the reader was closed so there is nobody left to write to
+						return
+					}
+				}
+			}
+			if readErr == io.EOF {
+				writer.Close()
+				return
+			}
+			if readErr != nil {
+				writer.CloseWithError(readErr)
+				return
+			}
+		}
+	}()
+
+	return reader
+}
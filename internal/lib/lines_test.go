@@ -0,0 +1,43 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package lib
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformLines(t *testing.T) {
+	input := strings.NewReader("keep this\r\ndrop this\nshout this")
+	output, err := io.ReadAll(TransformLines(input, func(line string) (string, bool) {
+		if strings.HasPrefix(line, "drop") {
+			return "", false
+		}
+		if strings.HasPrefix(line, "shout") {
+			return strings.ToUpper(line), true
+		}
+		return line, true
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "keep this\r\nSHOUT THIS", string(output))
+}
+
+func TestTransformLinesStopsWhenClosed(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	input := strings.NewReader(strings.Repeat("a line that is never read\n", 10000))
+	output := TransformLines(input, func(line string) (string, bool) { return line, true })
+	_, err := io.ReadFull(output, make([]byte, 10))
+	require.NoError(t, err)
+	require.NoError(t, output.Close())
+
+	// the goroutine reading the lines stops instead of waiting to write the rest forever
+	require.Eventually(t, func() bool {
+		return runtime.NumGoroutine() <= goroutines
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Metadata about the audiobooks in an output directory, captured when they are converted,
+// and the feeds that describe them to podcast and audiobook apps
+package library
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/charmbracelet/log"
+)
+
+// The directory inside an output directory that the metadata of its audiobooks is kept in
+const MetadataDir = ".library"
+
+// What is known about an audiobook in an output directory
+type Entry struct {
+	// The file name of the audiobook in the output directory
+	File        string `json:"file"`
+	Title       string `json:"title"`
+	Author      string `json:"author,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Description string `json:"description,omitempty"`
+	// The file name of the cover image in the metadata directory; empty if the book has none
+	Cover string `json:"cover,omitempty"`
+	// The media type of the audio, i.e. audio/mpeg
+	MediaType string `json:"media_type"`
+	// Length of the audio in milliseconds; 0 if it is not known
+	Duration int64     `json:"duration_ms"`
+	Size     int64     `json:"size"`
+	Chapters []Chapter `json:"chapters,omitempty"`
+	// The file name of the book the audiobook was made from
+	Source  string    `json:"source"`
+	Created time.Time `json:"created"`
+}
+
+// A chapter of an audiobook
+type Chapter struct {
+	Title string `json:"title"`
+	// Start of the chapter in milliseconds
+	Start int64 `json:"start_ms"`
+}
+
+// The media type of an audiobook from its extension
+func MediaType(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".mp3":
+		return "audio/mpeg"
+	case ".m4b", ".m4a":
+		return "audio/mp4"
+	case ".wav":
+		return "audio/wav"
+	}
+	return "application/octet-stream"
+}
+
+// The name of the podcast chapters file of an audiobook in the metadata directory
+func chaptersName(file string) string {
+	return file + ".chapters.json"
+}
+
+// The path of a file in the metadata directory, relative to the output directory
+func metadataPath(name string) string {
+	return MetadataDir + "/" + name
+}
+
+// Save the metadata of an audiobook in the output directory it was written to, along
+// with its cover if it has one. The cover is saved as entry.Cover
+func Save(outputDir string, entry Entry, cover io.Reader) error {
+	dir := filepath.Join(outputDir, MetadataDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	if cover != nil && entry.Cover != "" {
+		if err := writeFile(filepath.Join(dir, entry.Cover), cover); err != nil {
+			return fmt.Errorf("could not save the cover of %s: %v", entry.File, err)
+		}
+	} else {
+		entry.Cover = ""
+	}
+
+	chaptersFile := filepath.Join(dir, chaptersName(entry.File))
+	if len(entry.Chapters) > 0 {
+		chapters, err := podcastChapters(entry)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(chaptersFile, bytes.NewReader(chapters)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(chaptersFile); err != nil && !os.IsNotExist(err) {
+		// the chapters of an earlier audiobook with the same name
+		return err
+	}
+
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, entry.File+".json"), bytes.NewReader(data))
+}
+
+// Write a file through a temporary file so that it is never read half written
+func writeFile(path string, content io.Reader) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := io.Copy(temp, content); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(temp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// The files in an output directory that its audiobooks are published as, relative to it
+// with forward slashes: each audiobook along with its cover and chapters. These are the only
+// files a feed or catalog links to, so they are the only ones that should be served
+func Files(outputDir string) (map[string]bool, error) {
+	entries, err := Load(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	add := func(name string) {
+		// metadata that names a file outside the output directory is never trusted
+		if filepath.IsLocal(filepath.FromSlash(name)) {
+			files[name] = true
+		}
+	}
+	for _, entry := range entries {
+		add(entry.File)
+		if entry.Cover != "" {
+			add(metadataPath(entry.Cover))
+		}
+		if len(entry.Chapters) > 0 {
+			add(metadataPath(chaptersName(entry.File)))
+		}
+	}
+	return files, nil
+}
+
+// Load the metadata of the audiobooks in an output directory, newest first.
+// Audiobooks that were deleted since they were converted are left out
+func Load(outputDir string) ([]Entry, error) {
+	dir := filepath.Join(outputDir, MetadataDir)
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".chapters.json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			log.Warnf("Ignoring the metadata in %s which could not be read: %v", name, err)
+			continue
+		}
+		if entry.File != strings.TrimSuffix(name, ".json") {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(outputDir, entry.File)); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Created.After(entries[j].Created) })
+	return entries, nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package library
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Save an audiobook and its metadata in dir
+func saveBook(t *testing.T, dir string, entry Entry, cover string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, entry.File), []byte("audio"), 0644))
+	var coverReader io.Reader
+	if cover != "" {
+		coverReader = strings.NewReader(cover)
+	}
+	require.NoError(t, Save(dir, entry, coverReader))
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	saveBook(t, dir, Entry{File: "old.mp3", Title: "Old", Created: created}, "")
+	saveBook(t, dir, Entry{
+		File:     "Dubliners.mp3",
+		Title:    "Dubliners",
+		Cover:    "Dubliners.mp3.cover.jpg",
+		Chapters: []Chapter{{Title: "The Sisters", Start: 0}, {Title: "An Encounter", Start: 61500}},
+		Created:  created.Add(time.Hour),
+	}, "jpeg")
+	saveBook(t, dir, Entry{File: "deleted.mp3", Title: "Deleted", Created: created}, "")
+	require.NoError(t, os.Remove(filepath.Join(dir, "deleted.mp3")))
+
+	entries, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, "Dubliners", entries[0].Title)
+	require.Equal(t, "Old", entries[1].Title)
+
+	cover, err := os.ReadFile(filepath.Join(dir, MetadataDir, "Dubliners.mp3.cover.jpg"))
+	require.NoError(t, err)
+	require.Equal(t, "jpeg", string(cover))
+
+	data, err := os.ReadFile(filepath.Join(dir, MetadataDir, "Dubliners.mp3.chapters.json"))
+	require.NoError(t, err)
+	var chapters struct {
+		Version  string `json:"version"`
+		Chapters []struct {
+			StartTime float64 `json:"startTime"`
+			Title     string  `json:"title"`
+		} `json:"chapters"`
+	}
+	require.NoError(t, json.Unmarshal(data, &chapters))
+	require.Equal(t, "1.2.0", chapters.Version)
+	require.Len(t, chapters.Chapters, 2)
+	require.Equal(t, 61.5, chapters.Chapters[1].StartTime)
+	require.Equal(t, "An Encounter", chapters.Chapters[1].Title)
+
+	files, err := Files(dir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{
+		"old.mp3":                              true,
+		"Dubliners.mp3":                        true,
+		".library/Dubliners.mp3.cover.jpg":     true,
+		".library/Dubliners.mp3.chapters.json": true,
+	}, files)
+}
+
+func TestLoadMissingDirectory(t *testing.T) {
+	entries, err := Load(t.TempDir())
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestWriteFeed(t *testing.T) {
+	dir := t.TempDir()
+	saveBook(t, dir, Entry{
+		File:        "A Book.mp3",
+		Title:       "A Book",
+		Author:      "An Author",
+		Description: "About <b>things</b>",
+		Cover:       "A Book.mp3.cover.png",
+		MediaType:   "audio/mpeg",
+		Duration:    3723000,
+		Size:        5,
+		Chapters:    []Chapter{{Title: "One", Start: 0}},
+		Created:     time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}, "png")
+	require.NoError(t, WriteFeed(dir, "https://example.com/audiobooks/"))
+
+	data, err := os.ReadFile(filepath.Join(dir, FeedName))
+	require.NoError(t, err)
+	require.Contains(t, string(data), `xmlns:itunes="http://www.itunes.com/dtds/podcast-1.0.dtd"`)
+
+	var feed struct {
+		Channel struct {
+			Title string `xml:"title"`
+			Items []struct {
+				Title       string `xml:"title"`
+				Description string `xml:"description"`
+				PubDate     string `xml:"pubDate"`
+				Enclosure   struct {
+					URL    string `xml:"url,attr"`
+					Length int64  `xml:"length,attr"`
+					Type   string `xml:"type,attr"`
+				} `xml:"enclosure"`
+				Duration string `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd duration"`
+				Image    struct {
+					Href string `xml:"href,attr"`
+				} `xml:"http://www.itunes.com/dtds/podcast-1.0.dtd image"`
+				Chapters struct {
+					URL  string `xml:"url,attr"`
+					Type string `xml:"type,attr"`
+				} `xml:"https://podcastindex.org/namespace/1.0 chapters"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	require.Equal(t, filepath.Base(dir), feed.Channel.Title)
+	require.Len(t, feed.Channel.Items, 1)
+	item := feed.Channel.Items[0]
+	require.Equal(t, "A Book", item.Title)
+	require.Equal(t, "About <b>things</b>", item.Description)
+	require.Equal(t, "Sat, 01 Mar 2025 12:00:00 +0000", item.PubDate)
+	require.Equal(t, "https://example.com/audiobooks/A%20Book.mp3", item.Enclosure.URL)
+	require.Equal(t, int64(5), item.Enclosure.Length)
+	require.Equal(t, "audio/mpeg", item.Enclosure.Type)
+	require.Equal(t, "01:02:03", item.Duration)
+	require.Equal(t, "https://example.com/audiobooks/.library/A%20Book.mp3.cover.png", item.Image.Href)
+	require.Equal(t, "https://example.com/audiobooks/.library/A%20Book.mp3.chapters.json", item.Chapters.URL)
+	require.Equal(t, "application/json+chapters", item.Chapters.Type)
+}
+
+func TestWriteFeedNeedsURL(t *testing.T) {
+	dir := t.TempDir()
+	require.Error(t, WriteFeed(dir, ""))
+	require.Error(t, WriteFeed(dir, "audiobooks"))
+	require.NoFileExists(t, filepath.Join(dir, FeedName))
+	require.NoError(t, CheckFeedURL("http://localhost:8080"))
+}
+
+func TestURLForRelative(t *testing.T) {
+	require.Equal(t, "my%20book.mp3", URLFor("")("my book.mp3"))
+}
+
+func TestWriteCatalog(t *testing.T) {
+	dir := t.TempDir()
+	saveBook(t, dir, Entry{
+		File:      "A Book.mp3",
+		Title:     "A Book",
+		Author:    "An Author",
+		Language:  "fr",
+		Cover:     "A Book.mp3.cover.png",
+		MediaType: "audio/mpeg",
+		Duration:  3723000,
+		Size:      5,
+		Created:   time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}, "png")
+	require.NoError(t, WriteCatalog(dir, "https://example.com/audiobooks"))
+
+	data, err := os.ReadFile(filepath.Join(dir, CatalogName))
+	require.NoError(t, err)
+	type link struct {
+		Rel  string `xml:"rel,attr"`
+		Href string `xml:"href,attr"`
+		Type string `xml:"type,attr"`
+	}
+	var feed struct {
+		Title   string `xml:"http://www.w3.org/2005/Atom title"`
+		Updated string `xml:"http://www.w3.org/2005/Atom updated"`
+		Links   []link `xml:"http://www.w3.org/2005/Atom link"`
+		Entries []struct {
+			Title    string `xml:"http://www.w3.org/2005/Atom title"`
+			Author   string `xml:"http://www.w3.org/2005/Atom author>name"`
+			Language string `xml:"http://purl.org/dc/terms/ language"`
+			Extent   string `xml:"http://purl.org/dc/terms/ extent"`
+			Links    []link `xml:"http://www.w3.org/2005/Atom link"`
+		} `xml:"http://www.w3.org/2005/Atom entry"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &feed))
+	require.Equal(t, filepath.Base(dir), feed.Title)
+	require.Equal(t, "2025-03-01T12:00:00Z", feed.Updated)
+	require.Equal(t, link{Rel: "self", Href: "https://example.com/audiobooks/catalog.xml", Type: OPDS1Type}, feed.Links[0])
+	require.Len(t, feed.Entries, 1)
+	entry := feed.Entries[0]
+	require.Equal(t, "A Book", entry.Title)
+	require.Equal(t, "An Author", entry.Author)
+	require.Equal(t, "fr", entry.Language)
+	require.Equal(t, "PT3723S", entry.Extent)
+	require.Contains(t, entry.Links, link{Rel: "http://opds-spec.org/acquisition", Href: "https://example.com/audiobooks/A%20Book.mp3", Type: "audio/mpeg"})
+	require.Contains(t, entry.Links, link{Rel: "http://opds-spec.org/image", Href: "https://example.com/audiobooks/.library/A%20Book.mp3.cover.png", Type: "image/png"})
+
+	data, err = os.ReadFile(filepath.Join(dir, CatalogJSONName))
+	require.NoError(t, err)
+	var catalog struct {
+		Publications []struct {
+			Metadata struct {
+				Type     string  `json:"@type"`
+				Title    string  `json:"title"`
+				Author   string  `json:"author"`
+				Language string  `json:"language"`
+				Duration float64 `json:"duration"`
+			} `json:"metadata"`
+			Links []struct {
+				Rel  string `json:"rel"`
+				Href string `json:"href"`
+				Type string `json:"type"`
+			} `json:"links"`
+			Images []struct {
+				Href string `json:"href"`
+			} `json:"images"`
+		} `json:"publications"`
+	}
+	require.NoError(t, json.Unmarshal(data, &catalog))
+	require.Len(t, catalog.Publications, 1)
+	publication := catalog.Publications[0]
+	require.Equal(t, "http://schema.org/Audiobook", publication.Metadata.Type)
+	require.Equal(t, "A Book", publication.Metadata.Title)
+	require.Equal(t, "An Author", publication.Metadata.Author)
+	require.Equal(t, "fr", publication.Metadata.Language)
+	require.Equal(t, 3723.0, publication.Metadata.Duration)
+	require.Equal(t, "https://example.com/audiobooks/A%20Book.mp3", publication.Links[0].Href)
+	require.Equal(t, "audio/mpeg", publication.Links[0].Type)
+	require.Equal(t, "https://example.com/audiobooks/.library/A%20Book.mp3.cover.png", publication.Images[0].Href)
+}
@@ -0,0 +1,257 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package library
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"path/filepath"
+	"time"
+)
+
+// The names of the OPDS 1.2 and OPDS 2.0 catalogs written to an output directory
+const (
+	CatalogName     = "catalog.xml"
+	CatalogJSONName = "catalog.json"
+)
+
+// The media types of OPDS catalogs
+const (
+	OPDS1Type = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	OPDS2Type = "application/opds+json"
+)
+
+// The link relations OPDS uses for downloads and covers
+const (
+	acquisitionRel = "http://opds-spec.org/acquisition"
+	imageRel       = "http://opds-spec.org/image"
+	thumbnailRel   = "http://opds-spec.org/image/thumbnail"
+)
+
+// The media type of a cover image from its file name
+func imageType(cover string) string {
+	if mediaType := mime.TypeByExtension(filepath.Ext(cover)); mediaType != "" {
+		return mediaType
+	}
+	return "image/jpeg"
+}
+
+// A stable id for an audiobook; the same file converted again is a new publication
+func entryID(entry Entry) string {
+	return "urn:quickpiperaudiobook:" + entry.File + "@" + entry.Created.UTC().Format(time.RFC3339)
+}
+
+// The time the newest audiobook was converted, which is when the catalog last changed
+func updated(items []FeedItem) time.Time {
+	var newest time.Time
+	for _, item := range items {
+		if item.Created.After(newest) {
+			newest = item.Created
+		}
+	}
+	if newest.IsZero() {
+		return time.Unix(0, 0)
+	}
+	return newest
+}
+
+// Format a duration in milliseconds as an ISO 8601 duration, i.e. PT3723S
+func isoDuration(milliseconds int64) string {
+	return fmt.Sprintf("PT%dS", milliseconds/1000)
+}
+
+// The elements of an OPDS 1.2 acquisition feed, which is an Atom feed
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	DCTerms string      `xml:"xmlns:dcterms,attr"`
+	OPDS    string      `xml:"xmlns:opds,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  *atomAuthor `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel    string `xml:"rel,attr"`
+	Href   string `xml:"href,attr"`
+	Type   string `xml:"type,attr,omitempty"`
+	Length int64  `xml:"length,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Updated  string      `xml:"updated"`
+	Author   *atomAuthor `xml:"author"`
+	Language string      `xml:"dcterms:language,omitempty"`
+	Format   string      `xml:"dcterms:format"`
+	// the length of the audio
+	Extent  string     `xml:"dcterms:extent,omitempty"`
+	Issued  string     `xml:"dcterms:issued"`
+	Summary string     `xml:"summary,omitempty"`
+	Links   []atomLink `xml:"link"`
+}
+
+// Build an OPDS 1.2 acquisition feed of the audiobooks. selfURL is where the catalog is served from
+func OPDS1(title, selfURL string, items []FeedItem) ([]byte, error) {
+	feed := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		DCTerms: "http://purl.org/dc/terms/",
+		OPDS:    "http://opds-spec.org/2010/catalog",
+		ID:      "urn:quickpiperaudiobook:catalog:" + title,
+		Title:   title,
+		Updated: updated(items).UTC().Format(time.RFC3339),
+		Author:  &atomAuthor{Name: "QuickPiperAudiobook"},
+		Links: []atomLink{
+			{Rel: "self", Href: selfURL, Type: OPDS1Type},
+			{Rel: "start", Href: selfURL, Type: OPDS1Type},
+		},
+	}
+	for _, item := range items {
+		entry := atomEntry{
+			ID:       entryID(item.Entry),
+			Title:    item.Title,
+			Updated:  item.Created.UTC().Format(time.RFC3339),
+			Language: item.Language,
+			Format:   item.MediaType,
+			Issued:   item.Created.UTC().Format("2006-01-02"),
+			Summary:  item.Description,
+			Links:    []atomLink{{Rel: acquisitionRel, Href: item.AudioURL, Type: item.MediaType, Length: item.Size}},
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		if item.Duration > 0 {
+			entry.Extent = isoDuration(item.Duration)
+		}
+		if item.CoverURL != "" {
+			cover := imageType(item.Cover)
+			entry.Links = append(entry.Links,
+				atomLink{Rel: imageRel, Href: item.CoverURL, Type: cover},
+				atomLink{Rel: thumbnailRel, Href: item.CoverURL, Type: cover})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	var catalog bytes.Buffer
+	catalog.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&catalog)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return nil, err
+	}
+	catalog.WriteString("\n")
+	return catalog.Bytes(), nil
+}
+
+// The parts of an OPDS 2.0 feed that describe audiobooks
+type opds2Feed struct {
+	Metadata     opds2FeedMetadata  `json:"metadata"`
+	Links        []opds2Link        `json:"links"`
+	Publications []opds2Publication `json:"publications"`
+}
+
+type opds2FeedMetadata struct {
+	Title    string `json:"title"`
+	Modified string `json:"modified"`
+}
+
+type opds2Link struct {
+	Rel  string `json:"rel,omitempty"`
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+	// the length of linked audio in seconds
+	Duration float64 `json:"duration,omitempty"`
+}
+
+type opds2Publication struct {
+	Metadata opds2Metadata `json:"metadata"`
+	Links    []opds2Link   `json:"links"`
+	Images   []opds2Link   `json:"images,omitempty"`
+}
+
+type opds2Metadata struct {
+	Type        string  `json:"@type"`
+	Identifier  string  `json:"identifier"`
+	Title       string  `json:"title"`
+	Author      string  `json:"author,omitempty"`
+	Language    string  `json:"language,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Duration    float64 `json:"duration,omitempty"`
+	Published   string  `json:"published"`
+	Modified    string  `json:"modified"`
+}
+
+// Build an OPDS 2.0 feed of the audiobooks. selfURL is where the catalog is served from
+func OPDS2(title, selfURL string, items []FeedItem) ([]byte, error) {
+	feed := opds2Feed{
+		Metadata:     opds2FeedMetadata{Title: title, Modified: updated(items).UTC().Format(time.RFC3339)},
+		Links:        []opds2Link{{Rel: "self", Href: selfURL, Type: OPDS2Type}},
+		Publications: []opds2Publication{},
+	}
+	for _, item := range items {
+		duration := float64(item.Duration) / 1000
+		publication := opds2Publication{
+			Metadata: opds2Metadata{
+				Type:        "http://schema.org/Audiobook",
+				Identifier:  entryID(item.Entry),
+				Title:       item.Title,
+				Author:      item.Author,
+				Language:    item.Language,
+				Description: item.Description,
+				Duration:    duration,
+				Published:   item.Created.UTC().Format("2006-01-02"),
+				Modified:    item.Created.UTC().Format(time.RFC3339),
+			},
+			Links: []opds2Link{{Rel: acquisitionRel, Href: item.AudioURL, Type: item.MediaType, Duration: duration}},
+		}
+		if item.CoverURL != "" {
+			publication.Images = []opds2Link{{Href: item.CoverURL, Type: imageType(item.Cover)}}
+		}
+		feed.Publications = append(feed.Publications, publication)
+	}
+
+	catalog, err := json.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(catalog, '\n'), nil
+}
+
+// Write the OPDS 1.2 and 2.0 catalogs of the audiobooks in an output directory to catalog.xml
+// and catalog.json in it, with the files linked relative to baseURL, the url the directory is hosted at
+func WriteCatalog(outputDir, baseURL string) error {
+	feedMu.Lock()
+	defer feedMu.Unlock()
+
+	link := URLFor(baseURL)
+	items, err := Items(outputDir, link)
+	if err != nil {
+		return err
+	}
+	title := directoryTitle(outputDir)
+
+	catalog, err := OPDS1(title, link(CatalogName), items)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(outputDir, CatalogName), bytes.NewReader(catalog)); err != nil {
+		return err
+	}
+	catalog, err = OPDS2(title, link(CatalogJSONName), items)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(outputDir, CatalogJSONName), bytes.NewReader(catalog))
+}
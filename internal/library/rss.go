@@ -0,0 +1,238 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package library
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The name of the podcast feed written to an output directory
+const FeedName = "feed.xml"
+
+// An audiobook in a feed along with where its files can be downloaded from
+type FeedItem struct {
+	Entry
+	AudioURL string
+	// empty if the audiobook has no cover
+	CoverURL string
+	// empty if the audiobook has no chapters
+	ChaptersURL string
+}
+
+// Return the audiobooks in an output directory as feed items, newest first. link is given
+// the path of a file relative to the output directory and returns where it can be downloaded
+func Items(outputDir string, link func(path string) string) ([]FeedItem, error) {
+	entries, err := Load(outputDir)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]FeedItem, 0, len(entries))
+	for _, entry := range entries {
+		item := FeedItem{Entry: entry, AudioURL: link(entry.File)}
+		if entry.Cover != "" {
+			item.CoverURL = link(metadataPath(entry.Cover))
+		}
+		if len(entry.Chapters) > 0 {
+			item.ChaptersURL = link(metadataPath(chaptersName(entry.File)))
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// Return a function that makes the urls of files in a directory that is hosted at baseURL.
+// The urls are relative to the feed if baseURL is empty
+func URLFor(baseURL string) func(path string) string {
+	base := strings.TrimSuffix(baseURL, "/")
+	return func(path string) string {
+		parts := strings.Split(path, "/")
+		for i, part := range parts {
+			parts[i] = url.PathEscape(part)
+		}
+		if base == "" {
+			return strings.Join(parts, "/")
+		}
+		return base + "/" + strings.Join(parts, "/")
+	}
+}
+
+// The chapters of an audiobook in the JSON chapters format of the podcast namespace
+// https://github.com/Podcastindex-org/podcast-namespace/blob/main/docs/examples/chapters/jsonChapters.md
+func podcastChapters(entry Entry) ([]byte, error) {
+	type chapter struct {
+		StartTime float64 `json:"startTime"`
+		Title     string  `json:"title"`
+	}
+	chapters := struct {
+		Version  string    `json:"version"`
+		Chapters []chapter `json:"chapters"`
+	}{Version: "1.2.0", Chapters: []chapter{}}
+	for _, c := range entry.Chapters {
+		chapters.Chapters = append(chapters.Chapters, chapter{StartTime: float64(c.Start) / 1000, Title: c.Title})
+	}
+	return json.MarshalIndent(chapters, "", "  ")
+}
+
+// The elements of an RSS 2.0 feed with the itunes and podcast namespaces
+type rss struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	ITunesNS  string     `xml:"xmlns:itunes,attr"`
+	PodcastNS string     `xml:"xmlns:podcast,attr"`
+	Channel   rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string       `xml:"title"`
+	Link        string       `xml:"link,omitempty"`
+	Description string       `xml:"description"`
+	Generator   string       `xml:"generator"`
+	Author      string       `xml:"itunes:author"`
+	Explicit    string       `xml:"itunes:explicit"`
+	Image       *itunesImage `xml:"itunes:image"`
+	Items       []rssItem    `xml:"item"`
+}
+
+type itunesImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type rssItem struct {
+	Title       string               `xml:"title"`
+	Description string               `xml:"description,omitempty"`
+	Author      string               `xml:"itunes:author,omitempty"`
+	GUID        rssGUID              `xml:"guid"`
+	PubDate     string               `xml:"pubDate"`
+	Enclosure   rssEnclosure         `xml:"enclosure"`
+	Duration    string               `xml:"itunes:duration,omitempty"`
+	Image       *itunesImage         `xml:"itunes:image"`
+	Chapters    *podcastChaptersLink `xml:"podcast:chapters"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type podcastChaptersLink struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+// Format a duration in milliseconds as HH:MM:SS for itunes:duration
+func itunesDuration(milliseconds int64) string {
+	seconds := milliseconds / 1000
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// Build an RSS 2.0 podcast feed with one episode per audiobook that podcast apps can subscribe to
+func Feed(title, link string, items []FeedItem) ([]byte, error) {
+	channel := rssChannel{
+		Title:       title,
+		Link:        link,
+		Description: "Audiobooks converted with QuickPiperAudiobook",
+		Generator:   "QuickPiperAudiobook",
+		Author:      "QuickPiperAudiobook",
+		Explicit:    "false",
+	}
+	for _, item := range items {
+		episode := rssItem{
+			Title:       item.Title,
+			Description: item.Description,
+			Author:      item.Author,
+			// the same file converted again is a new episode
+			GUID:      rssGUID{Value: item.File + "@" + item.Created.UTC().Format(time.RFC3339)},
+			PubDate:   item.Created.Format(time.RFC1123Z),
+			Enclosure: rssEnclosure{URL: item.AudioURL, Length: item.Size, Type: item.MediaType},
+		}
+		if item.Duration > 0 {
+			episode.Duration = itunesDuration(item.Duration)
+		}
+		if item.CoverURL != "" {
+			episode.Image = &itunesImage{Href: item.CoverURL}
+			if channel.Image == nil {
+				// the podcast is shown with the cover of the newest audiobook
+				channel.Image = &itunesImage{Href: item.CoverURL}
+			}
+		}
+		if item.ChaptersURL != "" {
+			episode.Chapters = &podcastChaptersLink{URL: item.ChaptersURL, Type: "application/json+chapters"}
+		}
+		channel.Items = append(channel.Items, episode)
+	}
+
+	var feed bytes.Buffer
+	feed.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&feed)
+	encoder.Indent("", "  ")
+	err := encoder.Encode(rss{
+		Version:   "2.0",
+		ITunesNS:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		PodcastNS: "https://podcastindex.org/namespace/1.0",
+		Channel:   channel,
+	})
+	if err != nil {
+		return nil, err
+	}
+	feed.WriteString("\n")
+	return feed.Bytes(), nil
+}
+
+// The name of an output directory, which its feed and catalog are titled with
+func directoryTitle(outputDir string) string {
+	if abs, err := filepath.Abs(outputDir); err == nil {
+		return filepath.Base(abs)
+	}
+	return filepath.Base(outputDir)
+}
+
+// Make sure the url an output directory is hosted at is a full url since podcast apps
+// can only download the audiobooks in a feed from absolute links
+func CheckFeedURL(baseURL string) error {
+	if baseURL == "" {
+		return fmt.Errorf("a podcast feed needs --feed-url set to the url the output directory is hosted at, i.e. https://example.com/audiobooks")
+	}
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return fmt.Errorf("the feed url '%s' is not a full url like https://example.com/audiobooks", baseURL)
+	}
+	return nil
+}
+
+// Books in a batch finish at the same time so the feed and catalog are written by one of them at a time
+var feedMu sync.Mutex
+
+// Write the podcast feed of the audiobooks in an output directory to feed.xml in it,
+// with the files linked relative to baseURL, the url the directory is hosted at
+func WriteFeed(outputDir, baseURL string) error {
+	if err := CheckFeedURL(baseURL); err != nil {
+		return err
+	}
+	feedMu.Lock()
+	defer feedMu.Unlock()
+
+	items, err := Items(outputDir, URLFor(baseURL))
+	if err != nil {
+		return err
+	}
+	feed, err := Feed(directoryTitle(outputDir), baseURL, items)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(outputDir, FeedName), bytes.NewReader(feed))
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+
+	log "github.com/charmbracelet/log"
+)
+
+// Split the XHTML of an epub section into runs of text by the language
+// declared on each paragraph
+func epubSegments(xhtml io.Reader, bookLanguage string) ([]lang.Segment, error) {
+	epubSegments, err := epub.SplitByLanguage(xhtml, bookLanguage)
+	if err != nil {
+		return nil, err
+	}
+	segments := make([]lang.Segment, 0, len(epubSegments))
+	for _, segment := range epubSegments {
+		segments = append(segments, lang.Segment{Language: segment.Language, Text: segment.Text})
+	}
+	return lang.MergeSegments(segments), nil
+}
+
+// Synthesize text in several languages, reading each segment with the voice
+// for its language, and join the audio in order into outputName.
+// The audio of segments that were read before is reused from the cache.
+// The format of the output is chosen from the extension of outputName and
+// progress, if it isn't nil, is given how many of the segments were read
+func synthesizeSegments(voices *voiceSelector, segments []lang.Segment, bookLanguage, name, tempDir, outputName string, progress func(done, total int)) error {
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	config := voices.config
+
+	var parts []string
+	for i, segment := range segments {
+		model, speakUTF8 := voices.voiceFor(segment.Language, bookLanguage)
+		client, err := voices.clientForModel(model)
+		if err != nil {
+			return err
+		}
+
+		prepared, err := prepareText(strings.NewReader(segment.Text), config, segment.Language, model, speakUTF8)
+		if err != nil {
+			return err
+		}
+		// the whole text is hashed to find its audio in the cache
+		text, err := io.ReadAll(prepared)
+		prepared.Close()
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(string(text)) != "" {
+			part := filepath.Join(tempDir, fmt.Sprintf("%s-segment-%04d.mp3", base, i))
+			release := voices.acquire()
+			_, err = synthesizeCached(config.ctx(), config.audioCache(), client, 0, text, part)
+			release()
+			if err != nil {
+				return err
+			}
+			log.Debugf("Read segment %d of %s in '%s' with %s", i, name, segment.Language, model)
+			parts = append(parts, part)
+		}
+		if progress != nil {
+			progress(i+1, len(segments))
+		}
+	}
+
+	return ffmpeg.JoinAudio(parts, outputName)
+}
+
+// Whether the segments need more than one voice to be read
+func isMultilingual(segments []lang.Segment) bool {
+	return len(lang.MergeSegments(segments)) > 1
+}
+
+// Make a title from the start of a section's text. The goal is not to
+// have a perfect title but to have something that is reasonably identifiable
+func sectionTitle(text string) string {
+	// 20 is an arbitrary number of bytes to read to get the title
+	const maxTitleBytes = 20
+	if len(text) > maxTitleBytes {
+		cut := maxTitleBytes
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = text[:cut]
+	}
+	return strings.TrimSpace(text)
+}
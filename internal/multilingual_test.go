@@ -0,0 +1,57 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpubSegments(t *testing.T) {
+	const xhtml = `<html xml:lang="en-GB"><body>
+<p>Read this aloud.</p>
+<p xml:lang="en">It is short.</p>
+<p xml:lang="de">Guten Tag!</p>
+</body></html>`
+
+	segments, err := epubSegments(strings.NewReader(xhtml), "en")
+	require.NoError(t, err)
+	// regional variants of the same language are read by the same voice
+	require.Equal(t, []lang.Segment{
+		{Language: "en-GB", Text: "Read this aloud.\n\nIt is short."},
+		{Language: "de", Text: "Guten Tag!"},
+	}, segments)
+	require.True(t, isMultilingual(segments))
+	require.False(t, isMultilingual(segments[:1]))
+}
+
+func TestVoiceForSegments(t *testing.T) {
+	voices := newVoiceSelector(AudiobookArgs{Model: "en_US-lessac-medium.onnx", Multilingual: true})
+
+	// the book's language uses the explicitly chosen model
+	model, utf8 := voices.voiceFor("en", "en")
+	require.Equal(t, "en_US-lessac-medium.onnx", model)
+	require.False(t, utf8)
+
+	// passages in another language use the voice for that language
+	model, utf8 = voices.voiceFor("de", "en")
+	require.Equal(t, DefaultLanguageVoices["de"], model)
+	require.True(t, utf8)
+
+	// passages in a language without a voice are read by the book's voice
+	model, utf8 = voices.voiceFor("xx", "en")
+	require.Equal(t, "en_US-lessac-medium.onnx", model)
+	require.False(t, utf8)
+}
+
+func TestSectionTitle(t *testing.T) {
+	require.Equal(t, "Chapter 1", sectionTitle("  Chapter 1\n"))
+	require.Equal(t, "The Sisters There wa", sectionTitle("The Sisters There was no hope for him this time"))
+	// never cut a character in half
+	require.Equal(t, "标题 1 这是第", sectionTitle("标题 1 这是第一章的内容"))
+}
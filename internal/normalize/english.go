@@ -0,0 +1,413 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package normalize
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The rules for English text
+var englishRules = map[string]rule{
+	Abbreviations: englishAbbreviations,
+	Currency:      englishCurrency,
+	Times:         englishTimes,
+	Units:         englishUnits,
+	Ranges:        englishRanges,
+	Years:         englishYears,
+	Ordinals:      englishOrdinals,
+	Cardinals:     englishCardinals,
+}
+
+// Abbreviations that come before a name and so never end a sentence
+var titleAbbreviations = map[string]string{
+	"Mr": "Mister", "Mrs": "Missus", "Ms": "Miz", "Dr": "Doctor", "Prof": "Professor",
+	"Rev": "Reverend", "Capt": "Captain", "Lt": "Lieutenant", "Col": "Colonel",
+	"Gen": "General", "Sgt": "Sergeant", "Gov": "Governor", "Sen": "Senator",
+	"Rep": "Representative", "Hon": "Honorable", "Fr": "Father", "Mt": "Mount", "Ft": "Fort",
+}
+
+// Abbreviations that can also end a sentence
+var otherAbbreviations = map[string]string{
+	"etc": "et cetera", "vs": "versus", "approx": "approximately", "Jr": "Junior",
+	"Sr": "Senior", "Inc": "Incorporated", "Ltd": "Limited", "Co": "Company",
+	"Corp": "Corporation", "Ave": "Avenue", "Blvd": "Boulevard", "Rd": "Road",
+	"Dept": "Department", "Univ": "University",
+}
+
+var (
+	titleAbbreviationPattern = regexp.MustCompile(`\b(` + alternation(titleAbbreviations) + `)\.(\s)`)
+	otherAbbreviationPattern = regexp.MustCompile(`\b(` + alternation(otherAbbreviations) + `)\.(\s+[A-Z]|\s*$)?`)
+	saintPattern             = regexp.MustCompile(`\bSt\.`)
+	// a name or number right before "St." makes it a street, i.e. "Main St." or "5th St."
+	streetNamePattern  = regexp.MustCompile(`(?:^|[^\p{L}\d'-])([A-Z][\p{L}'-]*|\d+(?:st|nd|rd|th)?)\s+$`)
+	nextNamePattern    = regexp.MustCompile(`^\s+[A-Z]`)
+	numberAbbreviation = regexp.MustCompile(`\bNo\.(\s?\d)`)
+	latinAbbreviations = strings.NewReplacer("e.g.", "for example", "i.e.", "that is")
+)
+
+// Build a regex alternation from the keys of a map with the longest keys first
+// so that i.e. "Mrs" is matched before "Mr"
+func alternation(words map[string]string) string {
+	keys := make([]string, 0, len(words))
+	for key := range words {
+		keys = append(keys, regexp.QuoteMeta(key))
+	}
+	// sort by length, longest first, then alphabetically for a stable regex
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			if len(keys[j]) > len(keys[i]) || (len(keys[j]) == len(keys[i]) && keys[j] < keys[i]) {
+				keys[i], keys[j] = keys[j], keys[i]
+			}
+		}
+	}
+	return strings.Join(keys, "|")
+}
+
+// "Dr. Smith" -> "Doctor Smith", "St. Paul" -> "Saint Paul", "Main St." -> "Main Street"
+func englishAbbreviations(line string) string {
+	line = latinAbbreviations.Replace(line)
+
+	line = titleAbbreviationPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := titleAbbreviationPattern.FindStringSubmatch(match)
+		return titleAbbreviations[groups[1]] + groups[2]
+	})
+
+	line = expandSaint(line)
+
+	line = numberAbbreviation.ReplaceAllString(line, "number$1")
+
+	return otherAbbreviationPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := otherAbbreviationPattern.FindStringSubmatch(match)
+		// keep the period if the abbreviation also ended the sentence
+		if groups[2] != "" {
+			return otherAbbreviations[groups[1]] + "." + groups[2]
+		}
+		return otherAbbreviations[groups[1]]
+	})
+}
+
+// Read "St." as "Saint" before a name, i.e. "St. Paul", and as "Street" after one,
+// i.e. "Main St." even when the next sentence starts with a capital like "Main St. Then"
+func expandSaint(line string) string {
+	matches := saintPattern.FindAllStringIndex(line, -1)
+	if matches == nil {
+		return line
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, match := range matches {
+		result.WriteString(line[last:match[0]])
+		if nextNamePattern.MatchString(line[match[1]:]) && !afterStreetName(line[:match[0]]) {
+			result.WriteString("Saint")
+		} else {
+			result.WriteString("Street")
+		}
+		last = match[1]
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+// Capitalized words that start sentences before a saint's name, like "In St. Louis",
+// rather than being the name of a street
+var sentenceWords = map[string]bool{
+	"A": true, "An": true, "And": true, "As": true, "At": true, "But": true, "By": true, "For": true,
+	"From": true, "In": true, "Near": true, "Of": true, "On": true, "Or": true, "The": true,
+	"To": true, "Via": true, "Visit": true, "When": true, "Where": true, "While": true, "With": true,
+}
+
+// Whether the text before "St." ends with the name or number of a street
+func afterStreetName(before string) bool {
+	groups := streetNamePattern.FindStringSubmatch(before)
+	return groups != nil && !sentenceWords[groups[1]]
+}
+
+type currencyName struct {
+	singular, plural, subunitSingular, subunitPlural string
+}
+
+var currencies = map[string]currencyName{
+	"$": {"dollar", "dollars", "cent", "cents"},
+	"£": {"pound", "pounds", "penny", "pence"},
+	"€": {"euro", "euros", "cent", "cents"},
+	"¥": {"yen", "yen", "", ""},
+}
+
+var magnitudes = map[string]string{
+	"k": "thousand", "K": "thousand", "thousand": "thousand",
+	"m": "million", "M": "million", "mn": "million", "million": "million",
+	"b": "billion", "B": "billion", "bn": "billion", "billion": "billion",
+	"t": "trillion", "T": "trillion", "trillion": "trillion",
+}
+
+var currencyPattern = regexp.MustCompile(`([$£€¥])\s?(\d[\d,]*(?:\.\d+)?)(?:\s?(thousand|million|billion|trillion|mn|bn|[kKmMbBtT])\b)?`)
+
+// "$3.5M" -> "three point five million dollars", "$1.50" -> "one dollar and fifty cents"
+func englishCurrency(line string) string {
+	return currencyPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := currencyPattern.FindStringSubmatch(match)
+		name := currencies[groups[1]]
+		amount := strings.ReplaceAll(groups[2], ",", "")
+
+		if magnitude := magnitudes[groups[3]]; magnitude != "" {
+			return spellNumber(amount) + " " + magnitude + " " + name.plural
+		}
+
+		whole, fraction, _ := strings.Cut(amount, ".")
+		wholeValue, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil {
+			return match
+		}
+
+		unit := name.plural
+		if wholeValue == 1 {
+			unit = name.singular
+		}
+
+		if len(fraction) == 2 && name.subunitPlural != "" {
+			cents, _ := strconv.ParseInt(fraction, 10, 64)
+			subunit := name.subunitPlural
+			if cents == 1 {
+				subunit = name.subunitSingular
+			}
+			switch {
+			case cents == 0:
+				return cardinal(wholeValue) + " " + unit
+			case wholeValue == 0:
+				return cardinal(cents) + " " + subunit
+			default:
+				return cardinal(wholeValue) + " " + unit + " and " + cardinal(cents) + " " + subunit
+			}
+		}
+
+		return spellNumber(amount) + " " + unit
+	})
+}
+
+var (
+	// the period after "a.m." is kept when it also ends the sentence
+	clockPattern    = regexp.MustCompile(`\b([01]?\d|2[0-3]):([0-5]\d)(?:\s?([aApP])\.?[mM](?:(\.\s+[A-Z]|\.\s*$)|\.)?)?`)
+	meridiemPattern = regexp.MustCompile(`\b(1[0-2]|0?[1-9])\s?([aApP])\.?[mM](?:(\.\s+[A-Z]|\.\s*$)|\.|([^A-Za-z]|$))`)
+)
+
+// "3:30 pm" -> "three thirty P M", "14:05" -> "fourteen oh five", "9 a.m." -> "nine A M"
+func englishTimes(line string) string {
+	line = clockPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := clockPattern.FindStringSubmatch(match)
+		hour, _ := strconv.ParseInt(groups[1], 10, 64)
+		minute, _ := strconv.ParseInt(groups[2], 10, 64)
+
+		words := cardinal(hour)
+		switch {
+		case minute == 0 && groups[3] == "" && hour > 12:
+			words += " hundred"
+		case minute == 0 && groups[3] == "":
+			words += " o'clock"
+		case minute == 0:
+		case minute < 10:
+			words += " oh " + cardinal(minute)
+		default:
+			words += " " + cardinal(minute)
+		}
+		if groups[3] != "" {
+			words += " " + strings.ToUpper(groups[3]) + " M" + groups[4]
+		}
+		return words
+	})
+
+	return meridiemPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := meridiemPattern.FindStringSubmatch(match)
+		hour, _ := strconv.ParseInt(groups[1], 10, 64)
+		return cardinal(hour) + " " + strings.ToUpper(groups[2]) + " M" + groups[3] + groups[4]
+	})
+}
+
+type unitName struct {
+	singular, plural string
+}
+
+var units = map[string]unitName{
+	"km/h": {"kilometer per hour", "kilometers per hour"},
+	"km":   {"kilometer", "kilometers"},
+	"cm":   {"centimeter", "centimeters"},
+	"mm":   {"millimeter", "millimeters"},
+	"kg":   {"kilogram", "kilograms"},
+	"mg":   {"milligram", "milligrams"},
+	"ml":   {"milliliter", "milliliters"},
+	"mi":   {"mile", "miles"},
+	"ft":   {"foot", "feet"},
+	"lb":   {"pound", "pounds"},
+	"lbs":  {"pound", "pounds"},
+	"oz":   {"ounce", "ounces"},
+	"mph":  {"mile per hour", "miles per hour"},
+	"kph":  {"kilometer per hour", "kilometers per hour"},
+	"GHz":  {"gigahertz", "gigahertz"},
+	"MHz":  {"megahertz", "megahertz"},
+	"kHz":  {"kilohertz", "kilohertz"},
+	"Hz":   {"hertz", "hertz"},
+	"kW":   {"kilowatt", "kilowatts"},
+	"TB":   {"terabyte", "terabytes"},
+	"GB":   {"gigabyte", "gigabytes"},
+	"MB":   {"megabyte", "megabytes"},
+	"KB":   {"kilobyte", "kilobytes"},
+	"°C":   {"degree Celsius", "degrees Celsius"},
+	"°F":   {"degree Fahrenheit", "degrees Fahrenheit"},
+	"%":    {"percent", "percent"},
+}
+
+var unitPattern = regexp.MustCompile(`(\d[\d,]*(?:\.\d+)?)\s?(` + alternation(unitsAsWords()) + `)([^\p{L}\d]|$)`)
+
+func unitsAsWords() map[string]string {
+	words := make(map[string]string, len(units))
+	for symbol, name := range units {
+		words[symbol] = name.plural
+	}
+	return words
+}
+
+// "5 km" -> "5 kilometers", "1kg" -> "1 kilogram", "50%" -> "50 percent"
+func englishUnits(line string) string {
+	return unitPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := unitPattern.FindStringSubmatch(match)
+		name := units[groups[2]]
+		if groups[1] == "1" {
+			return groups[1] + " " + name.singular + groups[3]
+		}
+		return groups[1] + " " + name.plural + groups[3]
+	})
+}
+
+var rangePattern = regexp.MustCompile(`(\d+)\s?([–—-])\s?(\d+)`)
+
+// "1914–1918" -> "1914 to 1918". Numbers joined by plain hyphens are only treated
+// as a range if they are not part of a longer chain like a date or phone number
+func englishRanges(line string) string {
+	matches := rangePattern.FindAllStringSubmatchIndex(line, -1)
+	if matches == nil {
+		return line
+	}
+
+	var result strings.Builder
+	last := 0
+	for _, match := range matches {
+		start, end := match[0], match[1]
+		separator := line[match[4]:match[5]]
+		if separator == "-" {
+			chainedBefore := start > 0 && strings.ContainsAny(line[start-1:start], "-0123456789")
+			chainedAfter := end < len(line) && line[end] == '-'
+			if chainedBefore || chainedAfter {
+				continue
+			}
+		}
+		result.WriteString(line[last:start])
+		result.WriteString(line[match[2]:match[3]] + " to " + line[match[6]:match[7]])
+		last = end
+	}
+	result.WriteString(line[last:])
+	return result.String()
+}
+
+var (
+	decadePattern = regexp.MustCompile(`\b(1[1-9]\d0|20\d0)s\b`)
+	// years in the second millennium are common enough in books to always read as years
+	oldYearPattern = regexp.MustCompile(`(^|[^\d,.$£€¥])(1[1-9]\d\d)([^\d,]|[,.]\D|[,.]$|$)`)
+	// years in the 2000s can easily be quantities so they need a word hinting that they are a year
+	newYearPattern = regexp.MustCompile(`\b((?:[Ii]n|[Oo]f|[Ss]ince|[Bb]y|[Ff]rom|[Uu]ntil|[Tt]ill|[Tt]o|[Yy]ear|[Cc]irca|[Aa]round|[Bb]efore|[Aa]fter|[Dd]uring|AD|January|February|March|April|May|June|July|August|September|October|November|December|[Ss]pring|[Ss]ummer|[Aa]utumn|[Ff]all|[Ww]inter)\s+(?:\d{1,2},?\s+)?)(20\d\d)\b`)
+)
+
+// "1914" -> "nineteen fourteen", "in 2024" -> "in twenty twenty-four", "1960s" -> "nineteen sixties"
+func englishYears(line string) string {
+	line = decadePattern.ReplaceAllStringFunc(line, func(match string) string {
+		value, _ := strconv.ParseInt(strings.TrimSuffix(match, "s"), 10, 64)
+		words := year(value)
+		if strings.HasSuffix(words, "y") {
+			return strings.TrimSuffix(words, "y") + "ies"
+		}
+		return words + "s"
+	})
+
+	line = oldYearPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := oldYearPattern.FindStringSubmatch(match)
+		value, _ := strconv.ParseInt(groups[2], 10, 64)
+		return groups[1] + year(value) + groups[3]
+	})
+
+	return newYearPattern.ReplaceAllStringFunc(line, func(match string) string {
+		groups := newYearPattern.FindStringSubmatch(match)
+		value, _ := strconv.ParseInt(groups[2], 10, 64)
+		return groups[1] + year(value)
+	})
+}
+
+var ordinalPattern = regexp.MustCompile(`\b(\d+)(?:st|nd|rd|th)\b`)
+
+// "3rd" -> "third", "21st" -> "twenty-first"
+func englishOrdinals(line string) string {
+	return ordinalPattern.ReplaceAllStringFunc(line, func(match string) string {
+		value, err := strconv.ParseInt(ordinalPattern.FindStringSubmatch(match)[1], 10, 64)
+		if err != nil {
+			return match
+		}
+		return ordinal(value)
+	})
+}
+
+var (
+	// numbers joined by hyphens like phone numbers are read digit by digit
+	numberChainPattern = regexp.MustCompile(`(^|[^\p{L}\d])(\d+(?:-\d+)+)([^\p{L}\d]|$)`)
+	cardinalPattern    = regexp.MustCompile(`(^|[^\p{L}\d,.])(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?([^\p{L}\d]|$)`)
+)
+
+// "1,234" -> "one thousand two hundred thirty-four", "3.14" -> "three point one four"
+// Numbers that are part of a word like "MP3" are left alone
+func englishCardinals(line string) string {
+	line = replaceAllOverlapping(numberChainPattern, line, func(groups []string) string {
+		parts := strings.Split(groups[2], "-")
+		for i, part := range parts {
+			parts[i] = digits(part)
+		}
+		return groups[1] + strings.Join(parts, ", ") + groups[3]
+	})
+
+	return replaceAllOverlapping(cardinalPattern, line, func(groups []string) string {
+		return groups[1] + spellNumber(groups[2]+groups[3]) + groups[4]
+	})
+}
+
+// Like ReplaceAllStringFunc but the surrounding characters that a match consumes
+// to check its boundaries can also start the next match, i.e. "1 2 3".
+// The last group of pattern must be the trailing boundary, which replace keeps at the end of its result
+func replaceAllOverlapping(pattern *regexp.Regexp, line string, replace func(groups []string) string) string {
+	var result strings.Builder
+	position := 0
+	for position < len(line) {
+		groups := pattern.FindStringSubmatchIndex(line[position:])
+		if groups == nil {
+			break
+		}
+		submatches := make([]string, len(groups)/2)
+		for i := range submatches {
+			if groups[2*i] >= 0 {
+				submatches[i] = line[position+groups[2*i] : position+groups[2*i+1]]
+			}
+		}
+		trailing := submatches[len(submatches)-1]
+		replaced := replace(submatches)
+
+		result.WriteString(line[position : position+groups[0]])
+		result.WriteString(strings.TrimSuffix(replaced, trailing))
+		// the trailing boundary character may start the next match so scanning continues from it
+		next := position + groups[1] - len(trailing)
+		if next <= position {
+			// an empty match at the end of the line
+			break
+		}
+		position = next
+	}
+	result.WriteString(line[position:])
+	return result.String()
+}
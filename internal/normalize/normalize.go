@@ -0,0 +1,97 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package normalize rewrites numbers, dates, currency and abbreviations as
+// the words a reader would say so that piper does not have to guess
+package normalize
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+)
+
+// The names of the normalization rules. Any of them can be turned off
+const (
+	Abbreviations = "abbreviations"
+	Currency      = "currency"
+	Times         = "times"
+	Units         = "units"
+	Ranges        = "ranges"
+	Years         = "years"
+	Ordinals      = "ordinals"
+	Cardinals     = "cardinals"
+)
+
+// All rules in the order they are applied. The more specific rules run first
+// so that i.e. a year or a price is not read as a plain number
+var Rules = []string{Abbreviations, Currency, Times, Units, Ranges, Years, Ordinals, Cardinals}
+
+// A rule rewrites a single line of text
+type rule func(line string) string
+
+// The rules for each language keyed by base language code. Only English has rules so
+// normalization is effectively on only for English voices; text in other languages
+// is passed through unchanged rather than being read with English number words
+var languageRules = map[string]map[string]rule{
+	"en": englishRules,
+}
+
+// Rewrites text for a single language with a chosen set of rules
+type Normalizer struct {
+	rules []rule
+}
+
+// Create a normalizer for the given language that skips the named rules.
+// Returns an error if a rule name is not one of Rules
+func New(language string, skip []string) (Normalizer, error) {
+	for _, name := range skip {
+		if !slices.Contains(Rules, name) {
+			return Normalizer{}, fmt.Errorf("unknown normalization rule '%s'; expected one of %s", name, strings.Join(Rules, ", "))
+		}
+	}
+
+	available := languageRules[lang.Base(language)]
+	var rules []rule
+	for _, name := range Rules {
+		if r, ok := available[name]; ok && !slices.Contains(skip, name) {
+			rules = append(rules, r)
+		}
+	}
+	return Normalizer{rules: rules}, nil
+}
+
+// Whether the normalizer has any rules for its language
+func (n Normalizer) Enabled() bool {
+	return len(n.rules) > 0
+}
+
+// Normalize text line by line
+func (n Normalizer) Normalize(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = n.normalizeLine(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Normalize text as it is read. The result must be closed if it isn't read to the end
+func (n Normalizer) Reader(input io.Reader) io.ReadCloser {
+	if !n.Enabled() {
+		return io.NopCloser(input)
+	}
+	return lib.TransformLines(input, func(line string) (string, bool) {
+		return n.normalizeLine(line), true
+	})
+}
+
+func (n Normalizer) normalizeLine(line string) string {
+	for _, r := range n.rules {
+		line = r(line)
+	}
+	return line
+}
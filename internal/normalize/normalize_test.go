@@ -0,0 +1,105 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package normalize
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnglishRules(t *testing.T) {
+	normalizer, err := New("en-US", nil)
+	require.NoError(t, err)
+
+	for input, expected := range map[string]string{
+		// abbreviations
+		"Dr. Watson met Mrs. Hudson.":          "Doctor Watson met Missus Hudson.",
+		"He lived on Baker St. in London.":     "He lived on Baker Street in London.",
+		"St. Paul wrote letters.":              "Saint Paul wrote letters.",
+		"Turn onto Main St. Then go left.":     "Turn onto Main Street Then go left.",
+		"Main St. Then go left.":               "Main Street Then go left.",
+		"They met on 5th St. Monday.":          "They met on fifth Street Monday.",
+		"In St. Louis the letters of St. Paul": "In Saint Louis the letters of Saint Paul",
+		"Apples, pears, etc. are fruit.":       "Apples, pears, et cetera are fruit.",
+		"We bought apples, pears, etc. Then":   "We bought apples, pears, et cetera. Then",
+		"Fruit, e.g. apples":                   "Fruit, for example apples",
+		"He was No. 7":                         "He was number seven",
+		// currency
+		"It cost $3.5M to build.": "It cost three point five million dollars to build.",
+		"A coffee is $1.50.":      "A coffee is one dollar and fifty cents.",
+		"Only $0.99":              "Only ninety-nine cents",
+		"She paid £20 for it":     "She paid twenty pounds for it",
+		"€1 each":                 "one euro each",
+		// times
+		"We met at 3:30 pm.":   "We met at three thirty P M.",
+		"The train left 14:05": "The train left fourteen oh five",
+		"Wake me at 7:00":      "Wake me at seven o'clock",
+		"It starts at 9 a.m.":  "It starts at nine A M.",
+		"Leave at 6am, or 7":   "Leave at six A M, or seven",
+		// units
+		"It was 5 km away":    "It was five kilometers away",
+		"The bag weighs 1kg.": "The bag weighs one kilogram.",
+		"Sales rose 50%.":     "Sales rose fifty percent.",
+		"It was 30°C outside": "It was thirty degrees Celsius outside",
+		// ranges
+		"The war lasted 1914–1918.": "The war lasted nineteen fourteen to nineteen eighteen.",
+		"Read pages 12-15":          "Read pages twelve to fifteen",
+		"Call 555-123-4567":         "Call five five five, one two three, four five six seven",
+		// years
+		"In 1215 the charter was sealed": "In twelve fifteen the charter was sealed",
+		"Born in 1905.":                  "Born in nineteen oh five.",
+		"It happened in 2024.":           "It happened in twenty twenty-four.",
+		"There were 2024 people":         "There were two thousand twenty-four people",
+		"Music of the 1960s":             "Music of the nineteen sixties",
+		"On March 3, 2021 it snowed":     "On March three, twenty twenty-one it snowed",
+		// ordinals and cardinals
+		"The 21st century":   "The twenty-first century",
+		"He came 3rd":        "He came third",
+		"About 1,234 people": "About one thousand two hundred thirty-four people",
+		"Pi is 3.14":         "Pi is three point one four",
+		"1 2 3":              "one two three",
+		"Play the MP3 now":   "Play the MP3 now",
+		"Agent 007":          "Agent zero zero seven",
+	} {
+		require.Equal(t, expected, normalizer.Normalize(input), input)
+	}
+}
+
+func TestSkipRules(t *testing.T) {
+	normalizer, err := New("en", []string{Cardinals, Years})
+	require.NoError(t, err)
+	require.Equal(t, "In 1914 Doctor Smith was third of 40", normalizer.Normalize("In 1914 Dr. Smith was 3rd of 40"))
+
+	_, err = New("en", []string{"not-a-rule"})
+	require.Error(t, err)
+}
+
+func TestUnsupportedLanguagePassesThrough(t *testing.T) {
+	normalizer, err := New("pl", nil)
+	require.NoError(t, err)
+	require.False(t, normalizer.Enabled())
+
+	text := "W 1914 roku dr. Kowalski miał 3 koty"
+	require.Equal(t, text, normalizer.Normalize(text))
+}
+
+func TestReader(t *testing.T) {
+	normalizer, err := New("en", nil)
+	require.NoError(t, err)
+
+	output, err := io.ReadAll(normalizer.Reader(strings.NewReader("Chapter 1\r\nIt was 1888.\n")))
+	require.NoError(t, err)
+	require.Equal(t, "Chapter one\r\nIt was eighteen eighty-eight.\n", string(output))
+}
+
+func TestLongLine(t *testing.T) {
+	normalizer, err := New("en", nil)
+	require.NoError(t, err)
+
+	line := strings.Repeat("7 ", 20000)
+	require.Equal(t, strings.Repeat("seven ", 20000), normalizer.Normalize(line))
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package normalize
+
+import (
+	"strconv"
+	"strings"
+)
+
+var ones = []string{
+	"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+	"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen",
+}
+
+var tens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+
+var scales = []struct {
+	value int64
+	name  string
+}{
+	{1_000_000_000_000, "trillion"},
+	{1_000_000_000, "billion"},
+	{1_000_000, "million"},
+	{1_000, "thousand"},
+}
+
+// Spell out a whole number in English i.e. 1234 -> "one thousand two hundred thirty-four"
+func cardinal(n int64) string {
+	if n < 0 {
+		return "minus " + cardinal(-n)
+	}
+	if n < 20 {
+		return ones[n]
+	}
+	if n < 100 {
+		if n%10 == 0 {
+			return tens[n/10]
+		}
+		return tens[n/10] + "-" + ones[n%10]
+	}
+	if n < 1000 {
+		words := ones[n/100] + " hundred"
+		if n%100 != 0 {
+			words += " " + cardinal(n%100)
+		}
+		return words
+	}
+
+	var words []string
+	for _, scale := range scales {
+		if n >= scale.value {
+			words = append(words, cardinal(n/scale.value)+" "+scale.name)
+			n %= scale.value
+		}
+	}
+	if n > 0 {
+		words = append(words, cardinal(n))
+	}
+	return strings.Join(words, " ")
+}
+
+// Irregular ordinal endings
+var ordinalWords = map[string]string{
+	"one": "first", "two": "second", "three": "third", "five": "fifth",
+	"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+}
+
+// Spell out an ordinal number in English i.e. 21 -> "twenty-first"
+func ordinal(n int64) string {
+	words := cardinal(n)
+
+	// only the last word changes, i.e. "twenty-one" -> "twenty-first"
+	cut := strings.LastIndexAny(words, " -") + 1
+	last := words[cut:]
+	switch {
+	case ordinalWords[last] != "":
+		last = ordinalWords[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	return words[:cut] + last
+}
+
+// Spell out a year the way it is spoken i.e. 1914 -> "nineteen fourteen",
+// 1900 -> "nineteen hundred", 1905 -> "nineteen oh five" and 2005 -> "two thousand five"
+func year(n int64) string {
+	if n < 1000 || n > 9999 || (n >= 2000 && n < 2010) || n%1000 == 0 {
+		return cardinal(n)
+	}
+	century, rest := n/100, n%100
+	switch {
+	case rest == 0:
+		return cardinal(century) + " hundred"
+	case rest < 10:
+		return cardinal(century) + " oh " + ones[rest]
+	default:
+		return cardinal(century) + " " + cardinal(rest)
+	}
+}
+
+// Spell out a number that may contain thousands separators and a decimal part
+// i.e. "1,234.5" -> "one thousand two hundred thirty-four point five"
+// Numbers that are too large or have leading zeros are read digit by digit
+func spellNumber(number string) string {
+	whole, fraction, hasFraction := strings.Cut(strings.ReplaceAll(number, ",", ""), ".")
+
+	var words string
+	value, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || value >= 1_000_000_000_000_000 || (len(whole) > 1 && whole[0] == '0') {
+		words = digits(whole)
+	} else {
+		words = cardinal(value)
+	}
+
+	if hasFraction && fraction != "" {
+		words += " point " + digits(fraction)
+	}
+	return words
+}
+
+// Read a string of digits one at a time i.e. "007" -> "zero zero seven"
+func digits(number string) string {
+	words := make([]string, 0, len(number))
+	for _, d := range number {
+		if d >= '0' && d <= '9' {
+			words = append(words, ones[d-'0'])
+		}
+	}
+	return strings.Join(words, " ")
+}
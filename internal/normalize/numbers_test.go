@@ -0,0 +1,63 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package normalize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCardinal(t *testing.T) {
+	for number, expected := range map[int64]string{
+		0:             "zero",
+		13:            "thirteen",
+		40:            "forty",
+		42:            "forty-two",
+		100:           "one hundred",
+		101:           "one hundred one",
+		1234:          "one thousand two hundred thirty-four",
+		1_000_000:     "one million",
+		2_500_000_017: "two billion five hundred million seventeen",
+		-5:            "minus five",
+	} {
+		require.Equal(t, expected, cardinal(number))
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for number, expected := range map[int64]string{
+		1:   "first",
+		2:   "second",
+		3:   "third",
+		4:   "fourth",
+		12:  "twelfth",
+		20:  "twentieth",
+		21:  "twenty-first",
+		100: "one hundredth",
+		103: "one hundred third",
+	} {
+		require.Equal(t, expected, ordinal(number))
+	}
+}
+
+func TestYear(t *testing.T) {
+	for number, expected := range map[int64]string{
+		1066: "ten sixty-six",
+		1900: "nineteen hundred",
+		1905: "nineteen oh five",
+		1914: "nineteen fourteen",
+		2000: "two thousand",
+		2005: "two thousand five",
+		2024: "twenty twenty-four",
+	} {
+		require.Equal(t, expected, year(number))
+	}
+}
+
+func TestSpellNumber(t *testing.T) {
+	require.Equal(t, "one thousand two hundred thirty-four point five", spellNumber("1,234.5"))
+	require.Equal(t, "three point one four", spellNumber("3.14"))
+	require.Equal(t, "zero zero seven", spellNumber("007"))
+}
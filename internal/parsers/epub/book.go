@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"path"
+	"strings"
 )
 
 // A top level epub book
@@ -105,3 +106,25 @@ func (p *Book) openInternal(n string) (io.ReadCloser, error) {
 	}
 	return nil, fmt.Errorf("file %s does not exist", n)
 }
+
+// Return the manifest item of the cover image, which epub 3 marks with the cover-image
+// property and epub 2 names in a meta element called cover
+func (p *Book) CoverImage() (Manifest, error) {
+	coverID := ""
+	for _, meta := range p.Opf.Metadata.Meta {
+		if meta.Name == "cover" {
+			coverID = meta.Content
+		}
+	}
+	for _, manifestItem := range p.Opf.Manifest {
+		if hasProperty(manifestItem.Properties, "cover-image") {
+			return manifestItem, nil
+		}
+	}
+	for _, manifestItem := range p.Opf.Manifest {
+		if coverID != "" && manifestItem.ID == coverID && strings.HasPrefix(manifestItem.MediaType, "image/") {
+			return manifestItem, nil
+		}
+	}
+	return Manifest{}, fmt.Errorf("no cover image found in the epub")
+}
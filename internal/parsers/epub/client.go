@@ -28,21 +28,25 @@ func NewEpubSplitter(filepath string) (*EpubSplitter, error) {
 }
 
 func (p *EpubSplitter) GetCoverImage() (io.Reader, error) {
-	for _, manifestItem := range p.book.Opf.Manifest {
-		if manifestItem.Properties == "cover-image" {
-			file, err := p.book.OpenInternalBookFile(manifestItem.Href)
-			if err != nil {
-				return nil, err
-			}
-			return file, nil
-		}
+	cover, err := p.book.CoverImage()
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("no cover image found in the epub")
+	return p.book.OpenInternalBookFile(cover.Href)
 }
 
 type SectionData struct {
 	Filename string
 	Text     io.Reader
+	// The part of the book the section belongs to, i.e. the cover or the body
+	Kind SectionKind
+	// The position of the section in the spine, starting from 1
+	Index int
+	// The title of the section in the table of contents or empty if it has none
+	Title string
+	// Whether the section is part of the main reading order. Sections with
+	// linear="no" in the spine, like footnotes or answer keys, are not
+	Linear bool
 }
 
 // Split a book into individual io.Readers for each chapter
@@ -55,9 +59,16 @@ func (p *EpubSplitter) SplitBySection() ([]SectionData, error) {
 		idToFile[manifestItem.ID] = manifestItem.Href
 	}
 
+	hrefs := make([]string, len(spineItemsInOrder))
+	for i, item := range spineItemsInOrder {
+		hrefs[i] = idToFile[item.IDref]
+	}
+	kinds := p.book.classifySections(hrefs)
+	titles := p.book.sectionTitles()
+
 	var sections []SectionData
-	for _, item := range spineItemsInOrder {
-		filepath := idToFile[item.IDref]
+	for i, item := range spineItemsInOrder {
+		filepath := hrefs[i]
 		reader, err := p.book.OpenInternalBookFile(filepath)
 		if err != nil {
 			return nil, err
@@ -65,6 +76,10 @@ func (p *EpubSplitter) SplitBySection() ([]SectionData, error) {
 		sections = append(sections, SectionData{
 			Filename: filepath,
 			Text:     reader,
+			Index:    i + 1,
+			Title:    titles[filepath],
+			Kind:     kinds[i],
+			Linear:   item.Linear != "no",
 		})
 	}
 	return sections, nil
@@ -79,7 +94,7 @@ func (p *EpubSplitter) GetSectionNamesViaToc() ([]string, error) {
 
 	var sections []string
 	for _, navPoint := range p.book.Ncx.NavPoints {
-		sections = append(sections, navPoint.NavLabel.Content.Src)
+		sections = append(sections, navPoint.Content.Src)
 	}
 	if len(sections) == 0 {
 		return nil, fmt.Errorf("no sections found in the epub")
@@ -5,7 +5,6 @@ package epub
 
 import (
 	"path/filepath"
-	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -22,14 +21,10 @@ func TestParseEnglish(t *testing.T) {
 			require.NoError(t, err)
 			const sectionsInDublinersEbook = 18
 			require.Len(t, readers, sectionsInDublinersEbook)
+			// epub 3 marks the cover with a property and epub 2 with a meta element
 			cover, err := client.GetCoverImage()
-			if strings.HasSuffix(f, "epub3.epub") {
-				require.NoError(t, err)
-				require.NotNil(t, cover)
-			} else {
-				require.Error(t, err)
-				require.Nil(t, cover)
-			}
+			require.NoError(t, err)
+			require.NotNil(t, cover)
 
 		}
 	})
@@ -0,0 +1,129 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"strings"
+)
+
+/*
+EPUB3 books mark the structural parts of the book in the
+landmarks of their navigation document, which replaces the
+guide in the .opf file of EPUB2 books.
+
+Example (abbreviated):
+
+	<nav epub:type="landmarks">
+	  <ol>
+	    <li><a epub:type="cover" href="cover.xhtml">Cover</a></li>
+	    <li><a epub:type="bodymatter" href="chapter1.xhtml">Start of Content</a></li>
+	  </ol>
+	</nav>
+*/
+type Landmark struct {
+	// The epub:type of the landmark i.e. "bodymatter" or "copyright-page"
+	Type  string `json:"type"`
+	Title string `json:"title"`
+	// The file the landmark points to, relative to the .opf file like manifest hrefs
+	Href string `json:"href"`
+}
+
+// Read the landmarks from the navigation document of an EPUB3 book.
+// Books without a navigation document have no landmarks
+func (p *Book) Landmarks() ([]Landmark, error) {
+	return p.navLinks("landmarks")
+}
+
+// Read the links in the nav element of the given epub:type, i.e. "toc" or "landmarks",
+// from the navigation document. The type of each link is its own epub:type if it has one
+func (p *Book) navLinks(navType string) ([]Landmark, error) {
+	var navHref string
+	for _, item := range p.Opf.Manifest {
+		if hasProperty(item.Properties, "nav") {
+			navHref = item.Href
+			break
+		}
+	}
+	if navHref == "" {
+		return nil, nil
+	}
+
+	nav, err := p.OpenInternalBookFile(navHref)
+	if err != nil {
+		return nil, err
+	}
+	defer nav.Close()
+
+	decoder := xml.NewDecoder(nav)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var landmarks []Landmark
+	// how deeply nested we are inside the nav, 0 if outside of it
+	depth := 0
+	var current *Landmark
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if depth > 0 {
+				depth++
+			} else if t.Name.Local == "nav" && hasProperty(attr(t, "type"), navType) {
+				depth = 1
+			}
+			if depth > 0 && t.Name.Local == "a" {
+				current = &Landmark{
+					Type: attr(t, "type"),
+					// hrefs in the navigation document are relative to it and not to the .opf
+					Href: path.Join(path.Dir(navHref), attr(t, "href")),
+				}
+			}
+		case xml.EndElement:
+			if depth > 0 {
+				depth--
+			}
+			if t.Name.Local == "a" && current != nil {
+				current.Title = strings.TrimSpace(current.Title)
+				landmarks = append(landmarks, *current)
+				current = nil
+			}
+		case xml.CharData:
+			if current != nil {
+				current.Title += string(t)
+			}
+		}
+	}
+	return landmarks, nil
+}
+
+// Return the value of the attribute with the given local name, ignoring its namespace
+func attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Whether a space separated list of properties or epub:types contains the given one
+func hasProperty(properties, property string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
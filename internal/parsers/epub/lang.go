@@ -0,0 +1,170 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// A run of consecutive paragraphs in a section that are all in the same language
+type LanguageSegment struct {
+	// The language tag from the xml:lang or lang attribute, i.e. "fr" or "en-GB"
+	// Empty if the book did not declare a language for the text
+	Language string
+	// The plain text of the paragraphs in the segment
+	Text string
+}
+
+// Elements that start a new paragraph. The language of a paragraph is decided
+// by these so that a single foreign word inside a sentence doesn't switch voices
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "body": true,
+	"dd": true, "div": true, "dl": true, "dt": true, "figcaption": true, "figure": true,
+	"footer": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true,
+	"pre": true, "section": true, "table": true, "td": true, "th": true, "tr": true, "ul": true,
+}
+
+// Elements whose text is never read aloud
+var skippedElements = map[string]bool{
+	"head": true, "script": true, "style": true, "template": true,
+}
+
+// Split an XHTML document into segments by the language declared with
+// xml:lang or lang attributes. Consecutive paragraphs in the same language
+// are merged into one segment. Text without a declared language uses defaultLanguage
+func SplitByLanguage(xhtml io.Reader, defaultLanguage string) ([]LanguageSegment, error) {
+	decoder := xml.NewDecoder(xhtml)
+	// most epubs are valid XHTML but be lenient with the ones that aren't
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	type openElement struct {
+		name     string
+		language string
+		block    bool
+	}
+	stack := []openElement{{name: "", language: defaultLanguage, block: true}}
+	skipDepth := 0
+
+	var segments []LanguageSegment
+	var current strings.Builder
+	currentLanguage := defaultLanguage
+
+	flush := func() {
+		text := strings.TrimSpace(current.String())
+		current.Reset()
+		if text == "" {
+			return
+		}
+		if len(segments) > 0 && segments[len(segments)-1].Language == currentLanguage {
+			segments[len(segments)-1].Text += "\n\n" + text
+			return
+		}
+		segments = append(segments, LanguageSegment{Language: currentLanguage, Text: text})
+	}
+
+	// the language of the innermost paragraph level element
+	blockLanguage := func() string {
+		for i := len(stack) - 1; i >= 0; i-- {
+			if stack[i].block {
+				return stack[i].language
+			}
+		}
+		return defaultLanguage
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if skipDepth > 0 || skippedElements[name] {
+				skipDepth++
+				continue
+			}
+
+			language := stack[len(stack)-1].language
+			for _, attr := range t.Attr {
+				if attr.Name.Local == "lang" && strings.TrimSpace(attr.Value) != "" {
+					language = strings.TrimSpace(attr.Value)
+				}
+			}
+			block := blockElements[name]
+			stack = append(stack, openElement{name: name, language: language, block: block})
+
+			if name == "br" {
+				current.WriteString("\n")
+			}
+			if block {
+				current.WriteString("\n")
+				if language != currentLanguage {
+					flush()
+					currentLanguage = language
+				}
+			}
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			name := strings.ToLower(t.Name.Local)
+			// pop back to the matching element; tolerates unclosed tags
+			for i := len(stack) - 1; i > 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+			if blockElements[name] {
+				current.WriteString("\n")
+				if language := blockLanguage(); language != currentLanguage {
+					flush()
+					currentLanguage = language
+				}
+			}
+
+		case xml.CharData:
+			if skipDepth > 0 {
+				continue
+			}
+			// whitespace in XHTML is not significant so newlines inside
+			// a paragraph become spaces; they are collapsed when tidying
+			current.WriteString(strings.Map(func(r rune) rune {
+				if r == '\n' || r == '\r' || r == '\t' {
+					return ' '
+				}
+				return r
+			}, string(t)))
+		}
+	}
+	flush()
+
+	for i := range segments {
+		segments[i].Text = tidyParagraphs(segments[i].Text)
+	}
+	return segments, nil
+}
+
+// Collapse repeated spaces and runs of blank lines into a single paragraph break
+func tidyParagraphs(text string) string {
+	var paragraphs []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			paragraphs = append(paragraphs, line)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
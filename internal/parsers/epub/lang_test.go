@@ -0,0 +1,47 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitByLanguage(t *testing.T) {
+
+	t.Run("paragraphs switch language", func(t *testing.T) {
+		const xhtml = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xml:lang="en">
+<head><title>Lesson 1</title><style>p { color: red; }</style></head>
+<body>
+  <h1>Lesson 1</h1>
+  <p>Repeat after me.</p>
+  <p xml:lang="fr">Bonjour, je m'appelle <i>Marie</i>.</p>
+  <p lang="fr">Comment allez-vous&nbsp;?</p>
+  <p>This means <i lang="fr">how are you</i>.<br/>Try again.</p>
+</body>
+</html>`
+		segments, err := SplitByLanguage(strings.NewReader(xhtml), "")
+		require.NoError(t, err)
+		require.Equal(t, []LanguageSegment{
+			{Language: "en", Text: "Lesson 1\n\nRepeat after me."},
+			{Language: "fr", Text: "Bonjour, je m'appelle Marie.\n\nComment allez-vous ?"},
+			{Language: "en", Text: "This means how are you.\n\nTry again."},
+		}, segments)
+	})
+
+	t.Run("no declared language uses the default", func(t *testing.T) {
+		segments, err := SplitByLanguage(strings.NewReader(`<html><body><p>One</p><p>Two</p></body></html>`), "pl")
+		require.NoError(t, err)
+		require.Equal(t, []LanguageSegment{{Language: "pl", Text: "One\n\nTwo"}}, segments)
+	})
+
+	t.Run("empty document", func(t *testing.T) {
+		segments, err := SplitByLanguage(strings.NewReader(`<html><body><img src="cover.jpg"/></body></html>`), "")
+		require.NoError(t, err)
+		require.Empty(t, segments)
+	})
+}
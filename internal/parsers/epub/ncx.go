@@ -9,15 +9,16 @@ type Ncx struct {
 }
 
 type NavPoint struct {
-	NavLabel  NavLabel `xml:"navLabel" json:"navLabel"`
-	Id        string   `xml:"id,attr" json:"id"`
-	PlayOrder int      `xml:"playOrder,attr" json:"playOrder"`
+	NavLabel  NavLabel   `xml:"navLabel" json:"navLabel"`
+	Content   Content    `xml:"content" json:"content"`
+	Id        string     `xml:"id,attr" json:"id"`
+	PlayOrder int        `xml:"playOrder,attr" json:"playOrder"`
+	Children  []NavPoint `xml:"navPoint" json:"children"`
 }
 
 // NavPoint nav point
 type NavLabel struct {
-	Text    string  `xml:"navLabel>text" json:"text"`
-	Content Content `xml:"content" json:"content"`
+	Text string `xml:"text" json:"text"`
 }
 
 // Content nav-point content
@@ -43,9 +43,10 @@ Example (abbreviated):
 </package>
 */
 type Opf struct {
-	Metadata Metadata   `xml:"metadata" json:"metadata"`
-	Manifest []Manifest `xml:"manifest>item" json:"manifest"`
-	Spine    Spine      `xml:"spine" json:"spine"`
+	Metadata Metadata    `xml:"metadata" json:"metadata"`
+	Manifest []Manifest  `xml:"manifest>item" json:"manifest"`
+	Spine    Spine       `xml:"spine" json:"spine"`
+	Guide    []Reference `xml:"guide>reference" json:"guide"`
 }
 
 // The metadata section of the .opf file
@@ -123,3 +124,11 @@ type SpineItem struct {
 	ID         string `xml:"id,attr" json:"id"`
 	Properties string `xml:"properties,attr" json:"properties"`
 }
+
+// A reference in the EPUB2 guide that points to a structural part of the book
+// like the cover or the table of contents
+type Reference struct {
+	Type  string `xml:"type,attr" json:"type"`
+	Title string `xml:"title,attr" json:"title"`
+	Href  string `xml:"href,attr" json:"href"`
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"path"
+	"strings"
+)
+
+// The structural part of a book that a section belongs to
+type SectionKind string
+
+const (
+	Cover           SectionKind = "cover"
+	TableOfContents SectionKind = "toc"
+	Copyright       SectionKind = "copyright"
+	FrontMatter     SectionKind = "frontmatter"
+	BodyMatter      SectionKind = "bodymatter"
+	BackMatter      SectionKind = "backmatter"
+	Index           SectionKind = "index"
+)
+
+// All the kinds of sections in the order they usually appear in a book
+var SectionKinds = []SectionKind{Cover, TableOfContents, Copyright, FrontMatter, BodyMatter, BackMatter, Index}
+
+// The kinds of section for the types used in the EPUB2 guide and EPUB3 landmarks
+var structuralTypes = map[string]SectionKind{
+	"cover":            Cover,
+	"toc":              TableOfContents,
+	"copyright-page":   Copyright,
+	"copyright":        Copyright,
+	"index":            Index,
+	"bodymatter":       BodyMatter,
+	"text":             BodyMatter,
+	"frontmatter":      FrontMatter,
+	"titlepage":        FrontMatter,
+	"title-page":       FrontMatter,
+	"halftitlepage":    FrontMatter,
+	"dedication":       FrontMatter,
+	"epigraph":         FrontMatter,
+	"foreword":         FrontMatter,
+	"preface":          FrontMatter,
+	"acknowledgements": FrontMatter,
+	"acknowledgments":  FrontMatter,
+	"loi":              FrontMatter,
+	"lot":              FrontMatter,
+	"backmatter":       BackMatter,
+	"afterword":        BackMatter,
+	"appendix":         BackMatter,
+	"colophon":         BackMatter,
+	"glossary":         BackMatter,
+	"bibliography":     BackMatter,
+	"endnotes":         BackMatter,
+	"rearnotes":        BackMatter,
+	"notes":            BackMatter,
+	"other.also-by":    BackMatter,
+}
+
+// Work out which part of the book each of the spine files belongs to.
+//
+// Files the book labels in its guide or landmarks use that label. Files that are
+// not labeled are front matter until the body starts and back matter once a
+// section labeled as back matter or an index has come after the last of the body.
+// Books without any labels fall back to guessing from file names and are otherwise all body
+func (p *Book) classifySections(hrefs []string) []SectionKind {
+	labels := make(map[string]SectionKind)
+	label := func(href, structuralType string) {
+		href, _, _ = strings.Cut(href, "#")
+		if kind, ok := structuralTypes[strings.ToLower(structuralType)]; ok {
+			labels[href] = kind
+		}
+	}
+
+	for _, reference := range p.Opf.Guide {
+		label(reference.Href, reference.Type)
+	}
+	// landmarks replace the guide in EPUB3 so they take priority
+	if landmarks, err := p.Landmarks(); err == nil {
+		for _, landmark := range landmarks {
+			for _, structuralType := range strings.Fields(landmark.Type) {
+				label(landmark.Href, structuralType)
+			}
+		}
+	}
+	for _, item := range p.Opf.Manifest {
+		if _, labeled := labels[item.Href]; !labeled && hasProperty(item.Properties, "nav") {
+			labels[item.Href] = TableOfContents
+		}
+	}
+
+	bodyIsLabeled := false
+	for _, kind := range labels {
+		bodyIsLabeled = bodyIsLabeled || kind == BodyMatter
+	}
+
+	state := BodyMatter
+	if bodyIsLabeled {
+		state = FrontMatter
+	}
+	// sections labeled as back matter in the middle of the book, like the notes of a part,
+	// don't end the body if it carries on after them
+	lastBody := -1
+	for i, href := range hrefs {
+		if labels[href] == BodyMatter {
+			lastBody = i
+		}
+	}
+
+	kinds := make([]SectionKind, len(hrefs))
+	for i, href := range hrefs {
+		kind, labeled := labels[href]
+		guessed := false
+		if !labeled {
+			kind, guessed = guessFromFilename(href)
+		}
+		switch {
+		case guessed:
+			// a guess only describes its own file and doesn't end the body
+		case !labeled:
+			kind = state
+		case kind == BodyMatter:
+			state = BodyMatter
+		case (kind == BackMatter || kind == Index) && i > lastBody:
+			state = BackMatter
+		}
+		kinds[i] = kind
+	}
+	return kinds
+}
+
+// The words in file names that give away the kind of a section
+var filenameKinds = map[string]SectionKind{
+	"cover":     Cover,
+	"copyright": Copyright,
+	"toc":       TableOfContents,
+	"contents":  TableOfContents,
+}
+
+// Guess the kind of a section that the book didn't label from the words of its file name,
+// i.e. "cover.xhtml" or "ch00_copyright.html" but not "recovery.xhtml"
+func guessFromFilename(href string) (SectionKind, bool) {
+	name := strings.ToLower(strings.TrimSuffix(path.Base(href), path.Ext(href)))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return r == '-' || r == '_' || r == '.' || r == ' '
+	})
+	for _, word := range words {
+		if kind, ok := filenameKinds[word]; ok {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// Return the title of each file in the book from its table of contents, keyed by
+// href relative to the .opf file. Files with several entries use the first one
+func (p *Book) sectionTitles() map[string]string {
+	titles := make(map[string]string)
+	add := func(href, title string) {
+		href, _, _ = strings.Cut(href, "#")
+		if title = strings.Join(strings.Fields(title), " "); title != "" && titles[href] == "" {
+			titles[href] = title
+		}
+	}
+
+	var ncxHref string
+	for _, item := range p.Opf.Manifest {
+		if item.ID == p.Opf.Spine.Toc {
+			ncxHref = item.Href
+		}
+	}
+	var walk func(points []NavPoint)
+	walk = func(points []NavPoint) {
+		for _, point := range points {
+			// the sources in the ncx are relative to it and not to the .opf
+			add(path.Join(path.Dir(ncxHref), point.Content.Src), point.NavLabel.Text)
+			walk(point.Children)
+		}
+	}
+	walk(p.Ncx.NavPoints)
+
+	if links, err := p.navLinks("toc"); err == nil {
+		for _, link := range links {
+			add(link.Href, link.Title)
+		}
+	}
+	return titles
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Write a minimal epub with the given files inside OEBPS/ and return its path
+func writeTestEpub(t *testing.T, opf string, files map[string]string) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "test.epub")
+	out, err := os.Create(name)
+	require.NoError(t, err)
+	defer out.Close()
+
+	writer := zip.NewWriter(out)
+	contents := map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": opf,
+	}
+	for file, content := range files {
+		contents["OEBPS/"+file] = content
+	}
+	for file, content := range contents {
+		w, err := writer.Create(file)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return name
+}
+
+func page(text string) string {
+	return `<html xmlns="http://www.w3.org/1999/xhtml"><body><p>` + text + `</p></body></html>`
+}
+
+func TestClassifyWithLandmarks(t *testing.T) {
+	opf := `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:language>en</dc:language></metadata>
+  <manifest>
+    <item id="nav" href="text/nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="cover" href="text/titlepage.xhtml" media-type="application/xhtml+xml"/>
+    <item id="copyright" href="text/rights.xhtml" media-type="application/xhtml+xml"/>
+    <item id="preface" href="text/preface.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch1" href="text/ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="text/notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="also" href="text/also.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ads" href="text/ads.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="cover"/>
+    <itemref idref="nav"/>
+    <itemref idref="copyright"/>
+    <itemref idref="preface"/>
+    <itemref idref="ch1"/>
+    <itemref idref="notes" linear="no"/>
+    <itemref idref="ch2"/>
+    <itemref idref="also"/>
+    <itemref idref="ads"/>
+  </spine>
+</package>`
+	nav := `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="toc"><ol><li><a href="ch1.xhtml">Chapter 1</a></li></ol></nav>
+<nav epub:type="landmarks"><ol>
+  <li><a epub:type="cover" href="titlepage.xhtml">Cover</a></li>
+  <li><a epub:type="copyright-page" href="rights.xhtml">Copyright</a></li>
+  <li><a epub:type="bodymatter" href="ch1.xhtml#start">Start</a></li>
+  <li><a epub:type="other.also-by" href="also.xhtml">Also by the author</a></li>
+</ol></nav>
+</body></html>`
+
+	files := map[string]string{"text/nav.xhtml": nav}
+	for _, name := range []string{"titlepage", "rights", "preface", "ch1", "notes", "ch2", "also", "ads"} {
+		files["text/"+name+".xhtml"] = page(name)
+	}
+
+	splitter, err := NewEpubSplitter(writeTestEpub(t, opf, files))
+	require.NoError(t, err)
+	defer splitter.Close()
+
+	landmarks, err := splitter.book.Landmarks()
+	require.NoError(t, err)
+	require.Len(t, landmarks, 4)
+	require.Equal(t, Landmark{Type: "bodymatter", Title: "Start", Href: "text/ch1.xhtml#start"}, landmarks[2])
+
+	sections, err := splitter.SplitBySection()
+	require.NoError(t, err)
+
+	var kinds []SectionKind
+	var linear []bool
+	for _, section := range sections {
+		kinds = append(kinds, section.Kind)
+		linear = append(linear, section.Linear)
+	}
+	require.Equal(t, []SectionKind{Cover, TableOfContents, Copyright, FrontMatter, BodyMatter, BodyMatter, BodyMatter, BackMatter, BackMatter}, kinds)
+	require.Equal(t, []bool{true, true, true, true, true, false, true, true, true}, linear)
+}
+
+// Make sure sections labeled in the middle of the book don't turn the chapters after them into back matter
+func TestClassifyLabelsBetweenParts(t *testing.T) {
+	opf := `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:language>en</dc:language></metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="epigraph" href="epigraph.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="ch2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="notes" href="notes.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch3" href="ch3.xhtml" media-type="application/xhtml+xml"/>
+    <item id="part2" href="part2.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch4" href="ch4.xhtml" media-type="application/xhtml+xml"/>
+    <item id="appendix" href="appendix.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ads" href="ads.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="ch1"/>
+    <itemref idref="epigraph"/>
+    <itemref idref="ch2"/>
+    <itemref idref="notes"/>
+    <itemref idref="ch3"/>
+    <itemref idref="part2"/>
+    <itemref idref="ch4"/>
+    <itemref idref="appendix"/>
+    <itemref idref="ads"/>
+  </spine>
+</package>`
+	nav := `<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<nav epub:type="landmarks"><ol>
+  <li><a epub:type="bodymatter" href="ch1.xhtml">Start</a></li>
+  <li><a epub:type="epigraph" href="epigraph.xhtml">Epigraph</a></li>
+  <li><a epub:type="notes" href="notes.xhtml">Notes</a></li>
+  <li><a epub:type="bodymatter" href="part2.xhtml">Part Two</a></li>
+  <li><a epub:type="appendix" href="appendix.xhtml">Appendix</a></li>
+</ol></nav>
+</body></html>`
+
+	files := map[string]string{"nav.xhtml": nav}
+	for _, name := range []string{"ch1", "epigraph", "ch2", "notes", "ch3", "part2", "ch4", "appendix", "ads"} {
+		files[name+".xhtml"] = page(name)
+	}
+	splitter, err := NewEpubSplitter(writeTestEpub(t, opf, files))
+	require.NoError(t, err)
+	defer splitter.Close()
+
+	sections, err := splitter.SplitBySection()
+	require.NoError(t, err)
+	var kinds []SectionKind
+	for _, section := range sections {
+		kinds = append(kinds, section.Kind)
+	}
+	require.Equal(t, []SectionKind{
+		BodyMatter, FrontMatter, BodyMatter, BackMatter, BodyMatter, BodyMatter, BodyMatter, BackMatter, BackMatter,
+	}, kinds)
+}
+
+func TestGuessFromFilename(t *testing.T) {
+	for href, expected := range map[string]SectionKind{
+		"text/cover.xhtml":          Cover,
+		"ch00_copyright.html":       Copyright,
+		"toc.xhtml":                 TableOfContents,
+		"table-of-contents.xhtml":   TableOfContents,
+		"recovery.xhtml":            "",
+		"discovery.xhtml":           "",
+		"ch3-covered.xhtml":         "",
+		"discontents.xhtml":         "",
+		"chapter_1.copyrighted.htm": "",
+	} {
+		kind, ok := guessFromFilename(href)
+		require.Equal(t, expected, kind, href)
+		require.Equal(t, expected != "", ok, href)
+	}
+}
+
+func TestGuessesDoNotEndTheBody(t *testing.T) {
+	opf := `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="2.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:language>en</dc:language></metadata>
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="break" href="ch1-copyright.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="ch1"/><itemref idref="break"/><itemref idref="ch2"/></spine>
+  <guide><reference type="text" href="ch1.xhtml"/></guide>
+</package>`
+	splitter, err := NewEpubSplitter(writeTestEpub(t, opf, map[string]string{
+		"ch1.xhtml": page("one"), "ch1-copyright.xhtml": page("rights"), "ch2.xhtml": page("two"),
+	}))
+	require.NoError(t, err)
+	defer splitter.Close()
+
+	sections, err := splitter.SplitBySection()
+	require.NoError(t, err)
+	var kinds []SectionKind
+	for _, section := range sections {
+		kinds = append(kinds, section.Kind)
+	}
+	require.Equal(t, []SectionKind{BodyMatter, Copyright, BodyMatter}, kinds)
+}
+
+func TestClassifyWithGuide(t *testing.T) {
+	splitter, err := NewEpubSplitter(filepath.Join("testdata", "dubliners_epub2.epub"))
+	require.NoError(t, err)
+	defer splitter.Close()
+
+	sections, err := splitter.SplitBySection()
+	require.NoError(t, err)
+	require.Equal(t, Cover, sections[0].Kind)
+	require.Equal(t, TableOfContents, sections[1].Kind)
+	for _, section := range sections[2:] {
+		require.Equal(t, BodyMatter, section.Kind, section.Filename)
+		require.True(t, section.Linear)
+	}
+}
+
+func TestSectionTitles(t *testing.T) {
+	for _, f := range []string{"dubliners_epub2.epub", "dubliners_epub3.epub"} {
+		splitter, err := NewEpubSplitter(filepath.Join("testdata", f))
+		require.NoError(t, err)
+
+		sections, err := splitter.SplitBySection()
+		require.NoError(t, err)
+		var titles []string
+		for i, section := range sections {
+			require.Equal(t, i+1, section.Index)
+			titles = append(titles, section.Title)
+		}
+		require.Contains(t, titles, "THE SISTERS", f)
+		require.Contains(t, titles, "THE DEAD", f)
+		splitter.Close()
+	}
+}
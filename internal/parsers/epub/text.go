@@ -0,0 +1,279 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"encoding/xml"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Elements that are read as their own paragraph with a pause after them even
+// if the book didn't end them with punctuation
+var headingElements = map[string]bool{
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"caption": true, "dt": true, "figcaption": true,
+}
+
+// Elements whose text is left out of the spoken text on top of skippedElements
+var unreadElements = map[string]bool{
+	// the pronunciation guides above ruby text
+	"rp": true, "rt": true,
+	"svg": true, "math": true, "object": true, "iframe": true, "noscript": true,
+}
+
+// The epub:type and role values of footnote references and of footnotes that
+// reading systems show as popups instead of in the text
+var noteTypes = map[string]bool{
+	"noteref": true, "doc-noteref": true,
+	"footnote": true, "doc-footnote": true, "endnote": true, "rearnote": true,
+}
+
+// Convert an XHTML document from an epub to plain text to be read aloud.
+//
+// Each block element like a paragraph, heading or list item becomes its own
+// paragraph separated by a blank line, <br/> starts a new line, and headings
+// get a period if they don't end in punctuation so there is a pause after them.
+// Items of ordered lists are numbered. Footnote references, popup footnotes and
+// elements that are hidden or never shown like scripts and ruby annotations are left out
+func ExtractText(xhtml io.Reader) (string, error) {
+	paragraphs, err := ExtractParagraphs(xhtml)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, len(paragraphs))
+	for i, paragraph := range paragraphs {
+		texts[i] = paragraph.Text
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// A paragraph of text extracted from XHTML
+type Paragraph struct {
+	Text string
+	// 1 to 6 for the text of h1 to h6 elements and 0 for anything else
+	HeadingLevel int
+}
+
+// Convert an XHTML document to the paragraphs of text that are read aloud
+// in the same way as ExtractText, keeping which of them are headings
+func ExtractParagraphs(xhtml io.Reader) ([]Paragraph, error) {
+	decoder := xml.NewDecoder(xhtml)
+	// most epubs are valid XHTML but be lenient with the ones that aren't
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	type openElement struct {
+		name string
+		// the number of the next item if this is an ordered list
+		nextItem int
+		ordered  bool
+	}
+	var stack []openElement
+	skipDepth := 0
+
+	var paragraphs []Paragraph
+	var current strings.Builder
+	heading := false
+	headingLevel := 0
+	// where the text of the open link to somewhere in a book starts, -1 outside of one
+	linkStart := -1
+	// whether the open link is superscript, like most footnote markers
+	linkIsSuperscript := false
+
+	flush := func() {
+		var lines []string
+		for _, line := range strings.Split(current.String(), "\n") {
+			if line = strings.Join(strings.Fields(line), " "); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		current.Reset()
+		linkStart = -1
+		if len(lines) == 0 {
+			return
+		}
+		if heading {
+			for i := range lines {
+				lines[i] = endWithPunctuation(lines[i])
+			}
+		}
+		paragraphs = append(paragraphs, Paragraph{Text: strings.Join(lines, "\n"), HeadingLevel: headingLevel})
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if skipDepth > 0 || skippedElements[name] || unreadElements[name] || isHidden(t) || isNote(t) {
+				skipDepth++
+				continue
+			}
+
+			element := openElement{name: name}
+			switch name {
+			case "ol":
+				element.ordered = true
+				element.nextItem = 1
+				if start, err := strconv.Atoi(attr(t, "start")); err == nil {
+					element.nextItem = start
+				}
+			case "a":
+				if strings.Contains(attr(t, "href"), "#") {
+					linkStart = current.Len()
+					linkIsSuperscript = slices.ContainsFunc(stack, func(open openElement) bool { return open.name == "sup" })
+				}
+			case "sup":
+				if linkStart >= 0 {
+					linkIsSuperscript = true
+				}
+			case "br":
+				current.WriteString("\n")
+			case "td", "th":
+				// cells of a row are read as one line
+				current.WriteString(" ")
+			}
+			if blockElements[name] && name != "td" && name != "th" {
+				flush()
+				heading = headingElements[name]
+				headingLevel = 0
+				if len(name) == 2 && name[0] == 'h' && name[1] >= '1' && name[1] <= '6' {
+					headingLevel = int(name[1] - '0')
+				}
+			}
+			if name == "li" {
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i].name == "ul" {
+						break
+					}
+					if stack[i].ordered {
+						current.WriteString(strconv.Itoa(stack[i].nextItem) + ". ")
+						stack[i].nextItem++
+						break
+					}
+				}
+			}
+			stack = append(stack, element)
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			name := strings.ToLower(t.Name.Local)
+			// pop back to the matching element; tolerates unclosed tags
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == name {
+					stack = stack[:i]
+					break
+				}
+			}
+			if blockElements[name] && name != "td" && name != "th" {
+				flush()
+				heading = false
+				headingLevel = 0
+			}
+			// superscript links to notes that aren't marked as noterefs are recognized by their text like "12" or "[*]";
+			// other links like "see chapter 3" are part of the text
+			if name == "a" && linkStart >= 0 {
+				if text := current.String(); linkIsSuperscript && isNoteMarker(strings.TrimSpace(text[linkStart:])) {
+					current.Reset()
+					current.WriteString(text[:linkStart])
+				}
+				linkStart = -1
+			}
+
+		case xml.CharData:
+			if skipDepth > 0 {
+				continue
+			}
+			// whitespace in XHTML is not significant so newlines inside
+			// a paragraph become spaces
+			current.WriteString(strings.Map(func(r rune) rune {
+				switch {
+				case r == '\n' || r == '\r' || r == '\t' || r == '\u00a0':
+					return ' '
+				case r == '\u00ad' || r == '\u200b' || r == '\ufeff':
+					// soft hyphens and zero width spaces are not read
+					return -1
+				}
+				return r
+			}, string(t)))
+		}
+	}
+	flush()
+
+	return paragraphs, nil
+}
+
+// Whether an element is hidden from readers with the hidden attribute, aria-hidden or display: none
+func isHidden(element xml.StartElement) bool {
+	for _, a := range element.Attr {
+		switch strings.ToLower(a.Name.Local) {
+		case "hidden":
+			return true
+		case "aria-hidden":
+			if strings.EqualFold(strings.TrimSpace(a.Value), "true") {
+				return true
+			}
+		case "style":
+			style := strings.ToLower(strings.Join(strings.Fields(a.Value), ""))
+			if strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Whether an element is a footnote reference or a popup footnote
+func isNote(element xml.StartElement) bool {
+	for _, value := range []string{attr(element, "type"), attr(element, "role")} {
+		for _, noteType := range strings.Fields(value) {
+			if noteTypes[noteType] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Whether the text of a superscript link looks like a footnote marker i.e. "3", "[12]" or "*"
+func isNoteMarker(text string) bool {
+	marker := strings.Trim(text, "[]()")
+	if marker == "" || len([]rune(marker)) > 4 {
+		return false
+	}
+	for _, r := range marker {
+		if !unicode.IsDigit(r) && !strings.ContainsRune("*†‡§", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Add a period to the end of text unless it already ends in punctuation
+func endWithPunctuation(text string) string {
+	trimmed := strings.TrimRightFunc(text, func(r rune) bool {
+		// closing quotes and brackets come after the punctuation
+		return unicode.Is(unicode.Pf, r) || unicode.Is(unicode.Pe, r) || r == '"' || r == '\''
+	})
+	last := []rune(trimmed)
+	if len(last) > 0 && unicode.IsPunct(last[len(last)-1]) {
+		return text
+	}
+	return text + "."
+}
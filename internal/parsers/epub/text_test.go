@@ -0,0 +1,90 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package epub
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractText(t *testing.T) {
+
+	t.Run("blocks and headings", func(t *testing.T) {
+		const xhtml = `<?xml version="1.0" encoding="utf-8"?>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head><title>Chapter 1</title><style>p { margin: 0; }</style></head>
+<body>
+  <section>
+    <h1>Chapter I<br/>Loomings</h1>
+    <p>Call me
+       Ishmael. Some years ago&#8212;never mind how long&nbsp;precisely&mdash;</p>
+    <div><p>Nested &amp; <em>emphasized</em>.</p></div>
+    <blockquote>A line of verse,<br/>and another.</blockquote>
+  </section>
+</body>
+</html>`
+		text, err := ExtractText(strings.NewReader(xhtml))
+		require.NoError(t, err)
+		require.Equal(t, "Chapter I.\nLoomings.\n\n"+
+			"Call me Ishmael. Some years ago—never mind how long precisely—\n\n"+
+			"Nested & emphasized.\n\n"+
+			"A line of verse,\nand another.", text)
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		const xhtml = `<html><body>
+<ul><li>Apples</li><li>Pears</li></ul>
+<ol start="3"><li>Third</li><li>Fourth <ul><li>nested</li></ul></li></ol>
+</body></html>`
+		text, err := ExtractText(strings.NewReader(xhtml))
+		require.NoError(t, err)
+		require.Equal(t, "Apples\n\nPears\n\n3. Third\n\n4. Fourth\n\nnested", text)
+	})
+
+	t.Run("footnotes", func(t *testing.T) {
+		const xhtml = `<html xmlns:epub="http://www.idpf.org/2007/ops"><body>
+<p>A claim<a epub:type="noteref" href="#n1"><sup>1</sup></a> and another<a href="notes.xhtml#n2"><sup>[2]</sup></a> about <a href="#ch2">chapter two</a>.</p>
+<p>A third<sup><a href="notes.xhtml#n3">3</a></sup>, see chapter <a href="#c3">3</a> on <a href="#war">1914</a>.</p>
+<aside epub:type="footnote" id="n1"><p>The source of the claim.</p></aside>
+<p role="doc-footnote">Another note.</p>
+</body></html>`
+		text, err := ExtractText(strings.NewReader(xhtml))
+		require.NoError(t, err)
+		require.Equal(t, "A claim and another about chapter two.\n\nA third, see chapter 3 on 1914.", text)
+	})
+
+	t.Run("hidden elements", func(t *testing.T) {
+		const xhtml = `<html><body>
+<p hidden="hidden">Hidden</p>
+<p style="display: none">Not shown</p>
+<p aria-hidden="true">Decoration</p>
+<p>Shown <span style="visibility:hidden">not</span>text<script>var x = 1;</script>.</p>
+<p><ruby>漢<rt>kan</rt>字<rt>ji</rt></ruby></p>
+<p>soft&#173;hyphen</p>
+</body></html>`
+		text, err := ExtractText(strings.NewReader(xhtml))
+		require.NoError(t, err)
+		require.Equal(t, "Shown text.\n\n漢字\n\nsofthyphen", text)
+	})
+
+	t.Run("images only", func(t *testing.T) {
+		text, err := ExtractText(strings.NewReader(`<html><body><img src="cover.jpg"/></body></html>`))
+		require.NoError(t, err)
+		require.Empty(t, text)
+	})
+}
+
+func TestEndWithPunctuation(t *testing.T) {
+	require.Equal(t, "Chapter 1.", endWithPunctuation("Chapter 1"))
+	require.Equal(t, "Why?", endWithPunctuation("Why?"))
+	require.Equal(t, "“Quoted!”", endWithPunctuation("“Quoted!”"))
+}
+
+func TestExtractParagraphs(t *testing.T) {
+	paragraphs, err := ExtractParagraphs(strings.NewReader(`<html><body><h2>Part One</h2><p>Text.</p><h3>Scene</h3></body></html>`))
+	require.NoError(t, err)
+	require.Equal(t, []Paragraph{{Text: "Part One.", HeadingLevel: 2}, {Text: "Text."}, {Text: "Scene.", HeadingLevel: 3}}, paragraphs)
+}
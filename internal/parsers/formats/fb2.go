@@ -0,0 +1,204 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package formats
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// FictionBook elements and the HTML elements they are read like
+var fb2Elements = map[string]string{
+	"p":           "p",
+	"v":           "p",
+	"subtitle":    "p",
+	"text-author": "p",
+	"epigraph":    "blockquote",
+	"cite":        "blockquote",
+	"poem":        "div",
+	"stanza":      "div",
+	"table":       "table",
+	"tr":          "tr",
+	"td":          "td",
+	"th":          "th",
+}
+
+// FictionBook elements whose text is not read
+var fb2Skipped = map[string]bool{
+	"binary": true, "image": true, "stylesheet": true,
+}
+
+// Read a FictionBook 2 document. Each top level section of the main body becomes
+// a chapter and the title, author and language come from its title-info.
+// The bodies with notes and comments are not read, nor are references to them
+func ReadFB2(input io.Reader) (Document, error) {
+	decoder := xml.NewDecoder(input)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	// FictionBook files often declare encodings like windows-1251 or koi8-r
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		encoding, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported encoding '%s': %v", charset, err)
+		}
+		return encoding.NewDecoder().Reader(input), nil
+	}
+
+	var document Document
+	var out strings.Builder
+	out.WriteString("<html><body>\n")
+
+	// the names of the open elements
+	var path []string
+	// the element whose text is being read as metadata
+	var field string
+	var firstName, middleName, lastName string
+	bodies := 0
+	inBody := false
+	sectionDepth := 0
+	skipDepth := 0
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Document{}, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			path = append(path, name)
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+
+			if len(path) >= 2 && path[len(path)-2] == "title-info" || len(path) >= 3 && path[len(path)-3] == "title-info" && path[len(path)-2] == "author" {
+				field = name
+			}
+
+			switch {
+			case name == "body":
+				bodies++
+				// the first body is the book and the ones after it hold notes and comments
+				inBody = bodies == 1 && fb2Attr(t, "name") == ""
+				if !inBody {
+					skipDepth = 1
+				}
+			case !inBody:
+			case fb2Skipped[name] || name == "a" && fb2Attr(t, "type") == "note":
+				skipDepth = 1
+			case name == "section":
+				sectionDepth++
+			case name == "title":
+				// titles of sections are headings of the level of their section and the
+				// title of the body is read like a paragraph since it is the book's title
+				if sectionDepth > 0 {
+					out.WriteString(fmt.Sprintf("<h%d>", min(sectionDepth, 6)))
+				} else {
+					out.WriteString("<p>")
+				}
+			case name == "p" && insideTitle(path[:len(path)-1]):
+				// the lines of a title are separate paragraphs in FictionBook
+			case name == "empty-line" && insideTitle(path[:len(path)-1]):
+				out.WriteString("<br/>")
+			case fb2Elements[name] != "":
+				out.WriteString("<" + fb2Elements[name] + ">")
+			}
+
+		case xml.EndElement:
+			name := t.Name.Local
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+			field = ""
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+
+			switch {
+			case name == "body":
+				inBody = false
+			case !inBody:
+			case name == "section":
+				sectionDepth--
+			case name == "title":
+				if sectionDepth > 0 {
+					out.WriteString(fmt.Sprintf("</h%d>\n", min(sectionDepth, 6)))
+				} else {
+					out.WriteString("</p>\n")
+				}
+			case name == "p" && insideTitle(path):
+				out.WriteString("<br/>")
+			case fb2Elements[name] != "":
+				out.WriteString("</" + fb2Elements[name] + ">\n")
+			}
+
+		case xml.CharData:
+			if skipDepth > 0 {
+				continue
+			}
+			if inBody {
+				out.WriteString(html.EscapeString(string(t)))
+				continue
+			}
+			value := strings.TrimSpace(string(t))
+			switch field {
+			case "book-title":
+				document.Title = value
+			case "lang":
+				document.Language = lang.Code(value)
+			case "first-name":
+				if firstName == "" {
+					firstName = value
+				}
+			case "middle-name":
+				if middleName == "" {
+					middleName = value
+				}
+			case "last-name":
+				if lastName == "" {
+					lastName = value
+				}
+			}
+		}
+	}
+	out.WriteString("</body></html>\n")
+
+	document.Author = strings.Join(strings.Fields(strings.Join([]string{firstName, middleName, lastName}, " ")), " ")
+
+	paragraphs, err := epub.ExtractParagraphs(strings.NewReader(out.String()))
+	if err != nil {
+		return Document{}, err
+	}
+	document.Chapters = splitAtHeadings(paragraphs, &document.Title)
+	return document, nil
+}
+
+// Whether the innermost open element is inside of a title
+func insideTitle(path []string) bool {
+	return len(path) > 0 && path[len(path)-1] == "title"
+}
+
+// Return the value of an attribute of a FictionBook element, ignoring its namespace
+func fb2Attr(element xml.StartElement, name string) string {
+	for _, a := range element.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
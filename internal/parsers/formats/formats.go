@@ -0,0 +1,85 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package formats
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// The text of a book read by a native reader, split into chapters
+type Document struct {
+	Title  string
+	Author string
+	// The language the book declares, i.e. "en"; empty if it doesn't declare one
+	Language string
+	Chapters []Chapter
+}
+
+// A chapter of a document
+type Chapter struct {
+	// The title of the chapter; empty if it has none, like text before the first heading
+	Title string
+	// The text of the chapter to read aloud, including its title
+	Text string
+}
+
+// The text of the whole document
+func (d Document) Text() string {
+	texts := make([]string, 0, len(d.Chapters))
+	for _, chapter := range d.Chapters {
+		if text := strings.TrimSpace(chapter.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// Reads a file of one format into a document
+type Reader func(io.Reader) (Document, error)
+
+// The native readers for each file extension. Formats that are not
+// listed here are converted with ebook-convert
+var readers = map[string]Reader{
+	".txt":      ReadText,
+	".md":       ReadMarkdown,
+	".markdown": ReadMarkdown,
+	".html":     ReadHTML,
+	".htm":      ReadHTML,
+	".xhtml":    ReadHTML,
+	".fb2":      ReadFB2,
+}
+
+// Return the native reader for a file extension, i.e. ".md", if there is one
+func ForExtension(ext string) (Reader, bool) {
+	reader, ok := readers[strings.ToLower(ext)]
+	return reader, ok
+}
+
+// Add or replace the native reader for a file extension
+func Register(ext string, reader Reader) {
+	readers[strings.ToLower(ext)] = reader
+}
+
+// The file extensions that have a native reader
+func Extensions() []string {
+	extensions := make([]string, 0, len(readers))
+	for ext := range readers {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// Join lines into paragraphs separated by blank lines, dropping empty ones
+func joinParagraphs(paragraphs []string) string {
+	var kept []string
+	for _, paragraph := range paragraphs {
+		if paragraph = strings.TrimSpace(paragraph); paragraph != "" {
+			kept = append(kept, paragraph)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package formats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func chapterTitles(document Document) []string {
+	titles := make([]string, len(document.Chapters))
+	for i, chapter := range document.Chapters {
+		titles[i] = chapter.Title
+	}
+	return titles
+}
+
+func TestForExtension(t *testing.T) {
+	for _, ext := range []string{".txt", ".MD", ".html", ".fb2"} {
+		_, ok := ForExtension(ext)
+		require.True(t, ok, ext)
+	}
+	_, ok := ForExtension(".mobi")
+	require.False(t, ok)
+}
+
+func TestReadText(t *testing.T) {
+	text := `Title: Pride and Prejudice
+Author: Jane Austen
+Language: English
+
+Chapter 1
+
+It is a truth universally acknowledged.
+The chapter goes on.
+
+CHAPTER II.
+
+Mr. Bennet was among the earliest.
+Chapter and verse are quoted in this line but it doesn't start a chapter.
+`
+	document, err := ReadText(strings.NewReader(text))
+	require.NoError(t, err)
+	require.Equal(t, "Pride and Prejudice", document.Title)
+	require.Equal(t, "Jane Austen", document.Author)
+	require.Equal(t, "en", document.Language)
+	require.Equal(t, []string{"", "Chapter 1", "CHAPTER II."}, chapterTitles(document))
+	require.Equal(t, "Chapter 1\n\nIt is a truth universally acknowledged.\nThe chapter goes on.", document.Chapters[1].Text)
+	require.Contains(t, document.Chapters[2].Text, "Chapter and verse")
+}
+
+func TestIsChapterHeading(t *testing.T) {
+	for _, line := range []string{"Chapter 1", "CHAPTER II.", "Part Two:", "Letter 4", "Prologue", "CHAPTER IV. THE SEA", "EPILOGUE: AFTER"} {
+		require.True(t, isChapterHeading(line), line)
+	}
+	for _, line := range []string{
+		"Part of the crowd had gone home, and the rest stood waiting by the",
+		"Book lovers will know this one.",
+		"Chapter 3 of the report",
+		"Letters",
+		"PARTING WORDS",
+		"Part Two: The Return",
+	} {
+		require.False(t, isChapterHeading(line), line)
+	}
+}
+
+func TestReadMarkdown(t *testing.T) {
+	markdown := `---
+title: The Book
+author: Someone
+lang: fr-FR
+---
+
+# The Book
+
+## First
+
+Some *emphasis*, **bold** and a [link](https://example.com) ![image](cover.png).
+
+` + "```go\nfmt.Println(\"not read\")\n```" + `
+
+- one
+- two
+
+3. three
+4. four
+
+> quoted
+> text
+
+| a | b |
+|---|---|
+| 1 | 2 |
+
+Second
+------
+
+snake_case_names stay the same.
+`
+	document, err := ReadMarkdown(strings.NewReader(markdown))
+	require.NoError(t, err)
+	require.Equal(t, "The Book", document.Title)
+	require.Equal(t, "Someone", document.Author)
+	require.Equal(t, "fr", document.Language)
+	require.Equal(t, []string{"The Book", "First", "Second"}, chapterTitles(document))
+	require.Equal(t, "The Book.", document.Chapters[0].Text)
+	require.Equal(t, "First.\n\nSome emphasis, bold and a link .\n\none\n\ntwo\n\n3. three\n\n4. four\n\nquoted text\n\na, b\n\n1, 2", document.Chapters[1].Text)
+	require.Equal(t, "Second.\n\nsnake_case_names stay the same.", document.Chapters[2].Text)
+}
+
+func TestReadHTML(t *testing.T) {
+	page := `<html lang="de-DE"><head><title>Ein Buch</title><meta name="author" content="Jemand"/></head>
+<body>
+<h1>Ein Buch</h1>
+<h2>Eins</h2><p>Text<a href="#n1"><sup>1</sup></a> eins.</p>
+<h2>Zwei</h2><p>Text zwei.</p>
+</body></html>`
+	document, err := ReadHTML(strings.NewReader(page))
+	require.NoError(t, err)
+	require.Equal(t, "Ein Buch", document.Title)
+	require.Equal(t, "Jemand", document.Author)
+	require.Equal(t, "de", document.Language)
+	require.Equal(t, []string{"Ein Buch", "Eins", "Zwei"}, chapterTitles(document))
+	require.Equal(t, "Eins.\n\nText eins.", document.Chapters[1].Text)
+	require.Equal(t, "Ein Buch.\n\nEins.\n\nText eins.\n\nZwei.\n\nText zwei.", document.Text())
+}
+
+func TestSplitAtHeadingsUsesTheSingleHeadingAsTitle(t *testing.T) {
+	document, err := ReadHTML(strings.NewReader(`<html><body><h1>Only Title</h1><h3>A</h3><p>a</p><h3>B</h3><p>b</p></body></html>`))
+	require.NoError(t, err)
+	require.Equal(t, "Only Title", document.Title)
+	require.Equal(t, []string{"Only Title", "A", "B"}, chapterTitles(document))
+}
+
+func TestReadFB2(t *testing.T) {
+	book := `<?xml version="1.0" encoding="utf-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0" xmlns:l="http://www.w3.org/1999/xlink">
+<description>
+<title-info>
+<author><first-name>Lev</first-name><middle-name>N.</middle-name><last-name>Tolstoy</last-name></author>
+<book-title>War and Peace</book-title>
+<lang>ru</lang>
+</title-info>
+<document-info><author><first-name>Editor</first-name></author></document-info>
+</description>
+<body>
+<title><p>War and Peace</p></title>
+<section>
+<title><p>Chapter 1</p><p>Anna Pavlovna</p></title>
+<epigraph><p>An epigraph</p></epigraph>
+<p>Well, Prince<a l:href="#n1" type="note">1</a>, so Genoa and Lucca.</p>
+<empty-line/>
+<poem><stanza><v>First line</v><v>Second line</v></stanza></poem>
+</section>
+<section>
+<title><p>Chapter 2</p></title>
+<p>The next chapter.</p>
+<image l:href="#picture"/>
+</section>
+</body>
+<body name="notes"><section id="n1"><p>A note</p></section></body>
+<binary id="picture" content-type="image/png">AAAA</binary>
+</FictionBook>`
+	document, err := ReadFB2(strings.NewReader(book))
+	require.NoError(t, err)
+	require.Equal(t, "War and Peace", document.Title)
+	require.Equal(t, "Lev N. Tolstoy", document.Author)
+	require.Equal(t, "ru", document.Language)
+	require.Equal(t, []string{"", "Chapter 1: Anna Pavlovna", "Chapter 2"}, chapterTitles(document))
+	require.Equal(t, "Chapter 1.\nAnna Pavlovna.\n\nAn epigraph\n\nWell, Prince, so Genoa and Lucca.\n\nFirst line\n\nSecond line", document.Chapters[1].Text)
+	require.Equal(t, "Chapter 2.\n\nThe next chapter.", document.Chapters[2].Text)
+	require.NotContains(t, document.Text(), "A note")
+}
+
+func TestReadFB2Encodings(t *testing.T) {
+	for name, encoding := range map[string]*charmap.Charmap{"windows-1251": charmap.Windows1251, "koi8-r": charmap.KOI8R} {
+		t.Run(name, func(t *testing.T) {
+			book := `<?xml version="1.0" encoding="` + name + `"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0">
+<description><title-info><book-title>Война и мир</book-title><lang>ru</lang></title-info></description>
+<body><section><title><p>Глава 1</p></title><p>Ну, князь, Генуя и Лукка.</p></section></body>
+</FictionBook>`
+			encoded, err := encoding.NewEncoder().String(book)
+			require.NoError(t, err)
+
+			document, err := ReadFB2(strings.NewReader(encoded))
+			require.NoError(t, err)
+			require.Equal(t, "Война и мир", document.Title)
+			require.Equal(t, "Глава 1.\n\nНу, князь, Генуя и Лукка.", document.Chapters[0].Text)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package formats
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+)
+
+// Read an HTML or XHTML document, splitting it into chapters at its headings.
+// The title, author and language come from <title>, <meta name="author"> and the lang attribute
+func ReadHTML(input io.Reader) (Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return Document{}, err
+	}
+
+	document, err := htmlMetadata(bytes.NewReader(data))
+	if err != nil {
+		return Document{}, err
+	}
+	paragraphs, err := epub.ExtractParagraphs(bytes.NewReader(data))
+	if err != nil {
+		return Document{}, err
+	}
+
+	document.Chapters = splitAtHeadings(paragraphs, &document.Title)
+	return document, nil
+}
+
+// Group paragraphs into chapters that each start at a heading. Chapters start at the
+// highest level of heading that is used more than once, so a single <h1> with the
+// title of the whole document doesn't swallow every chapter; that heading becomes
+// the title if there is none yet
+func splitAtHeadings(paragraphs []epub.Paragraph, title *string) []Chapter {
+	counts := make(map[int]int)
+	for _, paragraph := range paragraphs {
+		counts[paragraph.HeadingLevel]++
+	}
+	splitLevel := 0
+	for level := 1; level <= 6 && splitLevel == 0; level++ {
+		if counts[level] > 1 {
+			splitLevel = level
+		}
+	}
+	for level := 1; level <= 6 && splitLevel == 0; level++ {
+		if counts[level] == 1 {
+			splitLevel = level
+		}
+	}
+	for level := 1; level < splitLevel && *title == ""; level++ {
+		if counts[level] == 1 {
+			*title = headingTitle(headingText(paragraphs, level))
+		}
+	}
+
+	var chapters []Chapter
+	var current Chapter
+	var texts []string
+	finish := func() {
+		current.Text = joinParagraphs(texts)
+		if current.Text != "" {
+			chapters = append(chapters, current)
+		}
+		texts = nil
+	}
+	for _, paragraph := range paragraphs {
+		if splitLevel > 0 && paragraph.HeadingLevel > 0 && paragraph.HeadingLevel <= splitLevel {
+			finish()
+			current = Chapter{Title: headingTitle(paragraph.Text)}
+		}
+		texts = append(texts, paragraph.Text)
+	}
+	finish()
+	return chapters
+}
+
+// Turn the text of a heading back into a title, i.e. "Chapter I.\nLoomings." -> "Chapter I: Loomings"
+func headingTitle(heading string) string {
+	return strings.TrimSuffix(strings.ReplaceAll(heading, ".\n", ": "), ".")
+}
+
+// Return the text of the first heading of a level
+func headingText(paragraphs []epub.Paragraph, level int) string {
+	for _, paragraph := range paragraphs {
+		if paragraph.HeadingLevel == level {
+			return paragraph.Text
+		}
+	}
+	return ""
+}
+
+// Read the title, author and language from the head of an HTML document
+func htmlMetadata(input io.Reader) (Document, error) {
+	decoder := xml.NewDecoder(input)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var document Document
+	inTitle := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return Document{}, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch strings.ToLower(t.Name.Local) {
+			case "html":
+				for _, a := range t.Attr {
+					if a.Name.Local == "lang" && a.Value != "" {
+						document.Language = lang.Base(a.Value)
+					}
+				}
+			case "title":
+				inTitle = true
+			case "meta":
+				var name, content string
+				for _, a := range t.Attr {
+					switch strings.ToLower(a.Name.Local) {
+					case "name":
+						name = strings.ToLower(a.Value)
+					case "content":
+						content = a.Value
+					}
+				}
+				if name == "author" || name == "dc.creator" {
+					document.Author = strings.TrimSpace(content)
+				}
+			case "body":
+				// the metadata is all in the head
+				return document, nil
+			}
+		case xml.EndElement:
+			if strings.ToLower(t.Name.Local) == "title" {
+				inTitle = false
+			}
+		case xml.CharData:
+			if inTitle {
+				document.Title = strings.Join(strings.Fields(document.Title+" "+string(t)), " ")
+			}
+		}
+	}
+	return document, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	atxHeading     = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	setextH1       = regexp.MustCompile(`^=+\s*$`)
+	setextH2       = regexp.MustCompile(`^-+\s*$`)
+	thematicBreak  = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	unorderedItem  = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedItem    = regexp.MustCompile(`^\s*(\d+)[.)]\s+(.*)$`)
+	blockquoteLine = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	tableSeparator = regexp.MustCompile(`^\s*\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?\s*$`)
+	codeFence      = regexp.MustCompile("^\\s*(```|~~~)")
+	definitionLine = regexp.MustCompile(`^\s*\[[^\]]+\]:\s+\S`)
+	// backslash escapes are read as the character they escape
+	backslashEscape = regexp.MustCompile(`\\([\\` + "`" + `*_{}\[\]()#+\-.!|>])`)
+	markdownInlines = []struct {
+		pattern     *regexp.Regexp
+		replacement string
+	}{
+		// images and footnote references are not read
+		{regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`), ""},
+		{regexp.MustCompile(`\[\^[^\]]+\]`), ""},
+		// links are read as their text
+		{regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), "$1"},
+		{regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`), "$1"},
+		{regexp.MustCompile(`<(https?://[^>]+)>`), "$1"},
+		{regexp.MustCompile("`([^`]+)`"), "$1"},
+		{regexp.MustCompile(`(\*\*|__)(\S(.*?\S)?)(\*\*|__)`), "$2"},
+		{regexp.MustCompile(`~~(\S(.*?\S)?)~~`), "$1"},
+		{regexp.MustCompile(`\*(\S(.*?\S)?)\*`), "$1"},
+		{regexp.MustCompile(`(^|[^\w])_(\S(.*?\S)?)_([^\w]|$)`), "$1$2$4"},
+		// inline html tags
+		{regexp.MustCompile(`</?[a-zA-Z][^>]*>`), ""},
+	}
+)
+
+// Metadata in YAML front matter at the start of a markdown file
+type frontMatter struct {
+	Title    string `yaml:"title"`
+	Author   string `yaml:"author"`
+	Language string `yaml:"language"`
+	Lang     string `yaml:"lang"`
+}
+
+// Read a markdown document, splitting it into chapters at its headings.
+// The title, author and language come from YAML front matter if there is any.
+// Formatting, links and images are removed and code blocks are not read
+func ReadMarkdown(input io.Reader) (Document, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return Document{}, err
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	var document Document
+	if rest, ok := strings.CutPrefix(text, "---\n"); ok {
+		if header, body, found := strings.Cut(rest, "\n---\n"); found {
+			var metadata frontMatter
+			if err := yaml.Unmarshal([]byte(header), &metadata); err == nil {
+				document.Title = metadata.Title
+				document.Author = metadata.Author
+				document.Language = lang.Code(metadata.Language + metadata.Lang)
+				text = body
+			}
+		}
+	}
+
+	paragraphs, err := epub.ExtractParagraphs(strings.NewReader(markdownToHTML(text)))
+	if err != nil {
+		return Document{}, err
+	}
+	document.Chapters = splitAtHeadings(paragraphs, &document.Title)
+	return document, nil
+}
+
+// Convert the block structure of markdown to simple HTML with the inline formatting removed
+func markdownToHTML(markdown string) string {
+	var out strings.Builder
+	out.WriteString("<html><body>\n")
+
+	var paragraph []string
+	// the list that is open, "ul" or "ol", or empty if none is
+	list := ""
+	inQuote := false
+	inCode := false
+
+	flush := func() {
+		if len(paragraph) > 0 {
+			out.WriteString("<p>" + inline(strings.Join(paragraph, " ")) + "</p>\n")
+			paragraph = nil
+		}
+	}
+	closeBlocks := func() {
+		flush()
+		if list != "" {
+			out.WriteString("</" + list + ">\n")
+			list = ""
+		}
+		if inQuote {
+			out.WriteString("</blockquote>\n")
+			inQuote = false
+		}
+	}
+	openList := func(kind, start string) {
+		flush()
+		if list != kind {
+			closeBlocks()
+			list = kind
+			if start != "" && start != "1" {
+				out.WriteString(fmt.Sprintf("<ol start=\"%s\">\n", start))
+			} else {
+				out.WriteString("<" + kind + ">\n")
+			}
+		}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(markdown))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if codeFence.MatchString(line) {
+			closeBlocks()
+			inCode = !inCode
+			continue
+		}
+		if inCode || definitionLine.MatchString(line) || tableSeparator.MatchString(line) && strings.Contains(line, "|") {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case len(paragraph) > 0 && list == "" && setextH1.MatchString(line):
+			out.WriteString("<h1>" + inline(strings.Join(paragraph, " ")) + "</h1>\n")
+			paragraph = nil
+		case len(paragraph) > 0 && list == "" && setextH2.MatchString(line):
+			out.WriteString("<h2>" + inline(strings.Join(paragraph, " ")) + "</h2>\n")
+			paragraph = nil
+		case thematicBreak.MatchString(line):
+			closeBlocks()
+		case atxHeading.MatchString(line):
+			closeBlocks()
+			match := atxHeading.FindStringSubmatch(line)
+			level := len(match[1])
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, inline(match[2]), level))
+		case orderedItem.MatchString(line):
+			match := orderedItem.FindStringSubmatch(line)
+			openList("ol", match[1])
+			out.WriteString("<li>" + inline(match[2]) + "</li>\n")
+		case unorderedItem.MatchString(line):
+			openList("ul", "")
+			out.WriteString("<li>" + inline(unorderedItem.FindStringSubmatch(line)[1]) + "</li>\n")
+		case blockquoteLine.MatchString(line):
+			if !inQuote {
+				closeBlocks()
+				out.WriteString("<blockquote>\n")
+				inQuote = true
+			}
+			if quoted := blockquoteLine.FindStringSubmatch(line)[1]; strings.TrimSpace(quoted) == "" {
+				flush()
+			} else {
+				paragraph = append(paragraph, quoted)
+			}
+		case strings.HasPrefix(trimmed, "|"):
+			// each row of a table is read as a line with its cells separated by commas
+			closeBlocks()
+			var cells []string
+			for _, cell := range strings.Split(strings.Trim(trimmed, "|"), "|") {
+				if cell = strings.TrimSpace(cell); cell != "" {
+					cells = append(cells, cell)
+				}
+			}
+			out.WriteString("<p>" + inline(strings.Join(cells, ", ")) + "</p>\n")
+		default:
+			if list != "" && !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") || inQuote {
+				closeBlocks()
+			}
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	closeBlocks()
+
+	out.WriteString("</body></html>\n")
+	return out.String()
+}
+
+// Remove inline markdown formatting from text and escape it for HTML
+func inline(text string) string {
+	for _, rule := range markdownInlines {
+		text = rule.pattern.ReplaceAllString(text, rule.replacement)
+	}
+	text = backslashEscape.ReplaceAllString(text, "$1")
+	return html.EscapeString(text)
+}
@@ -0,0 +1,91 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package formats
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+)
+
+// A line that starts a new chapter in plain text, i.e. "Chapter 4", "PART II." or "Prologue".
+// Lines that go on after the number only start a chapter if they are in capitals like "CHAPTER IV. THE SEA"
+// so that a paragraph starting with i.e. "Part of the crowd" does not
+var (
+	chapterHeading        = regexp.MustCompile(`(?i)^((chapter|part|book|letter)\s+([0-9]+|[ivxlcdm]+|one|two|three|four|five|six|seven|eight|nine|ten)|prologue|epilogue)[.:]?$`)
+	capitalChapterHeading = regexp.MustCompile(`^(CHAPTER|PART|BOOK|LETTER)\s+([0-9]+|[IVXLCDM]+|ONE|TWO|THREE|FOUR|FIVE|SIX|SEVEN|EIGHT|NINE|TEN)\b|^(PROLOGUE|EPILOGUE)\b`)
+)
+
+// The longest line that is treated as a chapter heading
+const maxHeadingLength = 80
+
+// Metadata fields in the header of plain text books like Project Gutenberg ones, i.e. "Author: Jane Austen"
+var textMetadata = regexp.MustCompile(`^(Title|Author|Language):\s*(.+)$`)
+
+// How many lines at the start of a plain text book are searched for metadata
+const metadataLines = 60
+
+// Read plain text, splitting it into chapters at lines like "Chapter 1" or "PART II".
+// Title, author and language fields in the header of the book are used as metadata
+func ReadText(input io.Reader) (Document, error) {
+	var document Document
+	var chapters []Chapter
+	current := Chapter{}
+	var lines []string
+
+	finish := func() {
+		current.Text = strings.TrimSpace(strings.Join(lines, "\n"))
+		if current.Text != "" {
+			chapters = append(chapters, current)
+		}
+		lines = nil
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for lineNumber := 0; scanner.Scan(); lineNumber++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if lineNumber < metadataLines {
+			if match := textMetadata.FindStringSubmatch(line); match != nil {
+				value := strings.TrimSpace(match[2])
+				switch match[1] {
+				case "Title":
+					document.Title = value
+				case "Author":
+					document.Author = value
+				case "Language":
+					document.Language = lang.Code(value)
+				}
+			}
+		}
+
+		trimmed := strings.TrimSpace(line)
+		// a heading is a short line of its own that comes after a blank line
+		startsParagraph := len(lines) == 0 || strings.TrimSpace(lines[len(lines)-1]) == ""
+		if startsParagraph && len(trimmed) <= maxHeadingLength && isChapterHeading(trimmed) {
+			finish()
+			current = Chapter{Title: trimmed}
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return Document{}, err
+	}
+	finish()
+
+	document.Chapters = chapters
+	return document, nil
+}
+
+// Whether a line on its own is a chapter heading
+func isChapterHeading(line string) bool {
+	if chapterHeading.MatchString(line) {
+		return true
+	}
+	return line == strings.ToUpper(line) && capitalChapterHeading.MatchString(line)
+}
@@ -0,0 +1,240 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// Package pdf cleans up the text that PDFs are converted to so that it reads
+// like a book instead of a series of printed pages
+package pdf
+
+import (
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// lines longer than this are body text and never treated as a header or footer
+	maxFurnitureLength = 80
+	// a line must repeat at least this many times to be treated as a header or footer
+	minRepeats = 3
+	// when the text has page breaks, a line must be on at least this share of pages
+	minPageShare = 0.3
+	// without page breaks, a line must repeat at least this many lines apart, which is about a page
+	minPageLines = 20
+	// without page breaks, this share of the gaps between repeats must be within
+	// pageTolerance of the usual gap, as page headers are; scene breaks and refrains are not
+	regularShare  = 0.8
+	pageTolerance = 0.2
+	// a line shorter than this share of a typical line ended early, so it ends a paragraph
+	shortLineShare = 0.75
+)
+
+// page numbers change from page to page so they are ignored when comparing lines
+var digitsPattern = regexp.MustCompile(`\d+`)
+
+// Clean up text converted from a PDF by removing headers and footers that repeat
+// on every page, rejoining words hyphenated across lines, and joining the hard
+// wrapped lines of each paragraph back together
+func Clean(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	pages := strings.Split(text, "\f")
+
+	// without page breaks, numbered headings like "Chapter 1" could be mistaken for
+	// numbered page headers, so lines only count as the same if they match exactly
+	paged := len(pages) > 1
+
+	var lines []string
+	repeated := repeatedLines(pages, paged)
+	for _, page := range pages {
+		for _, line := range strings.Split(page, "\n") {
+			line = strings.TrimSpace(line)
+			if repeated[furnitureKey(line, paged)] {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		// a page break always ends a line, but not necessarily a paragraph
+		if len(lines) > 0 && lines[len(lines)-1] != "" {
+			lines = append(lines, pageBreak)
+		}
+	}
+
+	return reflow(lines)
+}
+
+// Clean up text from a PDF as it is read. Headers and footers are found by
+// looking at the whole text so it is read fully before any of it is returned
+func Reader(input io.Reader) (io.Reader, error) {
+	text, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(Clean(string(text))), nil
+}
+
+// marks where a page ended between two lines
+const pageBreak = "\f"
+
+// The key used to compare lines that might be headers or footers. Empty if the line can't be one
+func furnitureKey(line string, ignoreNumbers bool) string {
+	line = strings.TrimSpace(line)
+	if line == "" || utf8.RuneCountInString(line) > maxFurnitureLength {
+		return ""
+	}
+	// a running header doesn't end like a sentence; this keeps repeated dialog like "Yes."
+	if last, _ := utf8.DecodeLastRuneInString(line); strings.ContainsRune(".!?\"'”’", last) {
+		return ""
+	}
+	if ignoreNumbers {
+		line = digitsPattern.ReplaceAllString(line, "#")
+	}
+	return strings.ToLower(line)
+}
+
+// Find the lines that repeat often enough to be headers or footers
+func repeatedLines(pages []string, paged bool) map[string]bool {
+	if !paged {
+		return regularLines(strings.Split(pages[0], "\n"))
+	}
+
+	counts := make(map[string]int)
+	for _, page := range pages {
+		// count each line once per page so a line repeated on one page doesn't count
+		seen := make(map[string]bool)
+		for _, line := range strings.Split(page, "\n") {
+			if key := furnitureKey(line, paged); key != "" && !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	threshold := max(minRepeats, int(float64(len(pages))*minPageShare))
+
+	repeated := make(map[string]bool)
+	for key, count := range counts {
+		if count >= threshold {
+			repeated[key] = true
+		}
+	}
+	return repeated
+}
+
+// Find the lines of text without page breaks that repeat at the regular interval of
+// a page, as headers and footers do. Lines like scene breaks ("* * *"), refrains or the
+// names of speakers repeat as often but not every page's worth of lines
+func regularLines(lines []string) map[string]bool {
+	positions := make(map[string][]int)
+	for i, line := range lines {
+		if key := furnitureKey(line, false); key != "" {
+			positions[key] = append(positions[key], i)
+		}
+	}
+
+	repeated := make(map[string]bool)
+	for key, at := range positions {
+		if len(at) >= minRepeats && isRegular(at) {
+			repeated[key] = true
+		}
+	}
+	return repeated
+}
+
+// Whether positions are about a page apart from each other
+func isRegular(positions []int) bool {
+	gaps := make([]int, 0, len(positions)-1)
+	for i := 1; i < len(positions); i++ {
+		gaps = append(gaps, positions[i]-positions[i-1])
+	}
+	sorted := slices.Clone(gaps)
+	slices.Sort(sorted)
+	usual := sorted[len(sorted)/2]
+	if usual < minPageLines {
+		return false
+	}
+
+	regular := 0
+	for _, gap := range gaps {
+		if math.Abs(float64(gap-usual)) <= float64(usual)*pageTolerance {
+			regular++
+		}
+	}
+	return float64(regular) >= float64(len(gaps))*regularShare
+}
+
+// Join hard wrapped lines into paragraphs separated by blank lines
+func reflow(lines []string) string {
+	typical := typicalLineLength(lines)
+
+	var result strings.Builder
+	var paragraph strings.Builder
+	endParagraph := func() {
+		if paragraph.Len() > 0 {
+			result.WriteString(strings.TrimSpace(paragraph.String()))
+			result.WriteString("\n\n")
+			paragraph.Reset()
+		}
+	}
+
+	for i, line := range lines {
+		if line == "" {
+			endParagraph()
+			continue
+		}
+		if line == pageBreak {
+			continue
+		}
+
+		next := nextLine(lines, i)
+		switch {
+		case strings.HasSuffix(line, "-") && isHyphenated(line, next):
+			// "hyphen-" followed by "ated" is one word
+			paragraph.WriteString(strings.TrimSuffix(line, "-"))
+		case float64(utf8.RuneCountInString(line)) < float64(typical)*shortLineShare:
+			// the line ended early so it is the end of a paragraph or a heading
+			paragraph.WriteString(line)
+			endParagraph()
+		default:
+			paragraph.WriteString(line + " ")
+		}
+	}
+	endParagraph()
+
+	return strings.TrimRight(result.String(), "\n") + "\n"
+}
+
+// Return the next line of text after i, looking past page breaks
+func nextLine(lines []string, i int) string {
+	for _, line := range lines[i+1:] {
+		if line != pageBreak {
+			return line
+		}
+	}
+	return ""
+}
+
+// Whether a line ending in a hyphen continues as a word on the next line
+func isHyphenated(line, next string) bool {
+	before, _ := utf8.DecodeLastRuneInString(strings.TrimSuffix(line, "-"))
+	after, _ := utf8.DecodeRuneInString(next)
+	return unicode.IsLetter(before) && unicode.IsLower(after)
+}
+
+// The length of a full line of text, used to tell when a line ended early
+func typicalLineLength(lines []string) int {
+	var lengths []int
+	for _, line := range lines {
+		if line != "" && line != pageBreak {
+			lengths = append(lengths, utf8.RuneCountInString(line))
+		}
+	}
+	if len(lengths) == 0 {
+		return 0
+	}
+	slices.Sort(lengths)
+	// most lines in a paragraph are full so a high percentile is a full line
+	return lengths[len(lengths)*3/4]
+}
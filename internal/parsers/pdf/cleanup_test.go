@@ -0,0 +1,79 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package pdf
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const pagedText = `THE TIME MACHINE
+The Time Traveller (for so it will be convenient to speak of him) was
+expounding a recondite matter to us. His grey eyes shone and twin-
+kled, and his usually pale face was flushed and animated.
+1
+` + "\f" + `THE TIME MACHINE
+The fire burned brightly, and the soft radiance of the incandescent
+lights in the lilies of silver caught the bubbles that flashed and
+passed.
+"Yes."
+2
+` + "\f" + `THE TIME MACHINE
+Chapter 2
+"Yes."
+It was a long and winding story that he told us all that night, and
+we listened.
+3
+`
+
+func TestClean(t *testing.T) {
+	expected := `The Time Traveller (for so it will be convenient to speak of him) was expounding a recondite matter to us. His grey eyes shone and twinkled, and his usually pale face was flushed and animated.
+
+The fire burned brightly, and the soft radiance of the incandescent lights in the lilies of silver caught the bubbles that flashed and passed.
+
+"Yes."
+
+Chapter 2
+
+"Yes."
+
+It was a long and winding story that he told us all that night, and we listened.
+`
+	require.Equal(t, expected, Clean(pagedText))
+}
+
+func TestCleanWithoutPageBreaks(t *testing.T) {
+	text := "Chapter 1\nIt began on a cold morning in the middle of a long and\nbitter winter when the snow lay deep on every road and\nfield.\nChapter 2\nIt ended on a warm evening in the first days of summer\nwhen nobody expected it.\nChapter 3\nNothing more.\n"
+	expected := "Chapter 1\n\nIt began on a cold morning in the middle of a long and bitter winter when the snow lay deep on every road and field.\n\nChapter 2\n\nIt ended on a warm evening in the first days of summer when nobody expected it.\n\nChapter 3\n\nNothing more.\n"
+	require.Equal(t, expected, Clean(text))
+}
+
+func TestReader(t *testing.T) {
+	reader, err := Reader(strings.NewReader("one para-\ngraph\r\n\r\nanother\r\n"))
+	require.NoError(t, err)
+	text, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "one paragraph\n\nanother\n", string(text))
+}
+
+func TestCleanWithoutPageBreaksKeepsRepeatedLines(t *testing.T) {
+	text := "HAMLET\nTo be, or not to be.\n* * *\nThe night was cold.\nHAMLET\nWho is there?\n* * *\nMorning came.\n* * *\nHAMLET\nFarewell.\n"
+	cleaned := Clean(text)
+	require.Equal(t, 3, strings.Count(cleaned, "* * *"))
+	require.Equal(t, 3, strings.Count(cleaned, "HAMLET"))
+}
+
+func TestCleanWithoutPageBreaksRemovesRegularHeaders(t *testing.T) {
+	var text strings.Builder
+	for page := 0; page < 4; page++ {
+		text.WriteString("THE TIME MACHINE\n")
+		for line := 0; line < 30; line++ {
+			text.WriteString("It was a long and winding story that he told us all that night.\n")
+		}
+	}
+	require.NotContains(t, Clean(text.String()), "THE TIME MACHINE")
+}
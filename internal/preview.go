@@ -0,0 +1,50 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// The longest text a voice can be previewed with
+const MaxPreviewLength = 500
+
+// Read a short text with a model and write it to output as a wav, so that a voice
+// can be heard before a book is converted with it
+func Preview(config AudiobookArgs, model, text string, output io.Writer) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("there is no text to preview")
+	}
+	if len(text) > MaxPreviewLength {
+		return fmt.Errorf("the preview text is longer than %d characters", MaxPreviewLength)
+	}
+
+	voices := newVoiceSelector(config)
+	client, err := voices.clientForModel(model)
+	if err != nil {
+		return err
+	}
+	release := voices.acquire()
+	defer release()
+
+	config.FileName = "preview.txt"
+	config.OutputDirectory = os.TempDir()
+	config.OutputAsMp3 = false
+	// the sample is short enough to keep in memory and fill in the sizes in the header
+	var wav bytes.Buffer
+	if err := streamAudio(client, strings.NewReader(text), config, &wav); err != nil {
+		return err
+	}
+	audio := wav.Bytes()
+	binary.LittleEndian.PutUint32(audio[4:8], uint32(len(audio)-8))
+	binary.LittleEndian.PutUint32(audio[40:44], uint32(len(audio)-44))
+	_, err = output.Write(audio)
+	return err
+}
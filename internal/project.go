@@ -0,0 +1,302 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/filters"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+
+	log "github.com/charmbracelet/log"
+	"gopkg.in/yaml.v3"
+)
+
+// The name of the project file the build command looks for by default
+const ProjectFileName = "audiobook.yaml"
+
+// The formats an audiobook can be built in
+var OutputFormats = []string{"mp3", "m4b", "wav"}
+
+// Describes how to build an audiobook from one or more files so the same
+// production can be checked in and rebuilt. Paths are relative to the project file
+type Project struct {
+	// The title of the audiobook, used to name the output files
+	Title string `yaml:"title"`
+	// The model that reads the book unless a chapter picks another one
+	Voice string `yaml:"voice,omitempty"`
+	// How fast to read relative to the model's default, i.e. 1.1
+	Speed float64 `yaml:"speed,omitempty"`
+	// Pronunciation lexicon files used on top of the global one
+	Lexicon []string `yaml:"lexicon,omitempty"`
+	// The directory to write the audiobook to; defaults to the project's directory
+	Output string `yaml:"output,omitempty"`
+	// The formats to write, any of OutputFormats; defaults to mp3
+	Formats []string `yaml:"formats,omitempty"`
+	// Tags written to the audiobook, i.e. {"artist": "James Joyce"}
+	Tags map[string]string `yaml:"tags,omitempty"`
+	// The files the text comes from
+	Sources []ProjectSource `yaml:"sources"`
+	// The chapters in the order they are read. If empty, every section of every source is read
+	Chapters []ProjectChapter `yaml:"chapters,omitempty"`
+}
+
+// A file that chapters are taken from
+type ProjectSource struct {
+	// The name chapters use to refer to the source; defaults to the file
+	Name string `yaml:"name,omitempty"`
+	File string `yaml:"file"`
+	// The language of the text if it should not be read from the file
+	Language string `yaml:"language,omitempty"`
+}
+
+// One or more chapters of the audiobook taken from a source
+type ProjectChapter struct {
+	// The name or file of the source to read
+	Source string `yaml:"source"`
+	// The epub sections to read, like --sections; each becomes its own chapter
+	Sections []string `yaml:"sections,omitempty"`
+	// The title of the chapter; only allowed when the entry is a single chapter
+	Title string `yaml:"title,omitempty"`
+	// The model that reads this chapter instead of the project's voice
+	Voice string `yaml:"voice,omitempty"`
+	// How fast to read this chapter instead of the project's speed
+	Speed float64 `yaml:"speed,omitempty"`
+	// The language of the chapter if it is different from its source
+	Language string `yaml:"language,omitempty"`
+}
+
+// Read and check a project file. Relative paths in it are made relative to its directory
+func LoadProject(path string) (Project, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Project{}, err
+	}
+	var project Project
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&project); err != nil {
+		return Project{}, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	resolve := func(file string) string {
+		if file == "" || filepath.IsAbs(file) || strings.HasPrefix(file, "~") || lib.IsUrl(file) {
+			return file
+		}
+		return filepath.Join(dir, file)
+	}
+
+	if project.Title == "" {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return Project{}, err
+		}
+		project.Title = filepath.Base(absDir)
+	}
+	if err := checkTitle(project.Title); err != nil {
+		return Project{}, fmt.Errorf("%s: %v", path, err)
+	}
+	if project.Output == "" {
+		project.Output = "."
+	}
+	project.Output = resolve(project.Output)
+	for i := range project.Lexicon {
+		project.Lexicon[i] = resolve(project.Lexicon[i])
+	}
+
+	if len(project.Formats) == 0 {
+		project.Formats = []string{"mp3"}
+	}
+	for _, format := range project.Formats {
+		if !slices.Contains(OutputFormats, format) {
+			return Project{}, fmt.Errorf("unknown format '%s' in %s; expected one of %s", format, path, strings.Join(OutputFormats, ", "))
+		}
+	}
+	if project.Speed < 0 {
+		return Project{}, fmt.Errorf("the speed in %s must be positive", path)
+	}
+
+	if len(project.Sources) == 0 {
+		return Project{}, fmt.Errorf("%s has no sources", path)
+	}
+	names := make(map[string]bool)
+	for i, source := range project.Sources {
+		if source.File == "" {
+			return Project{}, fmt.Errorf("source %d in %s has no file", i+1, path)
+		}
+		if source.Name == "" {
+			source.Name = source.File
+		}
+		if names[source.Name] {
+			return Project{}, fmt.Errorf("the source '%s' is listed twice in %s", source.Name, path)
+		}
+		names[source.Name] = true
+		source.File = resolve(source.File)
+		project.Sources[i] = source
+	}
+
+	for i, chapter := range project.Chapters {
+		if _, ok := project.source(chapter.Source); !ok {
+			return Project{}, fmt.Errorf("chapter %d in %s reads the unknown source '%s'", i+1, path, chapter.Source)
+		}
+		if chapter.Speed < 0 {
+			return Project{}, fmt.Errorf("the speed of chapter %d in %s must be positive", i+1, path)
+		}
+	}
+	return project, nil
+}
+
+// Find a source by its name or file
+func (p Project) source(name string) (ProjectSource, bool) {
+	for _, source := range p.Sources {
+		if source.Name == name || source.File == name {
+			return source, true
+		}
+	}
+	return ProjectSource{}, false
+}
+
+// The chapters to read; every source in order if none are listed
+func (p Project) chapterList() []ProjectChapter {
+	if len(p.Chapters) > 0 {
+		return p.Chapters
+	}
+	chapters := make([]ProjectChapter, len(p.Sources))
+	for i, source := range p.Sources {
+		chapters[i] = ProjectChapter{Source: source.Name}
+	}
+	return chapters
+}
+
+// Build the audiobook described by a project file in each of its formats,
+// reusing the audio of chapters that did not change since the last build.
+// The config supplies everything the project does not set. Returns the paths
+// of the audiobooks
+func Build(config AudiobookArgs, projectPath string) ([]string, error) {
+	project, err := LoadProject(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err = expandHomeDir(config)
+	if err != nil {
+		return nil, err
+	}
+	if project.Voice != "" {
+		config.Model = project.Voice
+		config.AutoVoice = false
+	}
+	config.OutputDirectory = project.Output
+	if err := os.MkdirAll(config.OutputDirectory, 0755); err != nil {
+		return nil, err
+	}
+	config.LexiconFiles = append(project.Lexicon, config.LexiconFiles...)
+	// the text of every source is prepared for reading the same way as when converting it on its own
+	config.filters, err = filters.New(config.FilterPresets, config.DropLines, config.Replacements)
+	if err != nil {
+		return nil, err
+	}
+	// a sidecar lexicon next to the project file applies to every source
+	config.lexicon, err = LoadLexicon(projectPath, config.LexiconFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, source := range project.Sources {
+		sourceConfig := config
+		sourceConfig.FileName = source.File
+		if err := downloadBook(&sourceConfig); err != nil {
+			return nil, err
+		}
+		// chapters still find the source by its name, which defaults to the url
+		project.Sources[i].File = sourceConfig.FileName
+	}
+
+	var chapters []chapterText
+	bookLanguage := ""
+	for i, entry := range project.chapterList() {
+		source, _ := project.source(entry.Source)
+
+		sourceConfig := config
+		sourceConfig.FileName = source.File
+		sourceConfig.Sections = entry.Sections
+		if err := sanityCheckConfig(&sourceConfig); err != nil {
+			return nil, err
+		}
+		entryChapters, language, err := bookChapters(sourceConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read chapter %d from %s: %v", i+1, source.File, err)
+		}
+		if entry.Title != "" && len(entryChapters) != 1 {
+			return nil, fmt.Errorf("chapter %d from %s has the title '%s' but is %d chapters; give each section its own entry to title it",
+				i+1, source.File, entry.Title, len(entryChapters))
+		}
+
+		for _, chapter := range entryChapters {
+			if entry.Title != "" {
+				chapter.title = entry.Title
+			}
+			for _, override := range []string{source.Language, entry.Language} {
+				if override != "" {
+					chapter.language = override
+				}
+			}
+			chapter.model = entry.Voice
+			chapter.speed = project.Speed
+			if entry.Speed > 0 {
+				chapter.speed = entry.Speed
+			}
+			chapters = append(chapters, chapter)
+		}
+		if bookLanguage == "" {
+			bookLanguage = language
+		}
+	}
+
+	tempDir, err := os.MkdirTemp("", "piper-build-dir-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tempDir)
+
+	voices := newVoiceSelector(config)
+	sections, err := synthesizeChapters(voices, config, chapters, bookLanguage, config.audioCache(), tempDir)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{"title": project.Title, "album": project.Title, "genre": "Audiobook"}
+	for key, value := range project.Tags {
+		tags[strings.ToLower(key)] = value
+	}
+
+	var outputs []string
+	for _, format := range project.Formats {
+		outputName := filepath.Join(config.OutputDirectory, project.Title+"."+format)
+		if format == "wav" {
+			files := make([]string, len(sections))
+			for i, section := range sections {
+				files[i] = section.Mp3File
+			}
+			err = ffmpeg.JoinAudio(files, outputName)
+		} else {
+			err = ffmpeg.ConcatWithChapters(sections, tags, outputName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		log.Infof("Audiobook created at: %s", outputName)
+		outputs = append(outputs, outputName)
+	}
+	config.pruneCache()
+
+	return outputs, nil
+}
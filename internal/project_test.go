@@ -0,0 +1,83 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeProject(t *testing.T, content string) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ProjectFileName)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadProject(t *testing.T) {
+	path := writeProject(t, `
+title: Dubliners
+voice: en_US-hfc_male-medium
+speed: 1.1
+lexicon: [names.yaml]
+output: build
+tags:
+  artist: James Joyce
+sources:
+  - name: book
+    file: dubliners.epub
+  - file: afterword.md
+chapters:
+  - source: book
+    sections: ["3-5"]
+  - source: afterword.md
+    title: Afterword
+    speed: 0.9
+`)
+	dir := filepath.Dir(path)
+
+	project, err := LoadProject(path)
+	require.NoError(t, err)
+	require.Equal(t, "Dubliners", project.Title)
+	require.Equal(t, filepath.Join(dir, "build"), project.Output)
+	require.Equal(t, []string{filepath.Join(dir, "names.yaml")}, project.Lexicon)
+	require.Equal(t, []string{"mp3"}, project.Formats)
+	require.Equal(t, filepath.Join(dir, "dubliners.epub"), project.Sources[0].File)
+	require.Equal(t, "afterword.md", project.Sources[1].Name)
+
+	source, ok := project.source("afterword.md")
+	require.True(t, ok)
+	require.Equal(t, filepath.Join(dir, "afterword.md"), source.File)
+	require.Len(t, project.chapterList(), 2)
+}
+
+func TestLoadProjectDefaults(t *testing.T) {
+	path := writeProject(t, "sources:\n  - file: one.txt\n  - file: two.txt\n")
+
+	project, err := LoadProject(path)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Base(filepath.Dir(path)), project.Title)
+	require.Equal(t, filepath.Dir(path), project.Output)
+	require.Equal(t, []ProjectChapter{{Source: "one.txt"}, {Source: "two.txt"}}, project.chapterList())
+}
+
+func TestLoadProjectErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"no sources":     "title: Empty\n",
+		"unknown format": "formats: [ogg]\nsources:\n  - file: one.txt\n",
+		"unknown field":  "sauces:\n  - file: one.txt\n",
+		"unknown source": "sources:\n  - file: one.txt\nchapters:\n  - source: two.txt\n",
+		"duplicate":      "sources:\n  - file: one.txt\n  - file: one.txt\n",
+		"negative speed": "speed: -1\nsources:\n  - file: one.txt\n",
+		"title path":     "title: ../book\nsources:\n  - file: one.txt\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadProject(writeProject(t, content))
+			require.Error(t, err)
+		})
+	}
+}
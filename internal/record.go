@@ -0,0 +1,188 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/library"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+
+	log "github.com/charmbracelet/log"
+)
+
+// Save what is known about a new audiobook in its output directory so that feeds and
+// catalogs can describe it, and update the feed and catalogs of the directory if it has them.
+// Nothing is saved unless a feed, catalog or server asked for it. The audiobook is already
+// written so failing to do so only logs a warning
+func recordAudiobook(config AudiobookArgs, outputName string) {
+	if !config.Feed && !config.OPDS && !config.Record {
+		return
+	}
+	entry, cover, err := describeAudiobook(config, outputName)
+	if err != nil {
+		log.Warnf("Could not read the metadata of %s: %v", outputName, err)
+		return
+	}
+	if err := library.Save(config.OutputDirectory, entry, cover); err != nil {
+		log.Warnf("Could not save the metadata of %s: %v", outputName, err)
+		return
+	}
+
+	if config.Feed {
+		if err := library.WriteFeed(config.OutputDirectory, config.FeedURL); err != nil {
+			log.Warnf("Could not update the podcast feed in %s: %v", config.OutputDirectory, err)
+			return
+		}
+		log.Infof("Updated the podcast feed at %s", filepath.Join(config.OutputDirectory, library.FeedName))
+	}
+	if config.OPDS {
+		if err := library.WriteCatalog(config.OutputDirectory, config.FeedURL); err != nil {
+			log.Warnf("Could not update the OPDS catalog in %s: %v", config.OutputDirectory, err)
+			return
+		}
+		log.Infof("Updated the OPDS catalog at %s", filepath.Join(config.OutputDirectory, library.CatalogName))
+	}
+}
+
+// Return the metadata of an audiobook from the book it was made from and the audio itself,
+// along with the book's cover or nil if it has none
+func describeAudiobook(config AudiobookArgs, outputName string) (library.Entry, io.Reader, error) {
+	info, err := os.Stat(outputName)
+	if err != nil {
+		return library.Entry{}, nil, err
+	}
+	file := filepath.Base(outputName)
+	entry := library.Entry{
+		File:      file,
+		Title:     strings.TrimSuffix(file, filepath.Ext(file)),
+		MediaType: library.MediaType(file),
+		Size:      info.Size(),
+		Source:    filepath.Base(config.FileName),
+		Created:   time.Now(),
+	}
+
+	var cover io.Reader
+	switch {
+	case filepath.Ext(config.FileName) == ".epub":
+		cover = describeEpub(config.FileName, &entry)
+	case hasNativeReader(config):
+		if document, err := readDocument(config); err == nil {
+			entry.Title = firstNonEmpty(document.Title, entry.Title)
+			entry.Author = document.Author
+			entry.Language = document.Language
+		}
+	}
+
+	if audio, err := ffmpeg.Probe(outputName); err == nil {
+		entry.Duration = audio.Duration
+		for _, chapter := range audio.Chapters {
+			entry.Chapters = append(entry.Chapters, library.Chapter{Title: chapter.Title, Start: chapter.Start})
+		}
+	} else if filepath.Ext(outputName) == ".wav" {
+		// wavs can be written without ffmpeg so their length is read from their header
+		if entry.Duration, err = wavDuration(outputName); err != nil {
+			log.Debugf("Could not read the length of %s: %v", outputName, err)
+		}
+	} else {
+		log.Debugf("Could not read the length of %s: %v", outputName, err)
+	}
+	return entry, cover, nil
+}
+
+// Fill in an entry from the metadata of an epub and return its cover or nil if it has none
+func describeEpub(filename string, entry *library.Entry) io.Reader {
+	book, err := epub.Open(filename)
+	if err != nil {
+		log.Debugf("Could not read the metadata of %s: %v", filename, err)
+		return nil
+	}
+	defer book.Close()
+
+	metadata := book.Opf.Metadata
+	if len(metadata.Title) > 0 {
+		entry.Title = firstNonEmpty(strings.TrimSpace(metadata.Title[0]), entry.Title)
+	}
+	for _, creator := range metadata.Creator {
+		// the first author, skipping editors and illustrators
+		if creator.Role == "" || creator.Role == "aut" {
+			entry.Author = strings.TrimSpace(creator.Data)
+			break
+		}
+	}
+	for _, language := range metadata.Language {
+		if base := lang.Base(language); base != "" {
+			entry.Language = base
+			break
+		}
+	}
+	if len(metadata.Description) > 0 {
+		entry.Description = strings.TrimSpace(metadata.Description[0])
+	}
+
+	item, err := book.CoverImage()
+	if err != nil {
+		return nil
+	}
+	image, err := book.OpenInternalBookFile(item.Href)
+	if err != nil {
+		log.Debugf("Could not read the cover of %s: %v", filename, err)
+		return nil
+	}
+	// the book is closed when this returns so the cover is read into memory
+	data, err := io.ReadAll(image)
+	image.Close()
+	if err != nil {
+		log.Debugf("Could not read the cover of %s: %v", filename, err)
+		return nil
+	}
+	entry.Cover = entry.File + ".cover" + strings.ToLower(path.Ext(item.Href))
+	return bytes.NewReader(data)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Read the length of a wav file in milliseconds from its header
+func wavDuration(name string) (int64, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	// the fields of a canonical 44 byte header, which piper writes
+	header := make([]byte, 44)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, err
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, fmt.Errorf("%s is not a wav file", name)
+	}
+	byteRate := binary.LittleEndian.Uint32(header[28:32])
+	if byteRate == 0 {
+		return 0, fmt.Errorf("%s has no byte rate", name)
+	}
+	return (info.Size() - int64(len(header))) * 1000 / int64(byteRate), nil
+}
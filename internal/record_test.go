@@ -0,0 +1,72 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/library"
+	"github.com/stretchr/testify/require"
+)
+
+// Write a wav with a canonical header and the given number of bytes of 16 bit mono audio
+func writeWav(t *testing.T, name string, sampleRate, dataSize int) {
+	header := streamingWavHeader(sampleRate)
+	require.NoError(t, os.WriteFile(name, append(header, make([]byte, dataSize)...), 0644))
+}
+
+func TestWavDuration(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "book.wav")
+	// two seconds of audio
+	writeWav(t, name, 22050, 22050*2*2)
+	duration, err := wavDuration(name)
+	require.NoError(t, err)
+	require.Equal(t, int64(2000), duration)
+}
+
+func TestRecordAudiobook(t *testing.T) {
+	outputDir := t.TempDir()
+	outputName := filepath.Join(outputDir, "titlepage_and_2_chapters.wav")
+	writeWav(t, outputName, 16000, 16000*2)
+
+	config := AudiobookArgs{FileName: "testdata/titlepage_and_2_chapters.epub", OutputDirectory: outputDir, Feed: true, OPDS: true, FeedURL: "https://example.com/audiobooks"}
+	recordAudiobook(config, outputName)
+
+	entries, err := library.Load(outputDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	entry := entries[0]
+	require.Equal(t, "titlepage_and_2_chapters.wav", entry.File)
+	require.Equal(t, "index", entry.Title)
+	require.Equal(t, "Unknown", entry.Author)
+	require.Equal(t, "en", entry.Language)
+	require.Equal(t, "audio/wav", entry.MediaType)
+	require.Equal(t, int64(1000), entry.Duration)
+	require.Equal(t, "titlepage_and_2_chapters.wav.cover.jpg", entry.Cover)
+	require.FileExists(t, filepath.Join(outputDir, library.MetadataDir, entry.Cover))
+	require.FileExists(t, filepath.Join(outputDir, library.FeedName))
+	require.FileExists(t, filepath.Join(outputDir, library.CatalogName))
+	require.FileExists(t, filepath.Join(outputDir, library.CatalogJSONName))
+}
+
+// Make sure converting a book without a feed or catalog leaves the output directory alone
+func TestRecordAudiobookOnlyWhenAsked(t *testing.T) {
+	outputDir := t.TempDir()
+	outputName := filepath.Join(outputDir, "titlepage_and_2_chapters.wav")
+	writeWav(t, outputName, 16000, 16000*2)
+
+	config := AudiobookArgs{FileName: "testdata/titlepage_and_2_chapters.epub", OutputDirectory: outputDir}
+	recordAudiobook(config, outputName)
+	require.NoDirExists(t, filepath.Join(outputDir, library.MetadataDir))
+
+	config.Record = true
+	recordAudiobook(config, outputName)
+	entries, err := library.Load(outputDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NoFileExists(t, filepath.Join(outputDir, library.FeedName))
+	require.NoFileExists(t, filepath.Join(outputDir, library.CatalogName))
+}
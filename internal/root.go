@@ -4,8 +4,9 @@
 package internal
 
 import (
+	"bufio"
 	"bytes"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -13,19 +14,25 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/filters"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lexicon"
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/library"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/normalize"
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/formats"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/pdf"
 
 	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
 
 	"github.com/briandowns/spinner"
 	log "github.com/charmbracelet/log"
 
-	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/iconv"
-
 	ebookconvert "github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ebookConvert"
 
 	"github.com/gen2brain/beeep"
@@ -49,8 +56,151 @@ type AudiobookArgs struct {
 	Chapters bool
 	// the number of threads to use when doing concurrent conversions
 	Threads int
+	// where to download piper and its models from if they are not installed
+	DownloadSources piper.DownloadSources
+	// whether to pick the model and utf-8 handling from the language of the book
+	// instead of always using Model
+	AutoVoice bool
+	// the models to use for each language when AutoVoice is set; keyed by language code i.e. "pl"
+	// languages not in this map fall back to DefaultLanguageVoices and then to Model
+	LanguageVoices map[string]string
+	// whether to switch voices for paragraphs in a different language than the rest of the book
+	// uses xml:lang attributes in epubs and language detection for other text; requires ffmpeg
+	Multilingual bool
+	// whether to spell out numbers, dates, currency and abbreviations before they are read
+	Normalize bool
+	// the names of normalization rules to turn off i.e. "years" or "cardinals"
+	NormalizeSkip []string
+	// built in filter presets to strip boilerplate with, see filters.Presets
+	FilterPresets []string
+	// regexes for lines to drop from the text
+	DropLines []string
+	// find and replace rules written as "regex=>replacement"
+	Replacements []string
+	// the epub sections to read instead of the whole book, by number ("3"), range ("5-7"),
+	// file in the book or part of the title; when set, Skip is ignored
+	Sections []string
+	// the kinds of epub sections to leave out i.e. "cover" or "toc",
+	// see epub.SectionKinds; "nonlinear" leaves out sections that are not in the main reading order
+	Skip []string
+	// whether to convert epubs with ebook-convert instead of the built in XHTML extractor
+	EbookConvert bool
+	// whether to remove repeating headers and footers and rejoin hard wrapped lines in text from PDFs
+	PdfCleanup bool
+	// extra lexicon files that take priority over the book's sidecar lexicon and the global one
+	LexiconFiles []string
+	// the directory to cache the audio of each chapter and the text converted from each file in,
+	// so unchanged chapters are not converted and read again; empty to turn caching off
+	CacheDir string
+	// the most bytes the cache may take up; the least recently used entries are removed
+	// after a conversion to stay under it. 0 for no limit
+	CacheSize int64
+	// the format of the book when it is read from standard input i.e. "epub"; defaults to DefaultInputFormat
+	InputFormat string
+	// whether to keep a podcast feed of the audiobooks in the output directory up to date in its feed.xml
+	Feed bool
+	// whether to keep OPDS catalogs of the audiobooks in the output directory up to date
+	// in its catalog.xml (OPDS 1.2) and catalog.json (OPDS 2.0)
+	OPDS bool
+	// whether to save the metadata of the audiobook in the output directory without writing a feed
+	// or catalogs, for a server that lists the audiobooks itself. Feed and OPDS save it too
+	Record bool
+	// the url the output directory is hosted at, which the files in the feed and catalogs are linked from;
+	// the links are relative to them if it is empty
+	FeedURL string
+	// whether to leave out the spinner and the desktop notification when the audiobook is done,
+	// for books that are converted in the background
+	Quiet bool
+	// called with how many of the chapters of a book have been read and how many there are;
+	// for books that are not split into chapters, with the percent of their text that was read out of 100
+	Progress func(done, total int)
+	// stops the conversion when it is done, killing piper and ffmpeg; nil to never stop it
+	Context context.Context
+
+	// the filters and pronunciation lexicon built from the options above when the conversion starts
+	filters filters.Filters
+	lexicon lexicon.Lexicon
+	// where to write the audiobook when OutputDirectory is StdioPath
+	stdout io.Writer
+	// the piper clients shared by the books of a batch; nil when converting a single book
+	pool *piperPool
+}
+
+// Report the progress of a conversion if anything is listening for it
+func (config AudiobookArgs) reportProgress(done, total int) {
+	if config.Progress != nil {
+		config.Progress(done, total)
+	}
+}
+
+// Counts how much of a book that is not split into chapters piper has read
+// and reports it as progress, once for each percent
+type progressReader struct {
+	reader      io.Reader
+	read, total int64
+	percent     int64
+	report      func(done, total int)
+}
+
+func (p *progressReader) Read(buffer []byte) (int, error) {
+	n, err := p.reader.Read(buffer)
+	p.read += int64(n)
+	if percent := min(p.read*100/p.total, 100); percent > p.percent {
+		p.percent = percent
+		p.report(int(percent), 100)
+	}
+	return n, err
+}
+
+// Report the progress of a book as its text is read if anything is listening for it.
+// total is the size of the text in bytes, or 0 if it is not known
+func (config AudiobookArgs) trackProgress(input io.Reader, total int64) io.Reader {
+	if config.Progress == nil || total <= 0 {
+		return input
+	}
+	return &progressReader{reader: input, total: total, report: config.Progress}
+}
+
+// The number of bytes left in text that is in memory or in a file; 0 if it is not known
+func textSize(input io.Reader) int64 {
+	switch r := input.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case interface{ Stat() (os.FileInfo, error) }:
+		if info, err := r.Stat(); err == nil {
+			return info.Size()
+		}
+	}
+	return 0
+}
+
+// The context the conversion runs in
+func (config AudiobookArgs) ctx() context.Context {
+	if config.Context == nil {
+		return context.Background()
+	}
+	return config.Context
 }
 
+// Return the cache of the audio of each chapter
+func (config AudiobookArgs) audioCache() audioCache {
+	if config.CacheDir == "" {
+		return audioCache{}
+	}
+	return audioCache{dir: filepath.Join(config.CacheDir, audioCacheDir)}
+}
+
+// Return the directory the text converted by ebook-convert is cached in, or an empty string if caching is off
+func (config AudiobookArgs) textCacheDir() string {
+	if config.CacheDir == "" {
+		return ""
+	}
+	return filepath.Join(config.CacheDir, textCacheDir)
+}
+
+// the number of bytes at the start of a text used to detect its language
+const languageSampleSize = 4096
+
 // make sure the config is not obviously invalid before we try to use it
 func sanityCheckConfig(config *AudiobookArgs) error {
 	if config.FileName == "" {
@@ -69,13 +219,41 @@ func sanityCheckConfig(config *AudiobookArgs) error {
 		return fmt.Errorf("the output directory %s does not exist", config.OutputDirectory)
 	}
 
-	if config.Chapters && filepath.Ext(config.FileName) != ".epub" {
+	if len(config.Sections) > 0 {
+		if filepath.Ext(config.FileName) != ".epub" {
+			log.Warnf("Only sections of epub files can be selected. Reading all of %s", config.FileName)
+		} else if !config.Chapters {
+			log.Info("Selecting sections requires splitting the book by chapter; generating an mp3 with chapters")
+			config.Chapters = true
+		}
+	}
+
+	if config.Chapters && filepath.Ext(config.FileName) != ".epub" && !hasNativeReader(*config) {
 		// This is a warning and not an error since we want someone to be able to set chapters = true in the config
 		// to use chapters by default for any arbitrary text content and just fall back if it isnt supported
-		log.Warnf("Currently only epub files can be split into chapters. Ignoring chapter splitting for %s", config.FileName)
+		log.Warnf("Only epub files and formats with a built in reader (%s) can be split into chapters. Ignoring chapter splitting for %s",
+			strings.Join(formats.Extensions(), ", "), config.FileName)
 		config.Chapters = false
 	}
 
+	if config.Normalize {
+		if _, err := normalize.New("", config.NormalizeSkip); err != nil {
+			return err
+		}
+	}
+
+	if config.Feed {
+		if err := library.CheckFeedURL(config.FeedURL); err != nil {
+			return err
+		}
+	}
+
+	for _, kind := range config.Skip {
+		if !isSkippable(kind) {
+			return fmt.Errorf("unknown section kind '%s' to skip; expected one of %s", kind, strings.Join(skippableKinds(), ", "))
+		}
+	}
+
 	if config.Threads > runtime.NumCPU() {
 		log.Warnf("%d threads is likely too high for your system; try setting it to a value below %d otherwise may get unexpected I/O errors", config.Threads, runtime.NumCPU())
 	}
@@ -85,17 +263,31 @@ func sanityCheckConfig(config *AudiobookArgs) error {
 
 // Run the conversion process with chaptered output
 // returns the name of the audiobook
-func processChapters(piper piper.PiperClient, config AudiobookArgs) (string, error) {
+func processChapters(voices *voiceSelector, config AudiobookArgs) (string, error) {
 	splitter, err := epub.NewEpubSplitter(config.FileName)
 	if err != nil {
 		return "", err
 	}
 	defer splitter.Close()
 
+	language := ""
+	if config.AutoVoice || config.Multilingual {
+		language = epubLanguage(config.FileName)
+		log.Infof("Book language from metadata: '%s'", language)
+	}
+	model, speakUTF8 := voices.voiceFor(language, language)
+	piper, err := voices.clientForModel(model)
+	if err != nil {
+		return "", err
+	}
+
 	sections, err := splitter.SplitBySection()
 	if err != nil {
 		return "", err
 	}
+	if sections, err = pickSections(sections, config); err != nil {
+		return "", err
+	}
 
 	errorGroup := errgroup.Group{}
 
@@ -107,6 +299,7 @@ func processChapters(piper piper.PiperClient, config AudiobookArgs) (string, err
 
 	var mu sync.Mutex
 	mp3InOrder := make([]ffmpeg.Mp3Section, len(sections))
+	var finished atomic.Int32
 
 	tempDir, err := os.MkdirTemp("", "piper-ffmpeg-dir-*")
 	if err != nil {
@@ -119,64 +312,91 @@ func processChapters(piper piper.PiperClient, config AudiobookArgs) (string, err
 
 		errorGroup.Go(func() error {
 			section.Filename = strings.ReplaceAll(section.Filename, "/", "_")
+			sectionPiper, sectionModel, sectionSpeakUTF8, sectionLanguage := piper, model, speakUTF8, language
 
-			convertedReader, err := ebookconvert.ConvertToText(
-				section.Text, filepath.Ext(section.Filename),
+			tmpMP3 := filepath.Join(
+				tempDir,
+				fmt.Sprintf("%04d-section-piper-output-%s.mp3", i, section.Filename),
 			)
-			if err != nil {
-				var emptyErr *ebookconvert.EmptyConversionResultError
-				if errors.As(err, &emptyErr) {
-					log.Warnf("Internal file %s was empty when converting and will be skipped. This is expected if it contains just images or no text",
-						section.Filename)
-					return nil
-				}
-				return err
-			}
 
-			if !config.SpeakUTF8 {
-				reader, err := iconv.RemoveDiacritics(convertedReader)
+			if config.Multilingual {
+				xhtml, err := io.ReadAll(section.Text)
+				if err != nil {
+					return err
+				}
+				segments, err := epubSegments(bytes.NewReader(xhtml), language)
 				if err != nil {
 					return err
 				}
-				convertedReader = reader
+
+				if isMultilingual(segments) {
+					if err := synthesizeSegments(voices, segments, language, section.Filename, tempDir, tmpMP3, nil); err != nil {
+						return err
+					}
+					log.Debugf("Converted multilingual section %d to %s", i, tmpMP3)
+
+					mu.Lock()
+					mp3InOrder[i] = ffmpeg.Mp3Section{
+						Mp3File: tmpMP3,
+						Title:   sectionTitle(segments[0].Text),
+					}
+					mu.Unlock()
+					config.reportProgress(int(finished.Add(1)), len(sections))
+					return nil
+				}
+
+				// a section in a single language may still be in a different language than the book
+				if len(segments) == 1 {
+					sectionModel, sectionSpeakUTF8 = voices.voiceFor(segments[0].Language, language)
+					if sectionPiper, err = voices.clientForModel(sectionModel); err != nil {
+						return err
+					}
+					sectionLanguage = segments[0].Language
+				}
+				section.Text = bytes.NewReader(xhtml)
 			}
 
-			// we use a tee reader here so that we can get the title of the audiobook
-			// while still being able to pass the full text to piper
-			buf := new(bytes.Buffer)
-			teeReader := io.TeeReader(convertedReader, buf)
-			// 20 is an arbitrary number of bytes to read to get the title
-			// the goal is not to have a perfect title but to have something
-			// that is reasonably identifiable
-			first20 := make([]byte, 20)
-			_, err = io.ReadFull(teeReader, first20)
-			if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			sectionContent, err := sectionText(section, config)
+			if err != nil {
 				return err
 			}
+			if strings.TrimSpace(sectionContent) == "" {
+				log.Warnf("Internal file %s was empty when converting and will be skipped. This is expected if it contains just images or no text",
+					section.Filename)
+				return nil
+			}
 
-			title := strings.TrimSpace(string(first20))
-
-			streamOutput, _, err := piper.Run(section.Filename, io.MultiReader(buf, convertedReader), config.OutputDirectory, true)
+			convertedReader, err := prepareText(strings.NewReader(sectionContent), config, sectionLanguage, sectionModel, sectionSpeakUTF8)
+			if err != nil {
+				return err
+			}
+			defer convertedReader.Close()
+			// the whole text is hashed to find its audio in the cache
+			text, err := io.ReadAll(convertedReader)
 			if err != nil {
 				return err
 			}
 
-			tmpMP3 := filepath.Join(
-				tempDir,
-				fmt.Sprintf("%04d-section-piper-output-%s.mp3", i, section.Filename),
-			)
-			err = ffmpeg.OutputToMp3(streamOutput.Stdout, tmpMP3)
+			// 20 is an arbitrary number of bytes to read to get the title
+			// the goal is not to have a perfect title but to have something
+			// that is reasonably identifiable
+			title := strings.TrimSpace(string(text[:min(len(text), 20)]))
+
+			release := voices.acquire()
+			mp3, err := synthesizeCached(config.ctx(), config.audioCache(), sectionPiper, 0, text, tmpMP3)
+			release()
 			if err != nil {
 				return err
 			}
-			log.Debugf("Converted section %d to %s", i, tmpMP3)
+			log.Debugf("Converted section %d to %s", i, mp3)
 
 			mu.Lock()
 			mp3InOrder[i] = ffmpeg.Mp3Section{
-				Mp3File: tmpMP3,
+				Mp3File: mp3,
 				Title:   title,
 			}
 			mu.Unlock()
+			config.reportProgress(int(finished.Add(1)), len(sections))
 
 			return nil
 		})
@@ -193,10 +413,7 @@ func processChapters(piper piper.PiperClient, config AudiobookArgs) (string, err
 		}
 	}
 
-	outputName := filepath.Join(
-		config.OutputDirectory,
-		strings.TrimSuffix(filepath.Base(config.FileName), filepath.Ext(config.FileName))+".mp3",
-	)
+	outputName := filepath.Join(config.OutputDirectory, outputBaseName(config)+".mp3")
 	log.Debugf("Concatenating %d MP3s", len(filteredMp3s))
 	err = ffmpeg.ConcatMp3s(filteredMp3s, outputName)
 	if err != nil {
@@ -206,25 +423,257 @@ func processChapters(piper piper.PiperClient, config AudiobookArgs) (string, err
 	return outputName, nil
 }
 
+// Download the book to the output directory if it is a url so it is read like a local file
+func downloadBook(config *AudiobookArgs) error {
+	if !lib.IsUrl(config.FileName) {
+		return nil
+	}
+	fileNameInUrl := config.FileName[strings.LastIndex(config.FileName, "/")+1:]
+	downloadedFile, err := lib.DownloadFileContext(config.ctx(), config.FileName, fileNameInUrl, config.OutputDirectory)
+	if err != nil {
+		return err
+	}
+	config.FileName = downloadedFile.Name()
+	return nil
+}
+
+// Convert a book to plain text chapters and return them along with the book's language.
+// Books with a built in reader are split at the headings it found, epubs into their
+// sections and other books are a single chapter. The text is not prepared for reading yet
+func bookChapters(config AudiobookArgs) ([]chapterText, string, error) {
+	if hasNativeReader(config) {
+		return documentChapters(config)
+	}
+
+	if filepath.Ext(config.FileName) != ".epub" {
+		rawFile, err := os.Open(config.FileName)
+		if err != nil {
+			return nil, "", err
+		}
+		defer rawFile.Close()
+
+		converted, err := ebookconvert.ConvertToText(rawFile, filepath.Ext(config.FileName), config.textCacheDir())
+		if err != nil {
+			return nil, "", err
+		}
+		defer converted.Close()
+
+		var convertedReader io.Reader = converted
+		if config.PdfCleanup && strings.EqualFold(filepath.Ext(config.FileName), ".pdf") {
+			if convertedReader, err = pdf.Reader(converted); err != nil {
+				return nil, "", err
+			}
+		}
+		text, err := io.ReadAll(convertedReader)
+		if err != nil {
+			return nil, "", err
+		}
+
+		language := lang.Detect(string(text[:min(len(text), languageSampleSize)]))
+		return []chapterText{{title: sectionTitle(string(text)), text: string(text), language: language}}, language, nil
+	}
+
+	language := epubLanguage(config.FileName)
+	splitter, err := epub.NewEpubSplitter(config.FileName)
+	if err != nil {
+		return nil, "", err
+	}
+	defer splitter.Close()
+
+	sections, err := splitter.SplitBySection()
+	if err != nil {
+		return nil, "", err
+	}
+	if sections, err = pickSections(sections, config); err != nil {
+		return nil, "", err
+	}
+
+	errorGroup := errgroup.Group{}
+	if config.Threads > 0 {
+		errorGroup.SetLimit(config.Threads)
+	}
+	chapters := make([]chapterText, len(sections))
+
+	for i, section := range sections {
+		i, section := i, section
+		errorGroup.Go(func() error {
+			text, err := sectionText(section, config)
+			if err != nil {
+				return err
+			}
+			text = strings.TrimSpace(text)
+			if text == "" {
+				log.Warnf("Internal file %s was empty when converting and will be skipped", section.Filename)
+				return nil
+			}
+
+			title := section.Title
+			if title == "" {
+				title = sectionTitle(text)
+			}
+			chapters[i] = chapterText{title: title, text: text, language: language}
+			return nil
+		})
+	}
+	if err := errorGroup.Wait(); err != nil {
+		return nil, "", err
+	}
+
+	var kept []chapterText
+	for _, chapter := range chapters {
+		if chapter.text != "" {
+			kept = append(kept, chapter)
+		}
+	}
+	return kept, language, nil
+}
+
+// Run the conversion process with chaptered output for a book with a built in
+// reader, splitting it at the headings the reader found
+// returns the name of the audiobook
+func processDocumentChapters(voices *voiceSelector, config AudiobookArgs) (string, error) {
+	chapters, language, err := bookChapters(config)
+	if err != nil {
+		return "", err
+	}
+	if config.AutoVoice || config.Multilingual {
+		log.Infof("Book language: '%s'", language)
+	}
+
+	tempDir, err := os.MkdirTemp("", "piper-ffmpeg-dir-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tempDir)
+
+	sections, err := synthesizeChapters(voices, config, chapters, language, config.audioCache(), tempDir)
+	if err != nil {
+		return "", err
+	}
+
+	baseName := strings.TrimSuffix(filepath.Base(config.FileName), filepath.Ext(config.FileName))
+	outputName := filepath.Join(config.OutputDirectory, baseName+".mp3")
+	log.Debugf("Concatenating %d MP3s", len(sections))
+	if err := ffmpeg.ConcatMp3s(sections, outputName); err != nil {
+		return "", err
+	}
+	return outputName, nil
+}
+
+// The name of a book's audiobook without its extension
+func outputBaseName(config AudiobookArgs) string {
+	baseName := strings.TrimSuffix(filepath.Base(config.FileName), filepath.Ext(config.FileName))
+	if len(config.Sections) > 0 && filepath.Ext(config.FileName) == ".epub" {
+		// don't overwrite the audiobook of the whole book with a few sections of it
+		baseName += " (sections " + strings.ReplaceAll(strings.Join(config.Sections, ", "), "/", "_") + ")"
+	}
+	return baseName
+}
+
 // process a book without splitting it into chapters
 // returns the filename of the created audiobook
-func processWithoutChapters(piper piper.PiperClient, config AudiobookArgs) (string, error) {
-	rawFile, err := os.Open(config.FileName)
+func processWithoutChapters(voices *voiceSelector, config AudiobookArgs) (string, error) {
+	var convertedReader io.Reader
+	var err error
+	// the language a book read with a built in reader declares
+	declaredLanguage := ""
+	if hasNativeReader(config) {
+		document, err := readDocument(config)
+		if err != nil {
+			return "", err
+		}
+		text := document.Text()
+		if strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("no text was found in %s", config.FileName)
+		}
+		declaredLanguage = document.Language
+		convertedReader = strings.NewReader(text)
+	} else if filepath.Ext(config.FileName) == ".epub" && !config.EbookConvert {
+		text, err := epubText(config)
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(text) == "" {
+			return "", fmt.Errorf("no text was found in %s", config.FileName)
+		}
+		convertedReader = strings.NewReader(text)
+	} else {
+		rawFile, err := os.Open(config.FileName)
+		if err != nil {
+			return "", err
+		}
+		defer rawFile.Close()
+
+		converted, err := ebookconvert.ConvertToText(rawFile, filepath.Ext(config.FileName), config.textCacheDir())
+		if err != nil {
+			return "", err
+		}
+		defer converted.Close()
+		convertedReader = converted
+	}
+
+	if config.PdfCleanup && strings.EqualFold(filepath.Ext(config.FileName), ".pdf") {
+		if convertedReader, err = pdf.Reader(convertedReader); err != nil {
+			return "", err
+		}
+	}
+	// measured before the text is wrapped in readers that hide its size
+	size := textSize(convertedReader)
+
+	language := ""
+	if config.AutoVoice || config.Multilingual {
+		if filepath.Ext(config.FileName) == ".epub" {
+			language = epubLanguage(config.FileName)
+		} else {
+			language = declaredLanguage
+		}
+		if language == "" {
+			// detect the language from the start of the text without consuming it
+			buffered := bufio.NewReaderSize(convertedReader, languageSampleSize)
+			sample, _ := buffered.Peek(languageSampleSize)
+			language = lang.Detect(string(sample))
+			convertedReader = buffered
+		}
+		log.Infof("Detected book language: '%s'", language)
+	}
+
+	fileBase := filepath.Base(config.FileName)
+	fileNameWithoutExt := strings.TrimSuffix(fileBase, filepath.Ext(fileBase))
+
+	if config.Multilingual {
+		text, err := io.ReadAll(convertedReader)
+		if err != nil {
+			return "", err
+		}
+		segments := lang.SplitByLanguage(string(text), language)
+		if isMultilingual(segments) {
+			return processSegments(voices, segments, language, config)
+		}
+		convertedReader = bytes.NewReader(text)
+	}
+
+	model, speakUTF8 := voices.voiceFor(language, language)
+	piper, err := voices.clientForModel(model)
 	if err != nil {
 		return "", err
 	}
 
-	convertedReader, err := ebookconvert.ConvertToText(rawFile, filepath.Ext(config.FileName))
+	// piper reads the text as it is prepared so how much of it was prepared is how far along piper is
+	prepared, err := prepareText(config.trackProgress(convertedReader, size), config, language, model, speakUTF8)
 	if err != nil {
 		return "", err
 	}
+	defer prepared.Close()
+	convertedReader = prepared
 
-	if !config.SpeakUTF8 {
-		reader, err := iconv.RemoveDiacritics(convertedReader)
-		if err != nil {
+	release := voices.acquire()
+	defer release()
+
+	if config.stdout != nil {
+		if err := streamAudio(piper, convertedReader, config, config.stdout); err != nil {
 			return "", err
 		}
-		convertedReader = reader
+		return StdioPath, nil
 	}
 
 	streamOutput, piperOutputFilename, err := piper.Run(config.FileName, convertedReader, config.OutputDirectory, config.OutputAsMp3)
@@ -234,11 +683,9 @@ func processWithoutChapters(piper piper.PiperClient, config AudiobookArgs) (stri
 
 	var outputName string
 	if config.OutputAsMp3 {
-		fileBase := filepath.Base(config.FileName)
-		fileNameWithoutExt := strings.TrimSuffix(fileBase, filepath.Ext(fileBase))
 		outputName = filepath.Join(config.OutputDirectory, fileNameWithoutExt) + ".mp3"
 
-		err = ffmpeg.OutputToMp3(streamOutput.Stdout, outputName)
+		err = ffmpeg.OutputToMp3(config.ctx(), streamOutput.Stdout, piper.SampleRate(), outputName)
 		if err != nil {
 			return "", err
 		}
@@ -250,6 +697,30 @@ func processWithoutChapters(piper piper.PiperClient, config AudiobookArgs) (stri
 
 }
 
+// process a book that switches between languages by reading each
+// segment with its own voice and joining the audio in order
+// returns the filename of the created audiobook
+func processSegments(voices *voiceSelector, segments []lang.Segment, bookLanguage string, config AudiobookArgs) (string, error) {
+	tempDir, err := os.MkdirTemp("", "piper-segments-dir-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tempDir)
+
+	ext := ".wav"
+	if config.OutputAsMp3 {
+		ext = ".mp3"
+	}
+	fileBase := filepath.Base(config.FileName)
+	outputName := filepath.Join(config.OutputDirectory, strings.TrimSuffix(fileBase, filepath.Ext(fileBase))+ext)
+
+	log.Infof("Reading %d segments in different languages", len(segments))
+	if err := synthesizeSegments(voices, segments, bookLanguage, config.FileName, tempDir, outputName, config.reportProgress); err != nil {
+		return "", err
+	}
+	return outputName, nil
+}
+
 // Run the core audiobook creation process. Does not include any CLI parsing. Returns the filepath of the created audiobook.
 func QuickPiperAudiobook(config AudiobookArgs) (string, error) {
 
@@ -260,44 +731,100 @@ func QuickPiperAudiobook(config AudiobookArgs) (string, error) {
 		return "", err
 	}
 
+	if config.FileName == StdioPath {
+		fileName, removeStdin, err := saveStdin(os.Stdin, config.InputFormat)
+		if err != nil {
+			return "", err
+		}
+		defer removeStdin()
+		config.FileName = fileName
+	}
+
+	if config.OutputDirectory == StdioPath {
+		// audiobooks that can't be streamed are written to a temporary directory and then copied
+		tempDir, err := os.MkdirTemp("", "piper-stdout-*")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(tempDir)
+		config.OutputDirectory = tempDir
+		config.stdout = os.Stdout
+	}
+
 	if err := sanityCheckConfig(&config); err != nil {
 		return "", err
 	}
 
 	log.Debugf("Got config after checking and expanding: %+v", config)
 
-	if lib.IsUrl(config.FileName) {
-		fileNameInUrl := config.FileName[strings.LastIndex(config.FileName, "/")+1:]
-		downloadedFile, err := lib.DownloadFile(config.FileName, fileNameInUrl, config.OutputDirectory)
-		if err != nil {
-			return "", err
-		}
-		config.FileName = downloadedFile.Name()
+	if err := downloadBook(&config); err != nil {
+		return "", err
 	}
 
-	piper, err := piper.NewPiperClient(config.Model)
+	config.filters, err = filters.New(config.FilterPresets, config.DropLines, config.Replacements)
 	if err != nil {
 		return "", err
 	}
 
+	config.lexicon, err = LoadLexicon(config.FileName, config.LexiconFiles)
+	if err != nil {
+		return "", err
+	}
+
+	voices := newVoiceSelector(config)
+	if !config.AutoVoice {
+		// set up the model before converting so that a bad model fails fast
+		if _, err := voices.clientFor(""); err != nil {
+			return "", err
+		}
+	}
+
 	var outputName string
 	log.Info("Converting files and generating audiobook. This may take a while...")
 	s := spinner.New(spinner.CharSets[9], 100*time.Millisecond)
-	s.Start()
-	if config.Chapters {
-		outputName, err = processChapters(*piper, config)
+	if config.stdout != nil {
+		// keep the spinner out of the audio
+		s.Writer = os.Stderr
+	}
+	if !config.Quiet {
+		s.Start()
+	}
+	if config.Chapters && filepath.Ext(config.FileName) != ".epub" {
+		outputName, err = processDocumentChapters(voices, config)
+		if err != nil {
+			return "", err
+		}
+	} else if config.Chapters {
+		outputName, err = processChapters(voices, config)
 		if err != nil {
 			return "", err
 		}
 	} else {
-		outputName, err = processWithoutChapters(*piper, config)
+		outputName, err = processWithoutChapters(voices, config)
 		if err != nil {
 			return "", err
 		}
 	}
 	s.Stop()
+	config.pruneCache()
+
+	if config.stdout != nil {
+		if outputName != StdioPath {
+			if err := copyFile(outputName, config.stdout); err != nil {
+				return "", err
+			}
+			outputName = StdioPath
+		}
+		log.Info("Audiobook written to standard output")
+		return outputName, nil
+	}
 
 	log.Infof("Audiobook created at: %s", outputName)
+	recordAudiobook(config, outputName)
+	config.reportProgress(1, 1)
+	if config.Quiet {
+		return outputName, nil
+	}
 
 	err = beeep.Alert("Audiobook created at "+outputName, "Check the terminal for more info", "")
 	if err != nil {
@@ -4,6 +4,7 @@
 package internal
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -261,3 +262,28 @@ func TestQuickPiperAudiobookWithUTF8(t *testing.T) {
 
 	})
 }
+
+// Make sure books that are not split into chapters report progress as their text is read
+func TestTrackProgress(t *testing.T) {
+	var reported []int
+	config := AudiobookArgs{Progress: func(done, total int) {
+		require.Equal(t, 100, total)
+		reported = append(reported, done)
+	}}
+
+	text := strings.Repeat("a", 1000)
+	input := strings.NewReader(text)
+	tracked := config.trackProgress(input, textSize(input))
+	buffer := make([]byte, 250)
+	for {
+		if _, err := tracked.Read(buffer); err != nil {
+			break
+		}
+	}
+	require.Equal(t, []int{25, 50, 75, 100}, reported)
+
+	// the progress of text of unknown size is not tracked
+	unknown := strings.NewReader(text)
+	require.Same(t, unknown, config.trackProgress(unknown, 0))
+	require.Equal(t, int64(0), textSize(io.MultiReader(unknown)))
+}
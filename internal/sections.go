@@ -0,0 +1,131 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+
+	log "github.com/charmbracelet/log"
+)
+
+// Skipping this leaves out sections that are not in the main reading order of the book
+const nonLinear = "nonlinear"
+
+// The sections that are skipped by default so that only the body of the book is read
+var DefaultSkip = []string{
+	string(epub.Cover), string(epub.TableOfContents), string(epub.Copyright),
+	string(epub.FrontMatter), string(epub.BackMatter), string(epub.Index), nonLinear,
+}
+
+// All the values that can be skipped
+func skippableKinds() []string {
+	kinds := []string{nonLinear}
+	for _, kind := range epub.SectionKinds {
+		kinds = append(kinds, string(kind))
+	}
+	return kinds
+}
+
+func isSkippable(kind string) bool {
+	return slices.Contains(skippableKinds(), kind)
+}
+
+// Whether a section is of a kind that should be skipped
+func isSkipped(section epub.SectionData, skip []string) bool {
+	return slices.Contains(skip, string(section.Kind)) || (!section.Linear && slices.Contains(skip, nonLinear))
+}
+
+// Remove the sections of the kinds that should be skipped. If that would leave
+// nothing to read, the book's labels are likely wrong so every section is kept
+func skipSections(sections []epub.SectionData, skip []string) []epub.SectionData {
+	var kept []epub.SectionData
+	for _, section := range sections {
+		if isSkipped(section, skip) {
+			log.Infof("Skipping %s section %s", section.Kind, section.Filename)
+			continue
+		}
+		kept = append(kept, section)
+	}
+	if len(kept) == 0 && len(sections) > 0 {
+		log.Warnf("Every section would be skipped; reading all %d sections instead", len(sections))
+		return sections
+	}
+	return kept
+}
+
+// Pick the sections of an epub to read: the ones asked for with --sections, or else
+// every section that is not of a kind that is skipped
+func pickSections(sections []epub.SectionData, config AudiobookArgs) ([]epub.SectionData, error) {
+	if len(config.Sections) > 0 {
+		return selectSections(sections, config.Sections)
+	}
+	return skipSections(sections, config.Skip), nil
+}
+
+var (
+	sectionNumber = regexp.MustCompile(`^\d+$`)
+	sectionRange  = regexp.MustCompile(`^(\d*)\s*-\s*(\d*)$`)
+)
+
+// Pick the sections matching any of the selectors, keeping them in reading order.
+// A selector is a section number like "3", a range like "5-7" or "5-",
+// a file in the book like "text/ch05.xhtml", or otherwise part of a title
+func selectSections(sections []epub.SectionData, selectors []string) ([]epub.SectionData, error) {
+	selected := make([]bool, len(sections))
+	for _, selector := range selectors {
+		selector = strings.TrimSpace(selector)
+		if selector == "" {
+			continue
+		}
+
+		matched := false
+		for i, section := range sections {
+			if matchesSelector(section, selector) {
+				selected[i] = true
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("no section matches '%s'; use the toc command to list the sections of the book", selector)
+		}
+	}
+
+	var kept []epub.SectionData
+	for i, section := range sections {
+		if selected[i] {
+			kept = append(kept, section)
+		}
+	}
+	return kept, nil
+}
+
+func matchesSelector(section epub.SectionData, selector string) bool {
+	if sectionNumber.MatchString(selector) {
+		number, _ := strconv.Atoi(selector)
+		return section.Index == number
+	}
+
+	if groups := sectionRange.FindStringSubmatch(selector); groups != nil && (groups[1] != "" || groups[2] != "") {
+		start, end := 1, math.MaxInt
+		if groups[1] != "" {
+			start, _ = strconv.Atoi(groups[1])
+		}
+		if groups[2] != "" {
+			end, _ = strconv.Atoi(groups[2])
+		}
+		return section.Index >= start && section.Index <= end
+	}
+
+	if section.Filename == selector || strings.HasSuffix(section.Filename, "/"+selector) {
+		return true
+	}
+	return section.Title != "" && strings.Contains(strings.ToLower(section.Title), strings.ToLower(selector))
+}
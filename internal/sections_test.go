@@ -0,0 +1,91 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipSections(t *testing.T) {
+	sections := []epub.SectionData{
+		{Filename: "cover.xhtml", Kind: epub.Cover, Linear: true},
+		{Filename: "ch1.xhtml", Kind: epub.BodyMatter, Linear: true},
+		{Filename: "notes.xhtml", Kind: epub.BodyMatter, Linear: false},
+		{Filename: "index.xhtml", Kind: epub.Index, Linear: true},
+	}
+
+	var names []string
+	for _, section := range skipSections(sections, DefaultSkip) {
+		names = append(names, section.Filename)
+	}
+	require.Equal(t, []string{"ch1.xhtml"}, names)
+
+	require.Len(t, skipSections(sections, nil), 4)
+	require.Len(t, skipSections(sections, []string{"index"}), 3)
+
+	// skipping everything keeps everything
+	require.Len(t, skipSections(sections[:1], DefaultSkip), 1)
+}
+
+func TestIsSkippable(t *testing.T) {
+	require.True(t, isSkippable("copyright"))
+	require.True(t, isSkippable("nonlinear"))
+	require.False(t, isSkippable("chapter"))
+}
+
+func TestSelectSections(t *testing.T) {
+	var sections []epub.SectionData
+	for i, title := range []string{"", "Contents", "Chapter 1: The Beginning", "Chapter 2: The Middle", "Chapter 3: The End", "Index"} {
+		sections = append(sections, epub.SectionData{Index: i + 1, Title: title, Filename: fmt.Sprintf("text/part%d.xhtml", i+1)})
+	}
+
+	indexes := func(selectors ...string) []int {
+		selected, err := selectSections(sections, selectors)
+		require.NoError(t, err)
+		var result []int
+		for _, section := range selected {
+			result = append(result, section.Index)
+		}
+		return result
+	}
+
+	require.Equal(t, []int{3}, indexes("3"))
+	require.Equal(t, []int{3, 4, 5}, indexes("3-5"))
+	require.Equal(t, []int{5, 6}, indexes("5-"))
+	require.Equal(t, []int{1, 2}, indexes("-2"))
+	require.Equal(t, []int{4}, indexes("part4.xhtml"))
+	require.Equal(t, []int{4}, indexes("text/part4.xhtml"))
+	require.Equal(t, []int{5}, indexes("the end"))
+	// overlapping selectors keep reading order without duplicates
+	require.Equal(t, []int{2, 3, 4}, indexes("4", "2-3", "middle"))
+
+	_, err := selectSections(sections, []string{"Epilogue"})
+	require.ErrorContains(t, err, "Epilogue")
+	_, err = selectSections(sections, []string{"9"})
+	require.Error(t, err)
+}
+
+func TestTableOfContents(t *testing.T) {
+	entries, err := TableOfContents(filepath.Join("parsers", "epub", "testdata", "dubliners_epub2.epub"), DefaultSkip)
+	require.NoError(t, err)
+	require.Len(t, entries, 18)
+
+	require.Equal(t, "cover", entries[0].Kind)
+	require.True(t, entries[0].Skipped)
+
+	var dead TocEntry
+	for _, entry := range entries {
+		if entry.Title == "THE DEAD" {
+			dead = entry
+		}
+	}
+	require.False(t, dead.Skipped)
+	require.Greater(t, dead.Words, 10000)
+	require.InDelta(t, float64(dead.Words)/wordsPerMinute, dead.EstimatedMinutes, 0.001)
+}
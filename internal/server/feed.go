@@ -0,0 +1,128 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/library"
+
+	log "github.com/charmbracelet/log"
+)
+
+// The url the server was reached at, which the files in its feeds are linked from
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// The audiobooks in the output directory and the ones made by the server's jobs, newest first,
+// which both the podcast feed and the catalogs list
+func (s *Server) feedItems(r *http.Request) ([]library.FeedItem, error) {
+	base := baseURL(r)
+	items, err := library.Items(s.outputDir, library.URLFor(base+"/library"))
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range s.queue.list() {
+		if job.Status != Done {
+			continue
+		}
+		jobItems, err := library.Items(filepath.Join(s.queue.jobDir(job.ID), "output"), library.URLFor(base+"/api/jobs/"+job.ID+"/files"))
+		if err != nil {
+			log.Warnf("Could not read the metadata of job %s: %v", job.ID, err)
+			continue
+		}
+		items = append(items, jobItems...)
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Created.After(items[j].Created) })
+	return items, nil
+}
+
+// The podcast feed of the audiobooks
+func (s *Server) getFeed(w http.ResponseWriter, r *http.Request) {
+	items, err := s.feedItems(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	feed, err := library.Feed("QuickPiperAudiobook", baseURL(r), items)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	if _, err := w.Write(feed); err != nil {
+		log.Debugf("Could not write the feed: %v", err)
+	}
+}
+
+// The OPDS 1.2 catalog of the audiobooks
+func (s *Server) getCatalog(w http.ResponseWriter, r *http.Request) {
+	s.writeCatalog(w, r, library.OPDS1, library.CatalogName, library.OPDS1Type+"; charset=utf-8")
+}
+
+// The OPDS 2.0 catalog of the audiobooks
+func (s *Server) getCatalogJSON(w http.ResponseWriter, r *http.Request) {
+	s.writeCatalog(w, r, library.OPDS2, library.CatalogJSONName, library.OPDS2Type)
+}
+
+func (s *Server) writeCatalog(w http.ResponseWriter, r *http.Request, build func(title, selfURL string, items []library.FeedItem) ([]byte, error), name, contentType string) {
+	items, err := s.feedItems(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	catalog, err := build("QuickPiperAudiobook", baseURL(r)+"/"+name, items)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	if _, err := w.Write(catalog); err != nil {
+		log.Debugf("Could not write the catalog: %v", err)
+	}
+}
+
+// Serve an audiobook in the output directory or its cover or chapters
+func (s *Server) getLibraryFile(w http.ResponseWriter, r *http.Request) {
+	serveFile(w, r, s.outputDir, r.PathValue("path"))
+}
+
+// Serve a file written by a job that is done, i.e. the cover or chapters of its audiobook
+func (s *Server) getJobFile(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.get(r.PathValue("id"))
+	if !ok || job.Status != Done {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no finished job with id %s", r.PathValue("id")))
+		return
+	}
+	serveFile(w, r, filepath.Join(s.queue.jobDir(job.ID), "output"), r.PathValue("path"))
+}
+
+// Serve a file of one of the audiobooks in dir, i.e. the audiobook or its cover.
+// Any other file in dir is not found, since dir may be any directory the server was started in
+func serveFile(w http.ResponseWriter, r *http.Request, dir, name string) {
+	files, err := library.Files(dir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	file := filepath.Join(dir, filepath.FromSlash(name))
+	// symbolic links are not followed out of dir
+	if info, err := os.Lstat(file); !files[name] || err != nil || !info.Mode().IsRegular() {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%s was not found", name))
+		return
+	}
+	http.ServeFile(w, r, file)
+}
@@ -0,0 +1,427 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+
+	log "github.com/charmbracelet/log"
+)
+
+// The state of a conversion job
+type Status string
+
+const (
+	Queued   Status = "queued"
+	Running  Status = "running"
+	Done     Status = "done"
+	Failed   Status = "failed"
+	Canceled Status = "canceled"
+)
+
+// The name of the file each job is saved in within its directory
+const jobFileName = "job.json"
+
+// A book submitted to the server to be converted
+type Job struct {
+	ID     string `json:"id"`
+	Status Status `json:"status"`
+	// the name of the uploaded file or the url to download the book from
+	Input string `json:"input"`
+	// the model to read the book with; empty to use the server's
+	Model    string `json:"model,omitempty"`
+	Format   string `json:"format"`
+	Chapters bool   `json:"chapters"`
+	// how much of the book has been read from 0 to 1
+	Progress float64 `json:"progress"`
+	Error    string  `json:"error,omitempty"`
+	// the file name of the finished audiobook
+	Output   string     `json:"output,omitempty"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+}
+
+// Whether the job won't change anymore
+func (j Job) finished() bool {
+	return j.Status == Done || j.Status == Failed || j.Status == Canceled
+}
+
+// Keeps the jobs of the server in a directory with one subdirectory per job holding
+// its state, the uploaded book and the audiobook, and runs them in the order they came in.
+// Jobs that were queued or running when the server stopped are run again when it starts
+type jobQueue struct {
+	dir string
+	// the options every job is converted with on top of its own
+	config internal.AudiobookArgs
+	// converts a book; internal.QuickPiperAudiobook except in tests
+	convert func(internal.AudiobookArgs) (string, error)
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	jobs    map[string]*Job
+	pending []string
+	// stops the conversion of each job a worker is running
+	running map[string]context.CancelFunc
+	stopped bool
+}
+
+// Returned when removing a canceled job whose conversion has not stopped yet
+var errJobStopping = errors.New("the job is still stopping")
+
+// Open the job queue in a directory, loading the jobs that are saved there
+func openJobQueue(dir string, config internal.AudiobookArgs) (*jobQueue, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	q := &jobQueue{dir: dir, config: config, convert: internal.QuickPiperAudiobook, jobs: make(map[string]*Job), running: make(map[string]context.CancelFunc)}
+	q.cond = sync.NewCond(&q.mu)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name(), jobFileName))
+		if err != nil {
+			log.Warnf("Ignoring %s which is not a job: %v", entry.Name(), err)
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil || job.ID != entry.Name() {
+			log.Warnf("Ignoring the job in %s which could not be read", entry.Name())
+			continue
+		}
+		if !job.finished() {
+			// the server stopped before the job was done so it is started over
+			job.Status, job.Progress, job.Started = Queued, 0, nil
+			q.pending = append(q.pending, job.ID)
+		}
+		q.jobs[job.ID] = &job
+	}
+	sort.Slice(q.pending, func(i, j int) bool {
+		return q.jobs[q.pending[i]].Created.Before(q.jobs[q.pending[j]].Created)
+	})
+	if len(q.pending) > 0 {
+		log.Infof("Resuming %d jobs", len(q.pending))
+	}
+	return q, nil
+}
+
+// Create an id for a job that can't be guessed
+func newJobID() (string, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// The directory a job keeps its files in
+func (q *jobQueue) jobDir(id string) string {
+	return filepath.Join(q.dir, id)
+}
+
+// The path of the book a job converts, downloading it first if the job was given a url
+func (q *jobQueue) source(ctx context.Context, job Job) (string, error) {
+	inputDir := filepath.Join(q.jobDir(job.ID), "input")
+	if !lib.IsUrl(job.Input) {
+		return filepath.Join(inputDir, job.Input), nil
+	}
+	name := path.Base(job.Input)
+	if parsed, err := url.Parse(job.Input); err == nil {
+		name = path.Base(parsed.Path)
+	}
+	if name == "/" || name == "." {
+		name = "book"
+	}
+	file, err := lib.DownloadFileContext(ctx, job.Input, name, inputDir)
+	if err != nil {
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// The path of the finished audiobook of a job
+func (q *jobQueue) outputPath(job Job) string {
+	return filepath.Join(q.jobDir(job.ID), "output", job.Output)
+}
+
+// Save the state of a job; must be called with the lock held
+func (q *jobQueue) save(job *Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(q.jobDir(job.ID), jobFileName)
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+// Add a job whose book was already put in place by prepare, which is given the
+// directory of the job. The job is saved before it is queued
+func (q *jobQueue) add(job Job, prepare func(dir string) error) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	job.ID = id
+	job.Status = Queued
+	job.Created = time.Now().UTC()
+
+	dir := q.jobDir(id)
+	for _, sub := range []string{"input", "output"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return Job{}, err
+		}
+	}
+	if prepare != nil {
+		if err := prepare(dir); err != nil {
+			os.RemoveAll(dir)
+			return Job{}, err
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.save(&job); err != nil {
+		os.RemoveAll(dir)
+		return Job{}, err
+	}
+	q.jobs[id] = &job
+	q.pending = append(q.pending, id)
+	q.cond.Signal()
+	return job, nil
+}
+
+// Return a copy of a job
+func (q *jobQueue) get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// Return copies of all the jobs, oldest first
+func (q *jobQueue) list() []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Created.Before(jobs[j].Created) })
+	return jobs
+}
+
+// Cancel a job that is queued or running. A running conversion is stopped,
+// killing piper and ffmpeg, and whatever it wrote is thrown away
+func (q *jobQueue) cancel(id string) (Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, os.ErrNotExist
+	}
+	if job.finished() {
+		return *job, fmt.Errorf("the job is already %s", job.Status)
+	}
+
+	now := time.Now().UTC()
+	job.Status, job.Finished = Canceled, &now
+	for i, pendingID := range q.pending {
+		if pendingID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			break
+		}
+	}
+	if stop, ok := q.running[id]; ok {
+		stop()
+	}
+	return *job, q.save(job)
+}
+
+// Remove a finished job along with its files. A canceled job can only be removed
+// once its conversion has stopped so nothing is still writing to its directory
+func (q *jobQueue) remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return os.ErrNotExist
+	}
+	if !job.finished() {
+		return fmt.Errorf("the job is still %s", job.Status)
+	}
+	if _, ok := q.running[id]; ok {
+		return errJobStopping
+	}
+	delete(q.jobs, id)
+	return os.RemoveAll(q.jobDir(id))
+}
+
+// Take the oldest queued job and mark it as running, waiting until there is one.
+// The job is converted with a context that is done when ctx is or the job is canceled,
+// and done must be called once the worker is finished with it.
+// Returns false once the queue is stopped
+func (q *jobQueue) next(ctx context.Context) (job Job, jobCtx context.Context, done func(), ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.pending) == 0 && !q.stopped {
+		q.cond.Wait()
+	}
+	if q.stopped {
+		return Job{}, nil, nil, false
+	}
+
+	running := q.jobs[q.pending[0]]
+	q.pending = q.pending[1:]
+	now := time.Now().UTC()
+	running.Status, running.Started = Running, &now
+	if err := q.save(running); err != nil {
+		log.Errorf("Could not save job %s: %v", running.ID, err)
+	}
+
+	// the job can be stopped as soon as it is marked as running so a cancel never misses it
+	jobCtx, stop := context.WithCancel(ctx)
+	id := running.ID
+	q.running[id] = stop
+	done = func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		delete(q.running, id)
+		stop()
+	}
+	return *running, jobCtx, done, true
+}
+
+// Update a running job; the update is dropped if the job was canceled in the meantime
+func (q *jobQueue) update(id string, change func(job *Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok || job.Status != Running {
+		return
+	}
+	change(job)
+	if err := q.save(job); err != nil {
+		log.Errorf("Could not save job %s: %v", job.ID, err)
+	}
+}
+
+// Convert a job's book with the options of the job until ctx is done. A job that is
+// stopped because the server is stopping stays running so it is started over next time
+func (q *jobQueue) run(ctx context.Context, job Job) {
+	log.Infof("Starting job %s for %s", job.ID, job.Input)
+
+	config := q.config
+	config.OutputDirectory = filepath.Join(q.jobDir(job.ID), "output")
+	config.OutputAsMp3 = job.Format == "mp3"
+	config.Chapters = job.Chapters
+	config.Quiet = true
+	// the server serves the feed and catalogs itself instead of writing them next to the audiobook
+	config.Feed = false
+	config.OPDS = false
+	config.Record = true
+	if job.Model != "" {
+		config.Model = job.Model
+		config.AutoVoice = false
+	}
+	config.Progress = func(done, total int) {
+		q.update(job.ID, func(job *Job) {
+			if total > 0 {
+				job.Progress = float64(done) / float64(total)
+			}
+		})
+	}
+
+	config.Context = ctx
+
+	var outputName string
+	source, err := q.source(ctx, job)
+	if err == nil {
+		config.FileName = source
+		outputName, err = q.convert(config)
+	}
+
+	if current, _ := q.get(job.ID); current.Status == Running && ctx.Err() != nil {
+		log.Infof("Job %s was stopped and will be resumed when the server starts again", job.ID)
+		return
+	}
+
+	now := time.Now().UTC()
+	q.update(job.ID, func(job *Job) {
+		job.Finished = &now
+		if err != nil {
+			job.Status, job.Error = Failed, err.Error()
+			return
+		}
+		job.Status, job.Progress, job.Output = Done, 1, filepath.Base(outputName)
+	})
+
+	if finished, _ := q.get(job.ID); finished.Status == Canceled {
+		// the audiobook of a canceled job is not kept
+		os.RemoveAll(filepath.Join(q.jobDir(job.ID), "output"))
+		log.Infof("Job %s was canceled", job.ID)
+		return
+	}
+	if err != nil {
+		log.Errorf("Job %s failed: %v", job.ID, err)
+	} else {
+		log.Infof("Job %s is done", job.ID)
+	}
+}
+
+// Run jobs with the given number of workers until ctx is done. Jobs that are
+// running when ctx is done are stopped and resumed when the queue is opened again
+func (q *jobQueue) work(ctx context.Context, workers int) {
+	go func() {
+		<-ctx.Done()
+		q.mu.Lock()
+		q.stopped = true
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				job, jobCtx, done, ok := q.next(ctx)
+				if !ok {
+					return
+				}
+				q.run(jobCtx, job)
+				done()
+			}
+		}()
+	}
+	wg.Wait()
+}
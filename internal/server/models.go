@@ -0,0 +1,95 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+
+	log "github.com/charmbracelet/log"
+)
+
+// The text a voice is previewed with if none is given
+const defaultPreviewText = "This is how your audiobook will sound when it is read with this voice."
+
+// An installed model along with whether it is the one the server uses by default
+type modelEntry struct {
+	piper.ModelInfo
+	Default bool `json:"default"`
+}
+
+// List the models that are installed and can be picked for a job
+func (s *Server) listModels(w http.ResponseWriter, r *http.Request) {
+	paths, err := piper.FindModels(s.modelDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	defaultModel := strings.TrimSuffix(s.queue.config.Model, ".onnx") + ".onnx"
+	models := []modelEntry{}
+	for _, path := range paths {
+		info, err := piper.ReadModelInfo(path)
+		if err != nil {
+			log.Warnf("Not listing model %s: %v", path, err)
+			continue
+		}
+		// the path of the model is not needed to pick it and says where the server's files are
+		info.Path = ""
+		models = append(models, modelEntry{ModelInfo: info, Default: info.Name == defaultModel})
+	}
+	writeJSON(w, http.StatusOK, models)
+}
+
+// Read a short text with an installed model as a wav, i.e. /api/models/en_US-lessac-medium.onnx/preview?text=Hello
+func (s *Server) previewModel(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the model must be the name of a model, not a path"))
+		return
+	}
+	// only installed models can be previewed so that a preview doesn't download a model
+	paths, err := piper.FindModels(s.modelDir)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	name = strings.TrimSuffix(name, ".onnx") + ".onnx"
+	installed := false
+	for _, path := range paths {
+		if filepath.Base(path) == name {
+			installed = true
+			break
+		}
+	}
+	if !installed {
+		writeError(w, http.StatusNotFound, fmt.Errorf("the model %s is not installed", name))
+		return
+	}
+
+	text := strings.TrimSpace(r.URL.Query().Get("text"))
+	if text == "" {
+		text = defaultPreviewText
+	}
+	if len(text) > internal.MaxPreviewLength {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the preview text is longer than %d characters", internal.MaxPreviewLength))
+		return
+	}
+	// the sample is buffered so that an error can be reported instead of cutting the audio off
+	var audio bytes.Buffer
+	if err := s.preview(s.queue.config, name, text, &audio); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "audio/wav")
+	if _, err := audio.WriteTo(w); err != nil {
+		log.Debugf("Could not write the preview of %s: %v", name, err)
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// A REST API for converting books to audiobooks in the background
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lib"
+
+	log "github.com/charmbracelet/log"
+)
+
+// The largest book that can be uploaded
+const maxUploadSize = 512 << 20
+
+// Return the directory the server keeps its jobs in by default
+func DefaultDataDir() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %v", err)
+	}
+	return filepath.Join(homedir, ".config", "QuickPiperAudiobook", "server"), nil
+}
+
+// The directory the installed models are listed from
+const defaultModelDir = "~/.config/QuickPiperAudiobook"
+
+// Converts the books submitted to its API with the options it was started with
+type Server struct {
+	queue *jobQueue
+	// the directory the models that can be picked from are installed in
+	modelDir string
+	// reads a short text with a model; internal.Preview except in tests
+	preview func(config internal.AudiobookArgs, model, text string, output io.Writer) error
+	// whether to serve a podcast feed of the audiobooks in outputDir and the ones made by jobs
+	feed bool
+	// whether to serve OPDS catalogs of the same audiobooks
+	opds      bool
+	outputDir string
+}
+
+// Create a server that keeps its jobs in dataDir and converts books with the given
+// options, overriding the model, format and chapters with the ones of each job.
+// If config.Feed is set the server also serves a podcast feed at /feed.xml and if config.OPDS
+// is set OPDS catalogs at /catalog.xml and /catalog.json
+func New(config internal.AudiobookArgs, dataDir string) (*Server, error) {
+	dataDir, err := internal.ExpandPath(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	outputDir, err := internal.ExpandPath(config.OutputDirectory)
+	if err != nil {
+		return nil, err
+	}
+	// the jobs share their piper clients so each model is only set up once
+	config = internal.ShareVoices(config, config.Threads)
+	queue, err := openJobQueue(filepath.Join(dataDir, "jobs"), config)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{queue: queue, modelDir: defaultModelDir, preview: internal.Preview, feed: config.Feed, opds: config.OPDS, outputDir: outputDir}, nil
+}
+
+// Convert the submitted books with the given number of workers until ctx is done
+func (s *Server) Run(ctx context.Context, workers int) {
+	s.queue.work(ctx, workers)
+}
+
+// The routes of the API along with the web page that uses it
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /", webHandler())
+	mux.HandleFunc("GET /api/models", s.listModels)
+	mux.HandleFunc("GET /api/models/{name}/preview", s.previewModel)
+	mux.HandleFunc("POST /api/jobs", s.createJob)
+	mux.HandleFunc("GET /api/jobs", s.listJobs)
+	mux.HandleFunc("GET /api/jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /api/jobs/{id}/audio", s.getAudio)
+	mux.HandleFunc("DELETE /api/jobs/{id}", s.deleteJob)
+	if s.feed {
+		mux.HandleFunc("GET /feed.xml", s.getFeed)
+	}
+	if s.opds {
+		mux.HandleFunc("GET /catalog.xml", s.getCatalog)
+		mux.HandleFunc("GET /catalog.json", s.getCatalogJSON)
+	}
+	if s.feed || s.opds {
+		mux.HandleFunc("GET /library/{path...}", s.getLibraryFile)
+		mux.HandleFunc("GET /api/jobs/{id}/files/{path...}", s.getJobFile)
+	}
+	return mux
+}
+
+// The options a job can be submitted with as a JSON body; books are uploaded as multipart forms instead
+type jobRequest struct {
+	URL      string `json:"url"`
+	Model    string `json:"model"`
+	Format   string `json:"format"`
+	Chapters bool   `json:"chapters"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Debugf("Could not write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// Check the options of a job and fill in the defaults of the server
+func (s *Server) validate(request jobRequest) (Job, error) {
+	job := Job{Model: request.Model, Format: request.Format, Chapters: request.Chapters}
+	// models are looked up in the model directory; a path could read any file on the server
+	if strings.ContainsAny(job.Model, `/\`) || strings.HasPrefix(job.Model, ".") {
+		return job, fmt.Errorf("the model must be the name of a model, not a path")
+	}
+	switch job.Format {
+	case "":
+		job.Format = "wav"
+		if s.queue.config.OutputAsMp3 {
+			job.Format = "mp3"
+		}
+	case "mp3", "wav":
+	default:
+		return job, fmt.Errorf("the format must be mp3 or wav, not %q", job.Format)
+	}
+	return job, nil
+}
+
+// Queue a book uploaded as the file field of a multipart form, or downloaded from a url
+func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var request jobRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %v", err))
+			return
+		}
+		s.createURLJob(w, request)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("expected a multipart form or a JSON body: %v", err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	chapters := false
+	if value := r.FormValue("chapters"); value != "" {
+		var err error
+		if chapters, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("chapters must be true or false, not %q", value))
+			return
+		}
+	}
+	request := jobRequest{URL: r.FormValue("url"), Model: r.FormValue("model"), Format: r.FormValue("format"), Chapters: chapters}
+
+	file, header, err := r.FormFile("file")
+	if errors.Is(err, http.ErrMissingFile) {
+		s.createURLJob(w, request)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer file.Close()
+
+	job, err := s.validate(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	job.Input = filepath.Base(filepath.Clean("/" + header.Filename))
+	if job.Input == "/" || job.Input == "." {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("the uploaded file has no name"))
+		return
+	}
+
+	job, err = s.queue.add(job, func(dir string) error {
+		book, err := os.Create(filepath.Join(dir, "input", job.Input))
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(book, file); err != nil {
+			book.Close()
+			return err
+		}
+		return book.Close()
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.created(w, job)
+}
+
+func (s *Server) createURLJob(w http.ResponseWriter, request jobRequest) {
+	if request.URL == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("either a file or a url is required"))
+		return
+	}
+	if !lib.IsUrl(request.URL) || !(strings.HasPrefix(request.URL, "http://") || strings.HasPrefix(request.URL, "https://")) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%q is not an http or https url", request.URL))
+		return
+	}
+	job, err := s.validate(request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	job.Input = request.URL
+
+	job, err = s.queue.add(job, nil)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.created(w, job)
+}
+
+func (s *Server) created(w http.ResponseWriter, job Job) {
+	log.Infof("Queued job %s for %s", job.ID, job.Input)
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.queue.list())
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job with id %s", r.PathValue("id")))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// Download the audiobook of a job once it is done, or play it in the browser with ?inline=1
+func (s *Server) getAudio(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.queue.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job with id %s", r.PathValue("id")))
+		return
+	}
+	if job.Status != Done {
+		writeError(w, http.StatusConflict, fmt.Errorf("the job is %s, not done", job.Status))
+		return
+	}
+
+	audio, err := os.Open(s.queue.outputPath(job))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer audio.Close()
+	info, err := audio.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// the web page plays the audiobook in the browser instead of downloading it
+	disposition := "attachment"
+	if r.URL.Query().Get("inline") == "1" {
+		disposition = "inline"
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": job.Output}))
+	http.ServeContent(w, r, job.Output, info.ModTime(), audio)
+}
+
+// Cancel a job that is queued or running, or remove a finished one along with its audiobook
+func (s *Server) deleteJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	job, ok := s.queue.get(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no job with id %s", id))
+		return
+	}
+	if job.finished() {
+		if err := s.queue.remove(id); errors.Is(err, errJobStopping) {
+			writeError(w, http.StatusConflict, err)
+			return
+		} else if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	job, err := s.queue.cancel(id)
+	if err != nil {
+		// the job finished before it could be canceled
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,450 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/library"
+	"github.com/stretchr/testify/require"
+)
+
+// A server whose books are converted by writing their text to the audiobook;
+// books named broken.txt fail, books named slow.txt wait until release is closed or
+// they are canceled and books named stuck.txt wait until release is closed either way
+func newTestServer(t *testing.T, dataDir string, release chan struct{}) (*Server, *httptest.Server) {
+	return newTestServerWith(t, internal.AudiobookArgs{Model: "en_US-lessac-medium.onnx"}, dataDir, release)
+}
+
+func newTestServerWith(t *testing.T, config internal.AudiobookArgs, dataDir string, release chan struct{}) (*Server, *httptest.Server) {
+	s, err := New(config, dataDir)
+	require.NoError(t, err)
+	s.modelDir = t.TempDir()
+	onnx := filepath.Join(s.modelDir, "en_US-lessac-medium.onnx")
+	require.NoError(t, os.WriteFile(onnx, []byte("onnx"), 0644))
+	require.NoError(t, os.WriteFile(onnx+".json", []byte(`{"audio": {"sample_rate": 22050, "quality": "medium"}, "language": {"code": "en_US", "name_english": "English"}}`), 0644))
+	s.preview = func(config internal.AudiobookArgs, model, text string, output io.Writer) error {
+		_, err := fmt.Fprintf(output, "%s says %s", model, text)
+		return err
+	}
+	s.queue.convert = func(config internal.AudiobookArgs) (string, error) {
+		name := filepath.Base(config.FileName)
+		switch name {
+		case "slow.txt":
+			select {
+			case <-release:
+			case <-config.Context.Done():
+				return "", config.Context.Err()
+			}
+		case "stuck.txt":
+			<-release
+		}
+		if name == "broken.txt" {
+			return "", fmt.Errorf("could not read it")
+		}
+		config.Progress(1, 2)
+		text, err := os.ReadFile(config.FileName)
+		if err != nil {
+			return "", err
+		}
+		ext := ".wav"
+		if config.OutputAsMp3 {
+			ext = ".mp3"
+		}
+		output := filepath.Join(config.OutputDirectory, strings.TrimSuffix(name, filepath.Ext(name))+ext)
+		if err := os.WriteFile(output, text, 0644); err != nil {
+			return "", err
+		}
+		entry := library.Entry{File: filepath.Base(output), Title: name, MediaType: library.MediaType(output), Created: time.Now()}
+		return output, library.Save(config.OutputDirectory, entry, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		s.Run(ctx, 1)
+		close(stopped)
+	}()
+	httpServer := httptest.NewServer(s.Handler())
+	t.Cleanup(func() {
+		httpServer.Close()
+		cancel()
+		<-stopped
+	})
+	return s, httpServer
+}
+
+func upload(t *testing.T, url, name, text string, fields map[string]string) *http.Response {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	require.NoError(t, err)
+	_, err = part.Write([]byte(text))
+	require.NoError(t, err)
+	for key, value := range fields {
+		require.NoError(t, form.WriteField(key, value))
+	}
+	require.NoError(t, form.Close())
+
+	resp, err := http.Post(url+"/api/jobs", form.FormDataContentType(), &body)
+	require.NoError(t, err)
+	return resp
+}
+
+func decodeJob(t *testing.T, resp *http.Response) Job {
+	defer resp.Body.Close()
+	var job Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&job))
+	return job
+}
+
+func waitForStatus(t *testing.T, url, id string, status Status) Job {
+	var job Job
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/api/jobs/" + id)
+		require.NoError(t, err)
+		job = decodeJob(t, resp)
+		return job.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	_, httpServer := newTestServer(t, t.TempDir(), nil)
+
+	resp := upload(t, httpServer.URL, "book.txt", "hello world", map[string]string{"format": "mp3", "model": "en_US-lessac-medium.onnx"})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	job := decodeJob(t, resp)
+	require.Equal(t, "/api/jobs/"+job.ID, resp.Header.Get("Location"))
+	require.Equal(t, "book.txt", job.Input)
+	require.Equal(t, "mp3", job.Format)
+
+	job = waitForStatus(t, httpServer.URL, job.ID, Done)
+	require.Equal(t, "book.mp3", job.Output)
+	require.Equal(t, 1.0, job.Progress)
+	require.NotNil(t, job.Finished)
+
+	resp, err := http.Get(httpServer.URL + "/api/jobs/" + job.ID + "/audio")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Disposition"), "book.mp3")
+	audio, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "hello world", string(audio))
+
+	resp, err = http.Get(httpServer.URL + "/api/jobs")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var jobs []Job
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&jobs))
+	require.Len(t, jobs, 1)
+}
+
+func TestFailedJob(t *testing.T) {
+	_, httpServer := newTestServer(t, t.TempDir(), nil)
+
+	job := decodeJob(t, upload(t, httpServer.URL, "broken.txt", "text", nil))
+	job = waitForStatus(t, httpServer.URL, job.ID, Failed)
+	require.Equal(t, "could not read it", job.Error)
+
+	resp, err := http.Get(httpServer.URL + "/api/jobs/" + job.ID + "/audio")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestInvalidRequests(t *testing.T) {
+	_, httpServer := newTestServer(t, t.TempDir(), nil)
+
+	resp := upload(t, httpServer.URL, "book.txt", "text", map[string]string{"model": "../../secret.onnx"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp = upload(t, httpServer.URL, "book.txt", "text", map[string]string{"format": "flac"})
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err := http.Post(httpServer.URL+"/api/jobs", "application/json", strings.NewReader(`{"url": "file:///etc/passwd"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Get(httpServer.URL + "/api/jobs/missing")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestURLJob(t *testing.T) {
+	book := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "downloaded text")
+	}))
+	defer book.Close()
+	_, httpServer := newTestServer(t, t.TempDir(), nil)
+
+	resp, err := http.Post(httpServer.URL+"/api/jobs", "application/json", strings.NewReader(`{"url": "`+book.URL+`/books/story.txt"}`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	job := waitForStatus(t, httpServer.URL, decodeJob(t, resp).ID, Done)
+	require.Equal(t, "story.wav", job.Output)
+}
+
+func TestCancelAndDelete(t *testing.T) {
+	release := make(chan struct{})
+	s, httpServer := newTestServer(t, t.TempDir(), release)
+
+	slow := decodeJob(t, upload(t, httpServer.URL, "slow.txt", "text", nil))
+	queued := decodeJob(t, upload(t, httpServer.URL, "book.txt", "text", nil))
+	waitForStatus(t, httpServer.URL, slow.ID, Running)
+
+	for _, id := range []string{queued.ID, slow.ID} {
+		req, err := http.NewRequest(http.MethodDelete, httpServer.URL+"/api/jobs/"+id, nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, Canceled, decodeJob(t, resp).Status)
+	}
+
+	// the running conversion is stopped and what it wrote is thrown away
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(s.queue.jobDir(slow.ID), "output"))
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, Canceled, waitForStatus(t, httpServer.URL, slow.ID, Canceled).Status)
+
+	// deleting a finished job removes it
+	for _, id := range []string{queued.ID, slow.ID} {
+		require.Equal(t, http.StatusNoContent, deleteJob(t, httpServer.URL, id).StatusCode)
+		require.NoDirExists(t, s.queue.jobDir(id))
+	}
+
+	// a canceled job can't be removed until its conversion has stopped
+	stuck := decodeJob(t, upload(t, httpServer.URL, "stuck.txt", "text", nil))
+	waitForStatus(t, httpServer.URL, stuck.ID, Running)
+	require.Equal(t, http.StatusOK, deleteJob(t, httpServer.URL, stuck.ID).StatusCode)
+	require.Equal(t, http.StatusConflict, deleteJob(t, httpServer.URL, stuck.ID).StatusCode)
+	require.DirExists(t, s.queue.jobDir(stuck.ID))
+	close(release)
+	require.Eventually(t, func() bool {
+		return deleteJob(t, httpServer.URL, stuck.ID).StatusCode == http.StatusNoContent
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoDirExists(t, s.queue.jobDir(stuck.ID))
+}
+
+func deleteJob(t *testing.T, url, id string) *http.Response {
+	req, err := http.NewRequest(http.MethodDelete, url+"/api/jobs/"+id, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestJobsAreResumed(t *testing.T) {
+	dataDir := t.TempDir()
+	first, err := New(internal.AudiobookArgs{}, dataDir)
+	require.NoError(t, err)
+	// queue a job without running it, as if the server stopped before it got to it
+	job, err := first.queue.add(Job{Input: "book.txt", Format: "wav"}, func(dir string) error {
+		return os.WriteFile(filepath.Join(dir, "input", "book.txt"), []byte("text"), 0644)
+	})
+	require.NoError(t, err)
+
+	_, httpServer := newTestServer(t, dataDir, nil)
+	require.Equal(t, "book.wav", waitForStatus(t, httpServer.URL, job.ID, Done).Output)
+}
+
+// Make sure a job canceled right after a worker took it is stopped and its files are kept until it has
+func TestCancelJobBeingStarted(t *testing.T) {
+	s, err := New(internal.AudiobookArgs{}, t.TempDir())
+	require.NoError(t, err)
+	added, err := s.queue.add(Job{Input: "book.txt", Format: "wav"}, nil)
+	require.NoError(t, err)
+
+	job, ctx, done, ok := s.queue.next(context.Background())
+	require.True(t, ok)
+	require.Equal(t, added.ID, job.ID)
+	_, err = s.queue.cancel(job.ID)
+	require.NoError(t, err)
+	require.Error(t, ctx.Err())
+	require.ErrorIs(t, s.queue.remove(job.ID), errJobStopping)
+
+	done()
+	require.NoError(t, s.queue.remove(job.ID))
+	require.NoDirExists(t, s.queue.jobDir(job.ID))
+}
+
+// Make sure stopping the server stops the running jobs, which are then resumed when it starts again
+func TestStoppedJobsAreResumed(t *testing.T) {
+	dataDir := t.TempDir()
+	s, err := New(internal.AudiobookArgs{}, dataDir)
+	require.NoError(t, err)
+	s.queue.convert = func(config internal.AudiobookArgs) (string, error) {
+		<-config.Context.Done()
+		return "", config.Context.Err()
+	}
+	job, err := s.queue.add(Job{Input: "book.txt", Format: "wav"}, func(dir string) error {
+		return os.WriteFile(filepath.Join(dir, "input", "book.txt"), []byte("text"), 0644)
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		s.Run(ctx, 1)
+		close(stopped)
+	}()
+	require.Eventually(t, func() bool {
+		running, _ := s.queue.get(job.ID)
+		return running.Status == Running
+	}, 5*time.Second, 10*time.Millisecond)
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server did not stop its running job")
+	}
+	stoppedJob, _ := s.queue.get(job.ID)
+	require.Equal(t, Running, stoppedJob.Status)
+
+	_, httpServer := newTestServer(t, dataDir, nil)
+	require.Equal(t, "book.wav", waitForStatus(t, httpServer.URL, job.ID, Done).Output)
+}
+
+func TestModels(t *testing.T) {
+	_, httpServer := newTestServer(t, t.TempDir(), nil)
+
+	resp, err := http.Get(httpServer.URL + "/api/models")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var models []modelEntry
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&models))
+	require.Len(t, models, 1)
+	require.Equal(t, "en_US-lessac-medium.onnx", models[0].Name)
+	require.Equal(t, "English", models[0].LanguageName)
+	require.True(t, models[0].Default)
+	require.Empty(t, models[0].Path)
+
+	resp, err = http.Get(httpServer.URL + "/api/models/en_US-lessac-medium/preview?text=Hello")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "audio/wav", resp.Header.Get("Content-Type"))
+	audio, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, "en_US-lessac-medium.onnx says Hello", string(audio))
+
+	resp, err = http.Get(httpServer.URL + "/api/models/de_DE-thorsten-medium.onnx/preview")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebPage(t *testing.T) {
+	_, httpServer := newTestServer(t, t.TempDir(), nil)
+
+	for _, page := range []string{"/", "/app.js", "/style.css"} {
+		resp, err := http.Get(httpServer.URL + page)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, page)
+	}
+
+	job := decodeJob(t, upload(t, httpServer.URL, "book.txt", "hello", nil))
+	waitForStatus(t, httpServer.URL, job.ID, Done)
+	resp, err := http.Get(httpServer.URL + "/api/jobs/" + job.ID + "/audio?inline=1")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Contains(t, resp.Header.Get("Content-Disposition"), "inline")
+}
+
+func TestFeed(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "article.mp3"), []byte("audio"), 0644))
+	entry := library.Entry{File: "article.mp3", Title: "An article", Cover: "article.jpg", MediaType: "audio/mpeg", Created: time.Now().Add(-time.Hour)}
+	require.NoError(t, library.Save(outputDir, entry, strings.NewReader("cover")))
+
+	config := internal.AudiobookArgs{OutputDirectory: outputDir, Feed: true}
+	_, httpServer := newTestServerWith(t, config, t.TempDir(), nil)
+	job := decodeJob(t, upload(t, httpServer.URL, "book.txt", "hello", nil))
+	waitForStatus(t, httpServer.URL, job.ID, Done)
+
+	resp, err := http.Get(httpServer.URL + "/feed.xml")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	feed, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(feed), `<enclosure url="`+httpServer.URL+`/library/article.mp3"`)
+	require.Contains(t, string(feed), `<enclosure url="`+httpServer.URL+`/api/jobs/`+job.ID+`/files/book.wav"`)
+	// the newest audiobook comes first
+	require.Less(t, strings.Index(string(feed), "book.txt"), strings.Index(string(feed), "An article"))
+
+	for _, file := range []string{"/library/article.mp3", "/library/.library/article.jpg", "/api/jobs/" + job.ID + "/files/book.wav"} {
+		resp, err := http.Get(httpServer.URL + file)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, file)
+	}
+	// only the files of the audiobooks are served, not anything else in the output directory
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, ".env"), []byte("SECRET=1"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(outputDir, ".env"), filepath.Join(outputDir, "link.mp3")))
+	require.NoError(t, library.Save(outputDir, library.Entry{File: "link.mp3", Title: "A link", Created: time.Now()}, nil))
+	for _, file := range []string{"/library/", "/library/../secret", "/library/.library", "/library/.env",
+		"/library/.library/article.mp3.json", "/library/link.mp3", "/api/jobs/" + job.ID + "/files/job.json"} {
+		resp, err := http.Get(httpServer.URL + file)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.NotEqual(t, http.StatusOK, resp.StatusCode, file)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(outputDir, "article.mp3"), []byte("audio"), 0644))
+	entry := library.Entry{File: "article.mp3", Title: "An article", MediaType: "audio/mpeg", Created: time.Now()}
+	require.NoError(t, library.Save(outputDir, entry, nil))
+
+	config := internal.AudiobookArgs{OutputDirectory: outputDir, OPDS: true}
+	_, httpServer := newTestServerWith(t, config, t.TempDir(), nil)
+
+	resp, err := http.Get(httpServer.URL + "/catalog.xml")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("Content-Type"), "profile=opds-catalog")
+	catalog, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(catalog), `href="`+httpServer.URL+`/library/article.mp3"`)
+
+	resp, err = http.Get(httpServer.URL + "/catalog.json")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, library.OPDS2Type, resp.Header.Get("Content-Type"))
+
+	// the files are served without the podcast feed
+	resp, err = http.Get(httpServer.URL + "/library/article.mp3")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get(httpServer.URL + "/feed.xml")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.NotEqual(t, http.StatusOK, resp.StatusCode)
+}
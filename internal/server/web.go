@@ -0,0 +1,25 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// The web page for converting books without the command line
+//
+//go:embed web
+var webFiles embed.FS
+
+// Serve the web page, which uses the API to upload books and follow their jobs
+func webHandler() http.Handler {
+	root, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		// the directory is embedded at build time so this can't happen
+		panic(err)
+	}
+	return http.FileServerFS(root)
+}
@@ -0,0 +1,198 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+// The web page of the serve command; everything goes through the REST API
+
+const form = document.getElementById("convert");
+const message = document.getElementById("message");
+const modelSelect = document.getElementById("models");
+const previewAudio = document.getElementById("preview-audio");
+const jobList = document.getElementById("jobs");
+
+// How often the list of jobs is refreshed while any of them are not finished
+const pollInterval = 2000;
+
+function showMessage(text, isError) {
+  message.textContent = text;
+  message.className = isError ? "error" : "";
+}
+
+// Return the error in a response of the API, or a generic one
+async function responseError(response) {
+  try {
+    const body = await response.json();
+    return body.error || response.statusText;
+  } catch {
+    return response.statusText;
+  }
+}
+
+async function loadModels() {
+  const response = await fetch("api/models");
+  if (!response.ok) {
+    showMessage("Could not list the voices: " + (await responseError(response)), true);
+    return;
+  }
+  const models = await response.json();
+  modelSelect.replaceChildren();
+  for (const model of models) {
+    const option = document.createElement("option");
+    option.value = model.name;
+    option.textContent = model.language_name
+      ? `${model.name} (${model.language_name}, ${model.quality})`
+      : model.name;
+    option.selected = model.default;
+    modelSelect.append(option);
+  }
+  if (models.length === 0) {
+    const option = document.createElement("option");
+    option.value = "";
+    option.textContent = "Server default";
+    modelSelect.append(option);
+  }
+}
+
+async function previewVoice() {
+  const model = modelSelect.value;
+  if (!model) {
+    showMessage("Install a voice on the server to preview it", true);
+    return;
+  }
+  const text = document.getElementById("preview-text").value;
+  showMessage("Generating a sample…", false);
+  const response = await fetch(
+    `api/models/${encodeURIComponent(model)}/preview?text=${encodeURIComponent(text)}`,
+  );
+  if (!response.ok) {
+    showMessage("Could not preview the voice: " + (await responseError(response)), true);
+    return;
+  }
+  if (previewAudio.src) {
+    URL.revokeObjectURL(previewAudio.src);
+  }
+  previewAudio.src = URL.createObjectURL(await response.blob());
+  previewAudio.hidden = false;
+  previewAudio.play();
+  showMessage("", false);
+}
+
+async function submitBook(event) {
+  event.preventDefault();
+  const button = form.querySelector("button[type=submit]");
+  button.disabled = true;
+  showMessage("Uploading…", false);
+  try {
+    const response = await fetch("api/jobs", { method: "POST", body: new FormData(form) });
+    if (!response.ok) {
+      showMessage("Could not convert the book: " + (await responseError(response)), true);
+      return;
+    }
+    form.querySelector("input[type=file]").value = "";
+    showMessage("The book was added to the queue", false);
+    refreshJobs();
+  } finally {
+    button.disabled = false;
+  }
+}
+
+// Cancel a job that is not finished or remove one that is
+async function deleteJob(id) {
+  const response = await fetch(`api/jobs/${id}`, { method: "DELETE" });
+  if (!response.ok) {
+    showMessage(await responseError(response), true);
+  }
+  refreshJobs();
+}
+
+function isFinished(job) {
+  return ["done", "failed", "canceled"].includes(job.status);
+}
+
+// Build the element for a job; elements are rebuilt only when the job changes
+// so that an audiobook that is playing isn't interrupted by a refresh
+function jobElement(job) {
+  const item = document.createElement("li");
+  item.className = "job";
+  item.dataset.state = `${job.status}:${job.progress}`;
+
+  const title = document.createElement("strong");
+  title.textContent = job.input;
+  const status = document.createElement("span");
+  status.textContent = job.status === "running"
+    ? `running, ${Math.round(job.progress * 100)}% read`
+    : job.status;
+  item.append(title, status);
+
+  if (!isFinished(job)) {
+    const progress = document.createElement("progress");
+    progress.max = 1;
+    if (job.status === "running") {
+      progress.value = job.progress;
+    }
+    item.append(progress);
+  }
+  if (job.error) {
+    const error = document.createElement("span");
+    error.className = "error";
+    error.textContent = job.error;
+    item.append(error);
+  }
+  if (job.status === "done") {
+    const audio = document.createElement("audio");
+    audio.controls = true;
+    audio.preload = "none";
+    audio.src = `api/jobs/${job.id}/audio?inline=1`;
+    item.append(audio);
+  }
+
+  const actions = document.createElement("div");
+  actions.className = "actions";
+  if (job.status === "done") {
+    const download = document.createElement("a");
+    download.href = `api/jobs/${job.id}/audio`;
+    download.textContent = `Download ${job.output}`;
+    actions.append(download);
+  }
+  const remove = document.createElement("button");
+  remove.type = "button";
+  remove.textContent = isFinished(job) ? "Remove" : "Cancel";
+  remove.addEventListener("click", () => deleteJob(job.id));
+  actions.append(remove);
+  item.append(actions);
+  return item;
+}
+
+let pollTimer;
+
+async function refreshJobs() {
+  clearTimeout(pollTimer);
+  const response = await fetch("api/jobs");
+  if (!response.ok) {
+    showMessage("Could not list the audiobooks: " + (await responseError(response)), true);
+    return;
+  }
+  // newest first
+  const jobs = (await response.json()).reverse();
+  document.getElementById("empty").hidden = jobs.length > 0;
+
+  const existing = new Map([...jobList.children].map((item) => [item.dataset.id, item]));
+  const items = jobs.map((job) => {
+    const item = existing.get(job.id);
+    if (item && item.dataset.state === `${job.status}:${job.progress}`) {
+      return item;
+    }
+    const updated = jobElement(job);
+    updated.dataset.id = job.id;
+    return updated;
+  });
+  jobList.replaceChildren(...items);
+
+  if (jobs.some((job) => !isFinished(job))) {
+    pollTimer = setTimeout(refreshJobs, pollInterval);
+  }
+}
+
+form.addEventListener("submit", submitBook);
+document.getElementById("preview").addEventListener("click", previewVoice);
+loadModels();
+refreshJobs();
@@ -0,0 +1,127 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/ffmpeg"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+
+	log "github.com/charmbracelet/log"
+)
+
+// The file name and output directory that mean standard input and standard output
+const StdioPath = "-"
+
+// The format of text read from standard input when no other format is given
+const DefaultInputFormat = "txt"
+
+// Save standard input to a temporary file named after the format it is in, i.e. "stdin.epub",
+// so that it can be read like any other file. The returned function removes the file
+func saveStdin(stdin io.Reader, format string) (string, func(), error) {
+	format = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(format)), ".")
+	if format == "" {
+		format = DefaultInputFormat
+	}
+	if strings.ContainsAny(format, `/\`) {
+		return "", nil, fmt.Errorf("invalid input format '%s'", format)
+	}
+
+	dir, err := os.MkdirTemp("", "piper-stdin-*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	name := filepath.Join(dir, "stdin."+format)
+	file, err := os.Create(name)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	defer file.Close()
+
+	written, err := io.Copy(file, stdin)
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed reading standard input: %v", err)
+	}
+	if written == 0 {
+		cleanup()
+		return "", nil, fmt.Errorf("nothing was read from standard input")
+	}
+	log.Debugf("Read %d bytes of %s from standard input", written, format)
+	return name, cleanup, nil
+}
+
+// Read text with piper and write the audio to output as it is generated,
+// encoded as an mp3 if mp3 output was asked for and as a wav otherwise
+func streamAudio(client *piper.PiperClient, text io.Reader, config AudiobookArgs, output io.Writer) error {
+	streamOutput, _, err := client.Run(config.FileName, text, config.OutputDirectory, true)
+	if err != nil {
+		return err
+	}
+
+	if config.OutputAsMp3 {
+		err = ffmpeg.StreamMp3(streamOutput.Stdout, client.SampleRate(), output)
+	} else {
+		if _, err = output.Write(streamingWavHeader(client.SampleRate())); err == nil {
+			_, err = io.Copy(output, streamOutput.Stdout)
+		}
+	}
+	if err != nil {
+		// stop piper since nothing is reading what it outputs anymore
+		_ = streamOutput.Handle.Process.Kill()
+		_ = streamOutput.Handle.Wait()
+		return err
+	}
+	if err := streamOutput.Handle.Wait(); err != nil {
+		return fmt.Errorf("piper failed: %v", err)
+	}
+	return nil
+}
+
+// The header of a mono 16 bit wav file whose length isn't known when it is written.
+// The sizes are set to the maximum which players treat as reading until the end of the stream
+func streamingWavHeader(sampleRate int) []byte {
+	const (
+		channels      = 1
+		bitsPerSample = 16
+		unknownSize   = 0xFFFFFFFF
+	)
+	blockAlign := channels * bitsPerSample / 8
+
+	header := make([]byte, 0, 44)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, unknownSize)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	// PCM
+	header = binary.LittleEndian.AppendUint16(header, 1)
+	header = binary.LittleEndian.AppendUint16(header, channels)
+	header = binary.LittleEndian.AppendUint32(header, uint32(sampleRate))
+	header = binary.LittleEndian.AppendUint32(header, uint32(sampleRate*blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, uint16(blockAlign))
+	header = binary.LittleEndian.AppendUint16(header, bitsPerSample)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, unknownSize)
+	return header
+}
+
+// Write an audiobook that was saved to a file to output
+func copyFile(name string, output io.Writer) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(output, file)
+	return err
+}
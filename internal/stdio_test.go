@@ -0,0 +1,55 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveStdin(t *testing.T) {
+	name, cleanup, err := saveStdin(strings.NewReader("# Title\n\nText"), ".MD")
+	require.NoError(t, err)
+	require.Equal(t, "stdin.md", filepath.Base(name))
+
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	require.Equal(t, "# Title\n\nText", string(data))
+
+	cleanup()
+	_, err = os.Stat(filepath.Dir(name))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestSaveStdinDefaultsToText(t *testing.T) {
+	name, cleanup, err := saveStdin(strings.NewReader("text"), "")
+	require.NoError(t, err)
+	defer cleanup()
+	require.Equal(t, "stdin.txt", filepath.Base(name))
+}
+
+func TestSaveStdinErrors(t *testing.T) {
+	_, _, err := saveStdin(strings.NewReader(""), "txt")
+	require.ErrorContains(t, err, "nothing was read")
+
+	_, _, err = saveStdin(strings.NewReader("text"), "../txt")
+	require.ErrorContains(t, err, "invalid input format")
+}
+
+func TestStreamingWavHeader(t *testing.T) {
+	header := streamingWavHeader(22050)
+	require.Len(t, header, 44)
+	require.Equal(t, "RIFF", string(header[0:4]))
+	require.Equal(t, "WAVEfmt ", string(header[8:16]))
+	require.Equal(t, uint16(1), binary.LittleEndian.Uint16(header[22:24]))
+	require.Equal(t, uint32(22050), binary.LittleEndian.Uint32(header[24:28]))
+	require.Equal(t, uint32(44100), binary.LittleEndian.Uint32(header[28:32]))
+	require.Equal(t, uint16(16), binary.LittleEndian.Uint16(header[34:36]))
+	require.Equal(t, "data", string(header[36:40]))
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/iconv"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lexicon"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/normalize"
+
+	log "github.com/charmbracelet/log"
+)
+
+// Text going through the stages of prepareText. Closing it stops every stage
+// so none of them is left waiting for a reader that stopped early
+type preparedText struct {
+	io.Reader
+	stages []io.Closer
+}
+
+func (p *preparedText) add(stage io.ReadCloser) {
+	p.Reader = stage
+	p.stages = append(p.stages, stage)
+}
+
+func (p *preparedText) Close() error {
+	for _, stage := range p.stages {
+		stage.Close()
+	}
+	return nil
+}
+
+// Prepare plain text for piper by removing boilerplate, applying the lexicon,
+// spelling out numbers and transliterating characters the voice can't speak.
+// language is the language the text is written in and model is the voice that will read it.
+// The result must be closed once it is read or is no longer needed
+func prepareText(input io.Reader, config AudiobookArgs, language, model string, speakUTF8 bool) (io.ReadCloser, error) {
+	var normalizer normalize.Normalizer
+	if config.Normalize {
+		// numbers are spelled out in the language of the voice since that is what it can pronounce
+		var err error
+		if normalizer, err = normalize.New(modelLanguage(model), config.NormalizeSkip); err != nil {
+			return nil, err
+		}
+	}
+
+	prepared := &preparedText{Reader: input}
+	filtered, err := config.filters.Reader(input)
+	if err != nil {
+		return nil, err
+	}
+	prepared.add(filtered)
+
+	// respellings come before normalization so they can also override how numbers and abbreviations are read
+	prepared.add(config.lexicon.Reader(prepared.Reader))
+	prepared.add(normalizer.Reader(prepared.Reader))
+
+	if !speakUTF8 {
+		transliterated, err := iconv.RemoveDiacritics(prepared.Reader)
+		if err != nil {
+			prepared.Close()
+			return nil, err
+		}
+		prepared.Reader = transliterated
+	}
+	return prepared, nil
+}
+
+// Load the pronunciation lexicons for a book. Files passed explicitly take priority,
+// then the book's sidecar lexicon and then the global lexicon in the config directory
+func LoadLexicon(book string, files []string) (lexicon.Lexicon, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return lexicon.Lexicon{}, err
+	}
+	global := filepath.Join(homedir, ".config", "QuickPiperAudiobook", "lexicon.yaml")
+
+	// unlike the sidecar and global lexicons, files that were asked for must exist
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			return lexicon.Lexicon{}, fmt.Errorf("lexicon %s could not be read: %v", file, err)
+		}
+	}
+
+	paths := slices.Clone(files)
+	if book != "" {
+		paths = append(paths, lexicon.SidecarPath(book))
+	}
+	paths = append(paths, global)
+
+	loaded, err := lexicon.Load(paths...)
+	if err != nil {
+		return lexicon.Lexicon{}, err
+	}
+	if loaded.Len() > 0 {
+		log.Infof("Using %d lexicon entries", loaded.Len())
+	}
+	return loaded, nil
+}
+
+// Return the base language of a piper model from its name i.e. "en_US-lessac-medium.onnx" -> "en"
+func modelLanguage(model string) string {
+	return lang.Base(strings.TrimSuffix(filepath.Base(model), ".onnx"))
+}
+
+// Read a word or phrase through the same text stages as a book and synthesize it
+// to a wav file in the output directory so its pronunciation can be checked.
+// config.FileName is optional and picks up that book's sidecar lexicon.
+// Returns the text that was read and the path of the wav file
+func LexiconTest(config AudiobookArgs, text string) (string, string, error) {
+	config, err := expandHomeDir(config)
+	if err != nil {
+		return "", "", err
+	}
+
+	config.lexicon, err = LoadLexicon(config.FileName, config.LexiconFiles)
+	if err != nil {
+		return "", "", err
+	}
+
+	language := modelLanguage(config.Model)
+	prepared, err := prepareText(strings.NewReader(text), config, language, config.Model, config.SpeakUTF8)
+	if err != nil {
+		return "", "", err
+	}
+	defer prepared.Close()
+	spoken, err := io.ReadAll(prepared)
+	if err != nil {
+		return "", "", err
+	}
+
+	client, err := piper.NewPiperClient(config.Model, config.DownloadSources)
+	if err != nil {
+		return "", "", err
+	}
+	_, wav, err := client.Run("lexicon-test", bytes.NewReader(spoken), config.OutputDirectory, false)
+	if err != nil {
+		return "", "", err
+	}
+	return string(spoken), wav, nil
+}
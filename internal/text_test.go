@@ -0,0 +1,65 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModelLanguage(t *testing.T) {
+	require.Equal(t, "en", modelLanguage("en_US-hfc_male-medium.onnx"))
+	require.Equal(t, "pl", modelLanguage("/home/user/.config/QuickPiperAudiobook/pl_PL-gosia-medium"))
+	require.Equal(t, "no", modelLanguage("no_NO-talesyntese-medium.onnx"))
+}
+
+func TestPrepareText(t *testing.T) {
+	config := AudiobookArgs{Normalize: true}
+
+	reader, err := prepareText(strings.NewReader("In 1905 Ōtani paid $5"), config, "en", "en_US-hfc_male-medium.onnx", false)
+	require.NoError(t, err)
+	text, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "In nineteen oh five Otani paid five dollars", string(text))
+
+	// numbers are left for voices in languages without rules
+	reader, err = prepareText(strings.NewReader("W 1905 roku"), config, "pl", "pl_PL-gosia-medium.onnx", true)
+	require.NoError(t, err)
+	text, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "W 1905 roku", string(text))
+
+	config.NormalizeSkip = []string{"not-a-rule"}
+	_, err = prepareText(strings.NewReader(""), config, "en", "en_US-hfc_male-medium.onnx", true)
+	require.Error(t, err)
+}
+
+func TestLoadLexicon(t *testing.T) {
+	// keep the global lexicon of whoever runs the tests out of them
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	book := filepath.Join(dir, "book.txt")
+	extra := filepath.Join(dir, "extra.yaml")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "book.lexicon.yaml"), []byte("- word: Kvothe\n  say: quothe\n- word: Denna\n  say: den uh\n"), 0644))
+	require.NoError(t, os.WriteFile(extra, []byte("- word: Kvothe\n  say: kuh vothe\n"), 0644))
+
+	loaded, err := LoadLexicon(book, []string{extra})
+	require.NoError(t, err)
+
+	// files passed explicitly take priority over the book's lexicon
+	config := AudiobookArgs{lexicon: loaded}
+	reader, err := prepareText(strings.NewReader("Kvothe met Denna"), config, "en", "en_US-hfc_male-medium.onnx", true)
+	require.NoError(t, err)
+	text, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	require.Equal(t, "Kuh vothe met Den uh", string(text))
+
+	_, err = LoadLexicon(book, []string{filepath.Join(dir, "missing.yaml")})
+	require.Error(t, err)
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"strings"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+)
+
+// piper reads at roughly this pace with the default length scale
+const wordsPerMinute = 160
+
+// A section of an epub as listed by the toc command
+type TocEntry struct {
+	// The number used to select the section with --sections
+	Index  int    `json:"index"`
+	Title  string `json:"title"`
+	Href   string `json:"href"`
+	Kind   string `json:"kind"`
+	Linear bool   `json:"linear"`
+	// Whether the section is left out with the given skip options
+	Skipped bool `json:"skipped"`
+	Words   int  `json:"words"`
+	// A rough estimate of how long the section takes to read
+	EstimatedMinutes float64 `json:"estimated_minutes"`
+}
+
+// List the sections of an epub in reading order with an estimate of how long each is
+func TableOfContents(filename string, skip []string) ([]TocEntry, error) {
+	splitter, err := epub.NewEpubSplitter(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer splitter.Close()
+
+	sections, err := splitter.SplitBySection()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TocEntry, 0, len(sections))
+	for _, section := range sections {
+		text, err := epub.ExtractText(section.Text)
+		if err != nil {
+			return nil, err
+		}
+		words := len(strings.Fields(text))
+
+		entries = append(entries, TocEntry{
+			Index:            section.Index,
+			Title:            section.Title,
+			Href:             section.Filename,
+			Kind:             string(section.Kind),
+			Linear:           section.Linear,
+			Skipped:          isSkipped(section, skip),
+			Words:            words,
+			EstimatedMinutes: float64(words) / wordsPerMinute,
+		})
+	}
+	return entries, nil
+}
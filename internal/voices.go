@@ -0,0 +1,200 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"context"
+	"sync"
+
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/binarymanagers/piper"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/lang"
+	"github.com/C-Loftus/QuickPiperAudiobook/internal/parsers/epub"
+
+	log "github.com/charmbracelet/log"
+	"golang.org/x/sync/semaphore"
+)
+
+// The voices that are used for a book's language when the model is
+// selected automatically. Any of these can be overridden in the config
+// and languages not listed here are read with the default model
+var DefaultLanguageVoices = map[string]string{
+	"ca": "ca_ES-upc_ona-medium.onnx",
+	"cs": "cs_CZ-jirka-medium.onnx",
+	"da": "da_DK-talesyntese-medium.onnx",
+	"de": "de_DE-thorsten-medium.onnx",
+	"el": "el_GR-rapunzelina-low.onnx",
+	"es": "es_ES-davefx-medium.onnx",
+	"fi": "fi_FI-harri-medium.onnx",
+	"fr": "fr_FR-siwis-medium.onnx",
+	"hu": "hu_HU-anna-medium.onnx",
+	"it": "it_IT-riccardo-x_low.onnx",
+	"nl": "nl_NL-mls-medium.onnx",
+	"no": "no_NO-talesyntese-medium.onnx",
+	"pl": "pl_PL-gosia-medium.onnx",
+	"pt": "pt_BR-faber-medium.onnx",
+	"ro": "ro_RO-mihai-medium.onnx",
+	"ru": "ru_RU-irina-medium.onnx",
+	"sv": "sv_SE-nst-medium.onnx",
+	"tr": "tr_TR-fahrettin-medium.onnx",
+	"uk": "uk_UA-ukrainian_tts-medium.onnx",
+	"vi": "vi_VN-vais1000-medium.onnx",
+	"zh": "zh_CN-huayan-medium.onnx",
+}
+
+// Picks which piper model reads text in a given language and keeps
+// one piper client per model so each is only set up once
+type voiceSelector struct {
+	config AudiobookArgs
+	pool   *piperPool
+}
+
+func newVoiceSelector(config AudiobookArgs) *voiceSelector {
+	pool := config.pool
+	if pool == nil {
+		pool = newPiperPool(0)
+	}
+	return &voiceSelector{config: config, pool: pool}
+}
+
+// The piper clients for each model and how many piper processes may run at once.
+// Books converted in the same batch share a pool so each model is only set
+// up once and the limit applies to all of them together
+type piperPool struct {
+	mu      sync.Mutex
+	clients map[string]*piper.PiperClient
+	// nil if there is no limit
+	slots *semaphore.Weighted
+}
+
+// Create a pool that runs at most limit piper processes at once, or any number if limit is 0
+func newPiperPool(limit int) *piperPool {
+	pool := &piperPool{clients: make(map[string]*piper.PiperClient)}
+	if limit > 0 {
+		pool.slots = semaphore.NewWeighted(int64(limit))
+	}
+	return pool
+}
+
+// Return the options with piper clients that are shared by every book converted with them,
+// for long running processes that convert many books. At most limit piper processes run
+// at once across all the books, or any number if limit is 0
+func ShareVoices(config AudiobookArgs, limit int) AudiobookArgs {
+	config.pool = newPiperPool(limit)
+	return config
+}
+
+// Wait until piper may be run and return the function to call once it is done
+func (v *voiceSelector) acquire() func() {
+	if v.pool.slots == nil {
+		return func() {}
+	}
+	_ = v.pool.slots.Acquire(context.Background(), 1)
+	return func() { v.pool.slots.Release(1) }
+}
+
+// Return the model configured for a language and whether one was found.
+// The user's config takes priority over the built in defaults
+func (v *voiceSelector) languageVoice(language string) (string, bool) {
+	language = lang.Base(language)
+	if language == "" {
+		return "", false
+	}
+	for configured, model := range v.config.LanguageVoices {
+		if lang.Base(configured) == language {
+			return model, true
+		}
+	}
+	model, ok := DefaultLanguageVoices[language]
+	return model, ok
+}
+
+// Return the model that should read text in the given language.
+// If automatic voice selection is off, this is always the configured model
+func (v *voiceSelector) modelFor(language string) string {
+	model, _ := v.voiceFor(language, language)
+	return model
+}
+
+// Whether utf-8 characters should be kept when reading text in the given language.
+// A voice that was picked for the language can speak its characters so there is
+// no need to strip them
+func (v *voiceSelector) speakUTF8(language string) bool {
+	_, speakUTF8 := v.voiceFor(language, language)
+	return speakUTF8
+}
+
+// Return the model that reads a passage in one language inside a book in another,
+// along with whether utf-8 characters should be kept for it.
+//
+// The book's own language is read with the configured model unless automatic voice
+// selection is on. Passages in other languages use the voice for their language if
+// there is one and otherwise fall back to the voice of the book
+func (v *voiceSelector) voiceFor(language, bookLanguage string) (string, bool) {
+	if lang.Base(language) != lang.Base(bookLanguage) {
+		if model, ok := v.languageVoice(language); ok {
+			return model, true
+		}
+		return v.voiceFor(bookLanguage, bookLanguage)
+	}
+
+	if v.config.AutoVoice {
+		if model, ok := v.languageVoice(language); ok {
+			return model, true
+		}
+	}
+	return v.config.Model, v.config.SpeakUTF8
+}
+
+// Return a piper client that reads the given language, creating it if needed
+func (v *voiceSelector) clientFor(language string) (*piper.PiperClient, error) {
+	return v.clientForModel(v.modelFor(language))
+}
+
+// Return a piper client for the given model, creating it if needed
+func (v *voiceSelector) clientForModel(model string) (*piper.PiperClient, error) {
+	v.pool.mu.Lock()
+	defer v.pool.mu.Unlock()
+
+	if client, ok := v.pool.clients[model]; ok {
+		return v.withContext(client), nil
+	}
+
+	if model != v.config.Model {
+		log.Infof("Using model %s", model)
+	}
+
+	client, err := piper.NewPiperClient(model, v.config.DownloadSources)
+	if err != nil {
+		return nil, err
+	}
+	v.pool.clients[model] = client
+	return v.withContext(client), nil
+}
+
+// Return a copy of a pooled client whose piper processes are killed when the conversion is stopped,
+// since the pool may be shared by books converted in different contexts
+func (v *voiceSelector) withContext(client *piper.PiperClient) *piper.PiperClient {
+	if v.config.Context == nil {
+		return client
+	}
+	withContext := client.WithContext(v.config.Context)
+	return &withContext
+}
+
+// Return the language declared in an epub's metadata or an empty string if there is none
+func epubLanguage(filename string) string {
+	book, err := epub.Open(filename)
+	if err != nil {
+		log.Debugf("Could not read the language of %s: %v", filename, err)
+		return ""
+	}
+	defer book.Close()
+
+	for _, language := range book.Opf.Metadata.Language {
+		if base := lang.Base(language); base != "" {
+			return base
+		}
+	}
+	return ""
+}
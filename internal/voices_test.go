@@ -0,0 +1,42 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoiceSelector(t *testing.T) {
+
+	t.Run("explicit model is always used", func(t *testing.T) {
+		voices := newVoiceSelector(AudiobookArgs{Model: "en_US-lessac-medium.onnx"})
+		require.Equal(t, "en_US-lessac-medium.onnx", voices.modelFor("pl"))
+		require.False(t, voices.speakUTF8("pl"))
+	})
+
+	t.Run("auto voice uses the language map", func(t *testing.T) {
+		voices := newVoiceSelector(AudiobookArgs{
+			Model:          "en_US-lessac-medium.onnx",
+			AutoVoice:      true,
+			LanguageVoices: map[string]string{"pl": "pl_PL-darkman-medium.onnx"},
+		})
+		// the config overrides the defaults
+		require.Equal(t, "pl_PL-darkman-medium.onnx", voices.modelFor("pl-PL"))
+		require.True(t, voices.speakUTF8("pl"))
+		// defaults are used for languages not in the config
+		require.Equal(t, DefaultLanguageVoices["zh"], voices.modelFor("zh"))
+		// unknown languages fall back to the default model and strip diacritics
+		require.Equal(t, "en_US-lessac-medium.onnx", voices.modelFor("en"))
+		require.Equal(t, "en_US-lessac-medium.onnx", voices.modelFor(""))
+		require.False(t, voices.speakUTF8("en"))
+	})
+}
+
+func TestEpubLanguage(t *testing.T) {
+	require.Equal(t, "en", epubLanguage(filepath.Join("testdata", "titlepage_and_2_chapters.epub")))
+	require.Equal(t, "", epubLanguage(filepath.Join("testdata", "invalid_epub.epub")))
+}
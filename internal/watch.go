@@ -0,0 +1,298 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	log "github.com/charmbracelet/log"
+)
+
+// How long a file in the inbox has to go without changing before it is converted
+const DefaultDebounce = 5 * time.Second
+
+// Suffixes of files that are still being downloaded or written by another program
+var partialSuffixes = []string{".part", ".crdownload", ".download", ".tmp", ".partial", "~"}
+
+// The folders the watch command picks books up from and moves them to once they are converted
+type WatchFolders struct {
+	Inbox string
+	// where books that were converted are moved along with the log of their conversion
+	Done string
+	// where books that could not be converted are moved along with the log of why
+	Failed string
+}
+
+// The folders for an inbox, with done and failed folders next to it i.e.
+// ~/ToListen/inbox, ~/ToListen/done and ~/ToListen/failed
+func DefaultWatchFolders(inbox string) WatchFolders {
+	parent := filepath.Dir(filepath.Clean(inbox))
+	return WatchFolders{Inbox: inbox, Done: filepath.Join(parent, "done"), Failed: filepath.Join(parent, "failed")}
+}
+
+// Watches an inbox folder and converts the books that are put in it one at a time
+type watcher struct {
+	config   AudiobookArgs
+	folders  WatchFolders
+	debounce time.Duration
+	// converts a book; QuickPiperAudiobook except in tests
+	convert func(AudiobookArgs) (string, error)
+
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+	queue  chan string
+}
+
+// Watch the inbox and convert every book that is added to it with the pipeline of
+// QuickPiperAudiobook until ctx is done. A book is converted once it has not changed
+// for the debounce duration so that files that are still being copied are not read.
+// Books that are already in the inbox are converted when the watch starts.
+// Each book is moved to the done or failed folder with a log of its conversion next to it
+func Watch(ctx context.Context, config AudiobookArgs, folders WatchFolders, debounce time.Duration) error {
+	config, err := expandHomeDir(config)
+	if err != nil {
+		return err
+	}
+	if config.OutputDirectory == StdioPath {
+		return errSeveralToStdout
+	}
+	for _, folder := range []*string{&folders.Inbox, &folders.Done, &folders.Failed} {
+		if *folder, err = ExpandPath(*folder); err != nil {
+			return err
+		}
+	}
+	// the books share their piper clients so each model is only set up once
+	config.pool = newPiperPool(0)
+	config.Quiet = true
+
+	w := &watcher{config: config, folders: folders, debounce: debounce, convert: QuickPiperAudiobook}
+	return w.run(ctx)
+}
+
+func (w *watcher) run(ctx context.Context) error {
+	for _, dir := range []string{w.folders.Inbox, w.folders.Done, w.folders.Failed, w.config.OutputDirectory} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	fsWatcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer fsWatcher.Close()
+	if err := fsWatcher.Add(w.folders.Inbox); err != nil {
+		return fmt.Errorf("could not watch %s: %v", w.folders.Inbox, err)
+	}
+
+	w.timers = make(map[string]*time.Timer)
+	w.queue = make(chan string, 1024)
+
+	var jobs sync.WaitGroup
+	jobs.Add(1)
+	go func() {
+		defer jobs.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case path := <-w.queue:
+				w.runJob(path)
+			}
+		}
+	}()
+
+	entries, err := os.ReadDir(w.folders.Inbox)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			w.schedule(filepath.Join(w.folders.Inbox, entry.Name()))
+		}
+	}
+	log.Infof("Watching %s for books; audiobooks are written to %s", w.folders.Inbox, w.config.OutputDirectory)
+
+	defer jobs.Wait()
+	defer w.stopTimers()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-fsWatcher.Events:
+			if !ok {
+				return nil
+			}
+			switch {
+			case event.Has(fsnotify.Create) || event.Has(fsnotify.Write):
+				w.schedule(event.Name)
+			case event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename):
+				w.cancel(event.Name)
+			}
+		case err, ok := <-fsWatcher.Errors:
+			if !ok {
+				return nil
+			}
+			log.Errorf("Error watching %s: %v", w.folders.Inbox, err)
+		}
+	}
+}
+
+// Whether a file in the inbox is a book to convert and not a hidden file,
+// a file that is still being downloaded or one in a format that can't be read
+func isWatchedFile(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, suffix := range partialSuffixes {
+		if strings.HasSuffix(strings.ToLower(name), suffix) {
+			return false
+		}
+	}
+	return isBatchExtension(filepath.Ext(name))
+}
+
+// Queue a file to be converted once it has stopped changing for the debounce duration
+func (w *watcher) schedule(path string) {
+	if !isWatchedFile(path) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.timers[path]; ok {
+		timer.Reset(w.debounce)
+		return
+	}
+	w.timers[path] = time.AfterFunc(w.debounce, func() {
+		w.mu.Lock()
+		delete(w.timers, path)
+		w.mu.Unlock()
+
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			return
+		}
+		log.Infof("Queued %s", path)
+		w.queue <- path
+	})
+}
+
+// Stop waiting to queue a file that was removed from the inbox
+func (w *watcher) cancel(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if timer, ok := w.timers[path]; ok {
+		timer.Stop()
+		delete(w.timers, path)
+	}
+}
+
+func (w *watcher) stopTimers() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for path, timer := range w.timers {
+		timer.Stop()
+		delete(w.timers, path)
+	}
+}
+
+// Convert a book from the inbox, logging to a file as well as the terminal, and move
+// the book and its log to the done or failed folder
+func (w *watcher) runJob(path string) {
+	if _, err := os.Stat(path); err != nil {
+		// the book was removed while it waited in the queue
+		return
+	}
+
+	jobLog, err := os.CreateTemp("", "piper-watch-*.log")
+	if err != nil {
+		log.Errorf("Could not create a log for %s: %v", path, err)
+		return
+	}
+	defer os.Remove(jobLog.Name())
+
+	// jobs run one at a time so the logger can be pointed at the log of the current one
+	log.SetOutput(io.MultiWriter(os.Stderr, jobLog))
+	start := time.Now()
+	log.Infof("Converting %s", path)
+	config := w.config
+	config.FileName = path
+	outputName, convertErr := w.convert(config)
+	if convertErr != nil {
+		log.Errorf("Failed to convert %s: %v", path, convertErr)
+	} else {
+		log.Infof("Converted %s to %s in %s", path, outputName, time.Since(start).Round(time.Second))
+	}
+	log.SetOutput(os.Stderr)
+	jobLog.Close()
+
+	dest := w.folders.Done
+	if convertErr != nil {
+		dest = w.folders.Failed
+	}
+	moved, err := moveFile(path, dest)
+	if err != nil {
+		log.Errorf("Could not move %s to %s: %v", path, dest, err)
+		return
+	}
+	if _, err := moveFileTo(jobLog.Name(), moved+".log"); err != nil {
+		log.Errorf("Could not save the log of %s: %v", path, err)
+	}
+}
+
+// Move a file into a directory, adding a number to its name if there already is a file
+// with the same name there. Returns the new path of the file
+func moveFile(path, dir string) (string, error) {
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	dest := filepath.Join(dir, name)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			break
+		}
+		dest = filepath.Join(dir, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), i, ext))
+	}
+	return moveFileTo(path, dest)
+}
+
+// Move a file to a new path, copying it if the path is on another file system
+func moveFileTo(path, dest string) (string, error) {
+	err := os.Rename(path, dest)
+	if err == nil {
+		return dest, nil
+	}
+	if !errors.Is(err, syscall.EXDEV) {
+		return "", err
+	}
+
+	source, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer source.Close()
+	target, err := os.Create(dest)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return "", err
+	}
+	if err := target.Close(); err != nil {
+		return "", err
+	}
+	return dest, os.Remove(path)
+}
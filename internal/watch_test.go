@@ -0,0 +1,98 @@
+// Copyright 2025 Colton Loftus
+// SPDX-License-Identifier: AGPL-3.0-only
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultWatchFolders(t *testing.T) {
+	folders := DefaultWatchFolders("/home/me/ToListen/inbox/")
+	require.Equal(t, "/home/me/ToListen/done", folders.Done)
+	require.Equal(t, "/home/me/ToListen/failed", folders.Failed)
+}
+
+func TestIsWatchedFile(t *testing.T) {
+	require.True(t, isWatchedFile("/inbox/book.epub"))
+	require.True(t, isWatchedFile("/inbox/notes.md"))
+	require.False(t, isWatchedFile("/inbox/.book.epub"))
+	require.False(t, isWatchedFile("/inbox/book.epub.part"))
+	require.False(t, isWatchedFile("/inbox/book.pdf.crdownload"))
+	require.False(t, isWatchedFile("/inbox/cover.jpg"))
+}
+
+func TestMoveFile(t *testing.T) {
+	from := t.TempDir()
+	to := t.TempDir()
+	writeFiles(t, from, "book.txt")
+	writeFiles(t, to, "book.txt")
+
+	moved, err := moveFile(filepath.Join(from, "book.txt"), to)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(to, "book (2).txt"), moved)
+	require.FileExists(t, moved)
+	require.NoFileExists(t, filepath.Join(from, "book.txt"))
+}
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	folders := DefaultWatchFolders(filepath.Join(root, "inbox"))
+	writeFiles(t, folders.Inbox, "existing.txt")
+
+	var mu sync.Mutex
+	var converted []string
+	w := &watcher{
+		config:   AudiobookArgs{OutputDirectory: filepath.Join(root, "audiobooks")},
+		folders:  folders,
+		debounce: 50 * time.Millisecond,
+		convert: func(config AudiobookArgs) (string, error) {
+			mu.Lock()
+			converted = append(converted, filepath.Base(config.FileName))
+			mu.Unlock()
+			if filepath.Base(config.FileName) == "broken.txt" {
+				return "", fmt.Errorf("could not read it")
+			}
+			return filepath.Join(config.OutputDirectory, "out.wav"), nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(folders.Done, "existing.txt"))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	writeFiles(t, folders.Inbox, "broken.txt", "book.md.part", "cover.jpg")
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(filepath.Join(folders.Failed, "broken.txt.log"))
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	mu.Lock()
+	require.Equal(t, []string{"existing.txt", "broken.txt"}, converted)
+	mu.Unlock()
+	require.FileExists(t, filepath.Join(folders.Inbox, "book.md.part"))
+	require.FileExists(t, filepath.Join(folders.Inbox, "cover.jpg"))
+
+	jobLog, err := os.ReadFile(filepath.Join(folders.Failed, "broken.txt.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(jobLog), "could not read it")
+	jobLog, err = os.ReadFile(filepath.Join(folders.Done, "existing.txt.log"))
+	require.NoError(t, err)
+	require.Contains(t, string(jobLog), "out.wav")
+}